@@ -18,6 +18,7 @@ package v1alpha1
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
@@ -43,6 +44,11 @@ type AppSpec struct {
 	// +optional
 	ImageTag string `json:"imageTag,omitempty"`
 
+	// when set, the registry is polled for new tags and matching tags are deployed automatically
+	// +kubebuilder:validation:Optional
+	// +nullable
+	ImageWatch *ImageWatchSpec `json:"imageWatch,omitempty"`
+
 	AppCommonSpec `json:",inline"`
 
 	// +kubebuilder:validation:Optional
@@ -58,6 +64,11 @@ type AppSpec struct {
 	// +nullable
 	Prometheus *PrometheusSpec `json:"prometheus,omitempty"`
 
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// +optional
+	Alerts *AlertsSpec `json:"alerts,omitempty"`
+
 	// +kubebuilder:validation:Optional
 	// +nullable
 	Targets []TargetConfig `json:"targets"`
@@ -99,6 +110,11 @@ type AppCommonSpec struct {
 	// +kubebuilder:validation:Optional
 	// +optional
 	Probes ProbeConfig `json:"probes,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// +optional
+	Tracing *TracingSpec `json:"tracing,omitempty"`
 }
 
 // AppStatus defines the observed state of App
@@ -109,6 +125,7 @@ type AppStatus struct {
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
 
 // App is the Schema for the apps API
 type App struct {
@@ -168,6 +185,27 @@ const (
 	DeployHalt   DeployMode = "halt"
 )
 
+// +kubebuilder:validation:Enum=semver;regex
+type TagStrategy string
+
+const (
+	TagStrategySemver TagStrategy = "semver"
+	TagStrategyRegex  TagStrategy = "regex"
+)
+
+type ImageWatchSpec struct {
+	// semver deploys the highest version, regex deploys the most recently created image with a matching tag
+	Strategy TagStrategy `json:"strategy"`
+
+	// tags must match this pattern to be considered, required for regex
+	// +optional
+	Pattern string `json:"pattern,omitempty"`
+
+	// how often to poll the registry, defaults to the operator's poll interval
+	// +optional
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+}
+
 type TargetConfig struct {
 	Name string `json:"name"`
 
@@ -215,6 +253,57 @@ type PrometheusSpec struct {
 	Rules []promv1.Rule `json:"rules,omitempty"`
 }
 
+// AlertsSpec turns on a set of standard alerts for the app
+type AlertsSpec struct {
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// +optional
+	SLO *SLOSpec `json:"slo,omitempty"`
+
+	// alert when the app's containers keep restarting
+	// +optional
+	CrashLoop bool `json:"crashLoop,omitempty"`
+
+	// alert when pods can't be scheduled
+	// +optional
+	PendingPods bool `json:"pendingPods,omitempty"`
+
+	// labels added to each alert, for routing in Alertmanager
+	// +kubebuilder:validation:Optional
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SLOSpec defines objectives for the app's requests, as measured by Istio
+type SLOSpec struct {
+	// percentage of requests that should succeed (not return 5xx), e.g. "99.9"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	Availability string `json:"availability,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// +optional
+	Latency *LatencySLO `json:"latency,omitempty"`
+}
+
+type LatencySLO struct {
+	// requests should complete within this many milliseconds. Must be one of Istio's histogram buckets
+	ThresholdMillis int32 `json:"thresholdMs"`
+
+	// percentage of requests that should complete within the threshold, e.g. "99"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Target string `json:"target"`
+}
+
+// TracingSpec configures Istio's tracing of the app's requests
+type TracingSpec struct {
+	// percentage of requests to trace, e.g. "5". Defaults to the mesh's rate of 1%
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	SamplingRate string `json:"samplingRate,omitempty"`
+}
+
 func (a *AppSpec) ScaleSpecForTarget(target string) *ScaleSpec {
 	scale := a.Scale.DeepCopy()
 	tc := a.GetTargetConfig(target)
@@ -310,6 +399,34 @@ func (p *ProbeConfig) GetReadinessTimeout() time.Duration {
 	return time.Second * time.Duration(timeout)
 }
 
+// IstioLatencyBuckets are the upper bounds of Istio's request duration histogram, in milliseconds
+var IstioLatencyBuckets = []int32{1, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000, 60000,
+	300000, 600000, 1800000, 3600000}
+
+// ErrorBudget returns the fraction of requests that may miss an objective, e.g. 0.001 for "99.9"
+func ErrorBudget(objective string) (float64, error) {
+	pct, err := strconv.ParseFloat(objective, 64)
+	if err != nil {
+		return 0, err
+	}
+	if pct <= 0 || pct >= 100 {
+		return 0, fmt.Errorf("objective must be between 0 and 100")
+	}
+	return (100 - pct) / 100, nil
+}
+
+// SamplingPercentage returns the percentage of requests to trace, from 0 to 100
+func (t *TracingSpec) SamplingPercentage() (float64, error) {
+	pct, err := strconv.ParseFloat(t.SamplingRate, 64)
+	if err != nil {
+		return 0, err
+	}
+	if pct < 0 || pct > 100 {
+		return 0, fmt.Errorf("sampling rate must be between 0 and 100")
+	}
+	return pct, nil
+}
+
 // ---------------------------------------------------------------------------//
 // a duplication of core Kube types, repeated here to avoid dependency on intOrString type
 // Probe describes a health check to be performed against a container to determine whether it is
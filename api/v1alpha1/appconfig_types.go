@@ -44,6 +44,7 @@ type ConfigType string
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster
+// +kubebuilder:storageversion
 // +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.type`
 
 // AppConfig is the Schema for the appconfigs API
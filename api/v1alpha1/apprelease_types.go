@@ -28,6 +28,11 @@ type AppReleaseSpec struct {
 	Build  string `json:"build"`
 	Config string `json:"config"`
 
+	// image that pods run, pinned to the build's digest when the release was created.
+	// releases created before this was set use the build's current image
+	// +optional
+	Image string `json:"image,omitempty"`
+
 	// num desired default state, autoscaling could change desired in status
 	NumDesired        int32       `json:"numDesired"`
 	Role              ReleaseRole `json:"role"`
@@ -54,8 +59,39 @@ type PodStatus struct {
 	Pod     string `json:"pod"`
 	Reason  string `json:"reason"`
 	Message string `json:"message"`
+
+	// +kubebuilder:validation:Optional
+	// +nullable
+	Containers []PodContainerStatus `json:"containers,omitempty"`
 }
 
+// PodContainerStatus summarizes the state of a container, including why it's waiting or has terminated
+type PodContainerStatus struct {
+	Name         string `json:"name"`
+	Ready        bool   `json:"ready"`
+	RestartCount int32  `json:"restartCount"`
+	// waiting, running, or terminated
+	State string `json:"state"`
+	// +kubebuilder:validation:Optional
+	Reason string `json:"reason,omitempty"`
+	// +kubebuilder:validation:Optional
+	Message string `json:"message,omitempty"`
+	// +kubebuilder:validation:Optional
+	ExitCode int32 `json:"exitCode,omitempty"`
+
+	// reason and exit code of the last time the container terminated, set after it has restarted
+	// +kubebuilder:validation:Optional
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+	// +kubebuilder:validation:Optional
+	LastExitCode int32 `json:"lastExitCode,omitempty"`
+}
+
+const (
+	ContainerStateWaiting    = "waiting"
+	ContainerStateRunning    = "running"
+	ContainerStateTerminated = "terminated"
+)
+
 type ReleaseState string
 
 func (rs ReleaseState) String() string {
@@ -57,6 +57,11 @@ type AppTargetSpec struct {
 	// +kubebuilder:validation:Optional
 	// +nullable
 	Prometheus *PrometheusSpec `json:"prometheus,omitempty"`
+
+	// +kubebuilder:validation:Optional
+	// +nullable
+	// +optional
+	Alerts *AlertsSpec `json:"alerts,omitempty"`
 }
 
 type AppTargetPhase string
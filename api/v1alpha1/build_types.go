@@ -37,10 +37,42 @@ type BuildSpec struct {
 	Image     string           `json:"image"`
 	Tag       string           `json:"tag"`
 	CreatedAt metav1.Timestamp `json:"createdAt"`
+
+	// content digest of the image, i.e. sha256:...
+	// +optional
+	Digest string `json:"digest,omitempty"`
+
+	// +optional
+	// +nullable
+	Source *BuildSource `json:"source,omitempty"`
+}
+
+// BuildSource describes the source code that a build was created from
+type BuildSource struct {
+	// +optional
+	Commit string `json:"commit,omitempty"`
+	// +optional
+	Branch string `json:"branch,omitempty"`
+	// +optional
+	Author string `json:"author,omitempty"`
+	// link to the CI job that produced the build
+	// +optional
+	CIURL string `json:"ciUrl,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // BuildStatus defines the observed state of Build
 type BuildStatus struct {
+	// error from the last failed attempt to resolve the image digest
+	// +optional
+	DigestError string `json:"digestError,omitempty"`
+	// number of consecutive failed digest resolutions
+	// +optional
+	DigestAttempts int32 `json:"digestAttempts,omitempty"`
+	// +optional
+	// +nullable
+	LastDigestAttempt *metav1.Time `json:"lastDigestAttempt,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -49,6 +81,7 @@ type BuildStatus struct {
 // +kubebuilder:printcolumn:name="Registry",type=string,JSONPath=`.spec.registry`
 // +kubebuilder:printcolumn:name="Image",type=string,JSONPath=`.spec.image`
 // +kubebuilder:printcolumn:name="Tag",type=string,JSONPath=`.spec.tag`
+// +kubebuilder:printcolumn:name="Commit",type=string,JSONPath=`.spec.source.commit`
 
 // Build is the Schema for the builds API
 type Build struct {
@@ -104,6 +137,14 @@ func (b *Build) FullImageWithTag() string {
 	return fullImage
 }
 
+// ImageReference returns the image that pods should run, pinned to the digest when it's known
+func (b *Build) ImageReference() string {
+	if b.Spec.Digest != "" {
+		return fmt.Sprintf("%s@%s", b.ImagePath(), b.Spec.Digest)
+	}
+	return b.FullImageWithTag()
+}
+
 func (b *Build) ShortName() string {
 	name := b.Spec.Image
 	if b.Spec.Tag != "" {
@@ -112,6 +153,46 @@ func (b *Build) ShortName() string {
 	return name
 }
 
+func (b *Build) ShortCommit() string {
+	if b.Spec.Source == nil {
+		return ""
+	}
+	return b.Spec.Source.ShortCommit()
+}
+
+func (s *BuildSource) ShortCommit() string {
+	if len(s.Commit) > 7 {
+		return s.Commit[:7]
+	}
+	return s.Commit
+}
+
+// MergeWith overrides fields with values that are set in other
+func (s *BuildSource) MergeWith(other *BuildSource) {
+	if other == nil {
+		return
+	}
+	if other.Commit != "" {
+		s.Commit = other.Commit
+	}
+	if other.Branch != "" {
+		s.Branch = other.Branch
+	}
+	if other.Author != "" {
+		s.Author = other.Author
+	}
+	if other.CIURL != "" {
+		s.CIURL = other.CIURL
+	}
+	if other.Message != "" {
+		s.Message = other.Message
+	}
+}
+
+func (s *BuildSource) IsEmpty() bool {
+	return s.Commit == "" && s.Branch == "" && s.Author == "" && s.CIURL == "" && s.Message == ""
+}
+
 func NewBuild(registry, image, tag string) *Build {
 	b := Build{
 		Spec: BuildSpec{
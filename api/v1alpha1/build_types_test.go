@@ -28,3 +28,28 @@ func TestBuildUniqueName(t *testing.T) {
 	}
 	assert.True(t, strings.HasPrefix(b.GetUniqueName(), "inv-l1d-NAME-a"))
 }
+
+func TestBuildSourceMergeWith(t *testing.T) {
+	s := &BuildSource{
+		Commit: "0123456789abcdef",
+		Branch: "main",
+	}
+	s.MergeWith(&BuildSource{
+		Branch: "release",
+		Author: "dev@example.com",
+	})
+	assert.Equal(t, "0123456789abcdef", s.Commit)
+	assert.Equal(t, "release", s.Branch)
+	assert.Equal(t, "dev@example.com", s.Author)
+	assert.Equal(t, "0123456", s.ShortCommit())
+	assert.False(t, s.IsEmpty())
+	assert.True(t, (&BuildSource{}).IsEmpty())
+}
+
+func TestBuildImageReference(t *testing.T) {
+	b := NewBuild("myregistry.com", "image", "v1")
+	assert.Equal(t, "myregistry.com/image:v1", b.ImageReference())
+
+	b.Spec.Digest = "sha256:abcd"
+	assert.Equal(t, "myregistry.com/image@sha256:abcd", b.ImageReference())
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1alpha1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,12 +38,36 @@ type ClusterConfigSpec struct {
 	// +kubebuilder:validation:Optional
 	// +nullable
 	AWS *AWSClusterSpec `json:"aws"`
+	// set for existing clusters adopted from a kubeconfig
+	// +kubebuilder:validation:Optional
+	// +nullable
+	Kubeconfig *KubeconfigClusterSpec `json:"kubeconfig,omitempty"`
 	// +kubebuilder:validation:Optional
 	// +nullable
 	Targets []string `json:"targets"`
 	// +kubebuilder:validation:Optional
 	// +nullable
 	ComponentConfig map[string]ComponentConfig `json:"componentConfig"`
+	// defaults filled in on apps when they are saved
+	// +kubebuilder:validation:Optional
+	// +nullable
+	AppDefaults *AppDefaults `json:"appDefaults,omitempty"`
+}
+
+// AppDefaults are applied to apps that leave the corresponding fields empty
+type AppDefaults struct {
+	// resource requests and limits, applied to each resource the app doesn't set
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// +optional
+	Scale ScaleSpec `json:"scale,omitempty"`
+	// deploy mode for targets, latest when unset
+	// +optional
+	DeployMode DeployMode `json:"deployMode,omitempty"`
+	// targets that new apps without any targets are deployed to, all cluster targets when unset
+	// +kubebuilder:validation:Optional
+	// +nullable
+	Targets []string `json:"targets,omitempty"`
 }
 
 // ClusterConfigStatus defines the observed state of ClusterConfig
@@ -86,6 +111,11 @@ type AWSClusterSpec struct {
 	AdminGroups []string `json:"adminGroups,omitempty"`
 }
 
+type KubeconfigClusterSpec struct {
+	// context in the kubeconfig that the cluster was adopted from
+	Context string `json:"context"`
+}
+
 type AWSClusterStatus struct {
 	// current vpc id
 	VpcId string `json:"vpcId"`
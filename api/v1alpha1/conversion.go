@@ -0,0 +1,75 @@
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k11n/konstellation/api/v1beta1"
+)
+
+// ConvertTo converts this App to the hub version (v1beta1)
+func (a *App) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.App)
+	dst.ObjectMeta = a.ObjectMeta
+	// the spec is structurally identical between versions
+	if err := convertJSON(&a.Spec, &dst.Spec); err != nil {
+		return err
+	}
+	return convertJSON(&a.Status, &dst.Status)
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version
+func (a *App) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.App)
+	a.ObjectMeta = src.ObjectMeta
+	if err := convertJSON(&src.Spec, &a.Spec); err != nil {
+		return err
+	}
+	return convertJSON(&src.Status, &a.Status)
+}
+
+// ConvertTo converts this AppConfig to the hub version (v1beta1). The YAML document is stored as a
+// structured object, comments and key ordering aren't preserved
+func (c *AppConfig) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.AppConfig)
+	dst.ObjectMeta = c.ObjectMeta
+	dst.Type = v1beta1.ConfigType(c.Type)
+	dst.Config = nil
+	if len(c.ConfigYaml) == 0 {
+		return nil
+	}
+	content, err := yaml.YAMLToJSON(c.ConfigYaml)
+	if err != nil {
+		return err
+	}
+	dst.Config = &runtime.RawExtension{Raw: content}
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version
+func (c *AppConfig) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.AppConfig)
+	c.ObjectMeta = src.ObjectMeta
+	c.Type = ConfigType(src.Type)
+	c.ConfigYaml = nil
+	if src.Config == nil || len(src.Config.Raw) == 0 {
+		return nil
+	}
+	content, err := yaml.JSONToYAML(src.Config.Raw)
+	if err != nil {
+		return err
+	}
+	c.ConfigYaml = content
+	return nil
+}
+
+func convertJSON(src, dst interface{}) error {
+	content, err := json.Marshal(src)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(content, dst)
+}
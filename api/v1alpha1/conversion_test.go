@@ -0,0 +1,61 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1beta1"
+)
+
+func TestAppConversion(t *testing.T) {
+	app := &App{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp"},
+		Spec: AppSpec{
+			Image:    "myapp",
+			ImageTag: "v1",
+			AppCommonSpec: AppCommonSpec{
+				Ports: []PortSpec{{Name: "http", Port: 80}},
+				Probes: ProbeConfig{
+					Readiness: &Probe{Handler: Handler{HTTPGet: &HTTPGetAction{Path: "/ready", Port: "http"}}},
+				},
+			},
+			Scale: ScaleSpec{Min: 1, Max: 4},
+			Targets: []TargetConfig{
+				{Name: "production", Ingress: &IngressConfig{Hosts: []string{"myapp.com"}}},
+			},
+		},
+		Status: AppStatus{ActiveTargets: []string{"production"}},
+	}
+
+	hub := &v1beta1.App{}
+	assert.NoError(t, app.ConvertTo(hub))
+	assert.Equal(t, "myapp", hub.Name)
+	assert.Equal(t, "/ready", hub.Spec.Probes.Readiness.HTTPGet.Path)
+	assert.Equal(t, []string{"myapp.com"}, hub.Spec.Targets[0].Ingress.Hosts)
+
+	converted := &App{}
+	assert.NoError(t, converted.ConvertFrom(hub))
+	assert.Equal(t, app, converted)
+}
+
+func TestAppConfigConversion(t *testing.T) {
+	conf := NewAppConfig("myapp", "production")
+	assert.NoError(t, conf.SetConfigYAML([]byte("key: value\nnested:\n  port: 80\n")))
+
+	hub := &v1beta1.AppConfig{}
+	assert.NoError(t, conf.ConvertTo(hub))
+	assert.Equal(t, v1beta1.ConfigTypeApp, hub.Type)
+	assert.JSONEq(t, `{"key": "value", "nested": {"port": 80}}`, string(hub.Config.Raw))
+
+	converted := &AppConfig{}
+	assert.NoError(t, converted.ConvertFrom(hub))
+	assert.Equal(t, conf.Labels, converted.Labels)
+	assert.Equal(t, conf.GetConfig(), converted.GetConfig())
+
+	// empty configs stay empty
+	conf.ConfigYaml = nil
+	assert.NoError(t, conf.ConvertTo(hub))
+	assert.Nil(t, hub.Config)
+}
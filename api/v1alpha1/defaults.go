@@ -0,0 +1,121 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	DefaultProbeSuccessThreshold = 1
+	DefaultProbeFailureThreshold = 3
+)
+
+// SetDefaults fills in fields left empty with the values the controllers would otherwise assume,
+// taking cluster-wide defaults into account. defaults may be nil
+func (a *App) SetDefaults(defaults *AppDefaults) {
+	if defaults == nil {
+		defaults = &AppDefaults{}
+	}
+
+	for i := range a.Spec.Ports {
+		if a.Spec.Ports[i].Protocol == "" {
+			a.Spec.Ports[i].Protocol = corev1.ProtocolTCP
+		}
+	}
+
+	setDefaultResources(&a.Spec.Resources, &defaults.Resources)
+	setDefaultProbes(&a.Spec.Probes)
+
+	scale := &a.Spec.Scale
+	if scale.Min == 0 {
+		scale.Min = defaults.Scale.Min
+		if scale.Min == 0 {
+			scale.Min = 1
+		}
+	}
+	if scale.Max == 0 && defaults.Scale.Max >= scale.Min {
+		// only when targets wouldn't end up with min > max
+		maxMin := int32(0)
+		for _, tc := range a.Spec.Targets {
+			if tc.Scale.Min > maxMin {
+				maxMin = tc.Scale.Min
+			}
+		}
+		if defaults.Scale.Max >= maxMin {
+			scale.Max = defaults.Scale.Max
+		}
+	}
+	if scale.TargetCPUUtilization == 0 {
+		scale.TargetCPUUtilization = defaults.Scale.TargetCPUUtilization
+	}
+
+	deployMode := defaults.DeployMode
+	if deployMode == "" {
+		deployMode = DeployLatest
+	}
+	for i := range a.Spec.Targets {
+		tc := &a.Spec.Targets[i]
+		if tc.DeployMode == "" {
+			tc.DeployMode = deployMode
+		}
+		setDefaultProbes(&tc.Probes)
+	}
+}
+
+// SetDefaultTargets adds a TargetConfig for each default target when the app doesn't define any.
+// Without configured defaults, apps are deployed to every cluster target
+func (a *App) SetDefaultTargets(defaults *AppDefaults, clusterTargets []string) {
+	if len(a.Spec.Targets) > 0 {
+		return
+	}
+	targets := clusterTargets
+	if defaults != nil && len(defaults.Targets) > 0 {
+		targets = defaults.Targets
+	}
+	for _, target := range targets {
+		a.Spec.Targets = append(a.Spec.Targets, TargetConfig{Name: target})
+	}
+}
+
+// sets default requests and limits for resources that the app leaves unspecified
+func setDefaultResources(res *corev1.ResourceRequirements, defaults *corev1.ResourceRequirements) {
+	for name, quantity := range defaults.Requests {
+		if _, ok := res.Requests[name]; ok {
+			continue
+		}
+		// Kubernetes uses the limit as the request
+		if _, ok := res.Limits[name]; ok {
+			continue
+		}
+		if res.Requests == nil {
+			res.Requests = corev1.ResourceList{}
+		}
+		res.Requests[name] = quantity.DeepCopy()
+	}
+	for name, quantity := range defaults.Limits {
+		if _, ok := res.Limits[name]; ok {
+			continue
+		}
+		// a limit below the request would be invalid
+		if request, ok := res.Requests[name]; ok && request.Cmp(quantity) > 0 {
+			continue
+		}
+		if res.Limits == nil {
+			res.Limits = corev1.ResourceList{}
+		}
+		res.Limits[name] = quantity.DeepCopy()
+	}
+}
+
+func setDefaultProbes(probes *ProbeConfig) {
+	for _, probe := range []*Probe{probes.Liveness, probes.Readiness, probes.Startup} {
+		if probe == nil {
+			continue
+		}
+		if probe.SuccessThreshold == 0 {
+			probe.SuccessThreshold = DefaultProbeSuccessThreshold
+		}
+		if probe.FailureThreshold == 0 {
+			probe.FailureThreshold = DefaultProbeFailureThreshold
+		}
+	}
+}
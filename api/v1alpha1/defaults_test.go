@@ -0,0 +1,70 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestAppSetDefaults(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			AppCommonSpec: AppCommonSpec{
+				Ports: []PortSpec{{Name: "http", Port: 80}},
+				Resources: corev1.ResourceRequirements{
+					Limits: corev1.ResourceList{
+						corev1.ResourceMemory: resource.MustParse("50Mi"),
+					},
+				},
+				Probes: ProbeConfig{
+					Readiness: &Probe{Handler: Handler{HTTPGet: &HTTPGetAction{Port: "http"}}},
+				},
+			},
+			Targets: []TargetConfig{
+				{Name: "staging"},
+				{Name: "production", DeployMode: DeployHalt, Scale: ScaleSpec{Min: 4}},
+			},
+		},
+	}
+	app.SetDefaults(&AppDefaults{
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("100Mi"),
+			},
+		},
+		Scale: ScaleSpec{Max: 3},
+	})
+
+	assert.Equal(t, corev1.ProtocolTCP, app.Spec.Ports[0].Protocol)
+	cpu := app.Spec.Resources.Requests[corev1.ResourceCPU]
+	assert.Equal(t, "100m", cpu.String())
+	// memory limit is used as the request
+	_, ok := app.Spec.Resources.Requests[corev1.ResourceMemory]
+	assert.False(t, ok)
+
+	assert.EqualValues(t, 1, app.Spec.Scale.Min)
+	// production would have min > max
+	assert.EqualValues(t, 0, app.Spec.Scale.Max)
+
+	assert.EqualValues(t, DefaultProbeFailureThreshold, app.Spec.Probes.Readiness.FailureThreshold)
+	assert.Equal(t, DeployLatest, app.Spec.Targets[0].DeployMode)
+	assert.Equal(t, DeployHalt, app.Spec.Targets[1].DeployMode)
+	assert.Empty(t, app.Validate())
+}
+
+func TestAppSetDefaultTargets(t *testing.T) {
+	app := &App{}
+	app.SetDefaultTargets(nil, []string{"staging", "production"})
+	assert.Len(t, app.Spec.Targets, 2)
+
+	app = &App{}
+	app.SetDefaultTargets(&AppDefaults{Targets: []string{"staging"}}, []string{"staging", "production"})
+	assert.Len(t, app.Spec.Targets, 1)
+	assert.Equal(t, "staging", app.Spec.Targets[0].Name)
+
+	app.SetDefaultTargets(nil, []string{"staging", "production"})
+	assert.Len(t, app.Spec.Targets, 1)
+}
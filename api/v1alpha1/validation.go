@@ -0,0 +1,254 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Validate checks the app spec for errors that can be determined without looking at the cluster
+func (a *App) Validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	ports := make(map[string]bool)
+	for i, p := range a.Spec.Ports {
+		portPath := specPath.Child("ports").Index(i)
+		if p.Name == "" {
+			errs = append(errs, field.Required(portPath.Child("name"), "ports must be named"))
+		} else if ports[p.Name] {
+			errs = append(errs, field.Duplicate(portPath.Child("name"), p.Name))
+		}
+		if p.Port <= 0 || p.Port > 65535 {
+			errs = append(errs, field.Invalid(portPath.Child("port"), p.Port, "must be between 1 and 65535"))
+		}
+		ports[p.Name] = true
+	}
+
+	errs = append(errs, validateProbes(&a.Spec.Probes, ports, specPath.Child("probes"))...)
+	errs = append(errs, validateScale(&a.Spec.Scale, specPath.Child("scale"))...)
+	errs = append(errs, validateResources(&a.Spec.Resources, specPath.Child("resources"))...)
+
+	if a.Spec.Alerts != nil {
+		errs = append(errs, validateAlerts(a.Spec.Alerts, len(a.Spec.Ports) > 0, specPath.Child("alerts"))...)
+	}
+
+	if t := a.Spec.Tracing; t != nil && t.SamplingRate != "" {
+		if _, err := t.SamplingPercentage(); err != nil {
+			errs = append(errs, field.Invalid(specPath.Child("tracing", "samplingRate"), t.SamplingRate, err.Error()))
+		}
+	}
+
+	for i, dep := range a.Spec.Dependencies {
+		if dep.Name == "" {
+			errs = append(errs, field.Required(specPath.Child("dependencies").Index(i).Child("name"), ""))
+		}
+	}
+
+	if iw := a.Spec.ImageWatch; iw != nil {
+		watchPath := specPath.Child("imageWatch")
+		if iw.Strategy == TagStrategyRegex && iw.Pattern == "" {
+			errs = append(errs, field.Required(watchPath.Child("pattern"), "pattern is required for the regex strategy"))
+		}
+		if iw.Pattern != "" {
+			if _, err := regexp.Compile(iw.Pattern); err != nil {
+				errs = append(errs, field.Invalid(watchPath.Child("pattern"), iw.Pattern, err.Error()))
+			}
+		}
+	}
+
+	targets := make(map[string]bool)
+	for i := range a.Spec.Targets {
+		tc := &a.Spec.Targets[i]
+		targetPath := specPath.Child("targets").Index(i)
+		if tc.Name == "" {
+			errs = append(errs, field.Required(targetPath.Child("name"), ""))
+		} else if targets[tc.Name] {
+			errs = append(errs, field.Duplicate(targetPath.Child("name"), tc.Name))
+		}
+		targets[tc.Name] = true
+
+		if tc.Ingress != nil {
+			ingressPath := targetPath.Child("ingress")
+			if len(tc.Ingress.Hosts) == 0 {
+				errs = append(errs, field.Required(ingressPath.Child("hosts"), "ingress requires at least one host"))
+			}
+			if tc.Ingress.Port != "" {
+				if !ports[tc.Ingress.Port] {
+					errs = append(errs, field.NotFound(ingressPath.Child("port"), tc.Ingress.Port))
+				}
+			} else if len(a.Spec.Ports) == 0 {
+				errs = append(errs, field.Required(specPath.Child("ports"), "ingress requires the app to declare a port"))
+			}
+		}
+
+		errs = append(errs, validateProbes(&tc.Probes, ports, targetPath.Child("probes"))...)
+		// only check target overrides, so errors in the base scale aren't repeated for each target
+		if tc.Scale.Min != 0 || tc.Scale.Max != 0 || tc.Scale.TargetCPUUtilization != 0 {
+			errs = append(errs, validateScale(a.Spec.ScaleSpecForTarget(tc.Name), targetPath.Child("scale"))...)
+		}
+		if len(tc.Resources.Requests) != 0 || len(tc.Resources.Limits) != 0 {
+			errs = append(errs, validateResources(a.Spec.ResourcesForTarget(tc.Name), targetPath.Child("resources"))...)
+		}
+	}
+
+	return errs
+}
+
+// Validate checks that the config is well formed and labeled for an app or shared config
+func (c *AppConfig) Validate() field.ErrorList {
+	var errs field.ErrorList
+	labelsPath := field.NewPath("metadata", "labels")
+	switch c.Type {
+	case ConfigTypeApp:
+		if c.GetAppName() == "" {
+			errs = append(errs, field.Required(labelsPath.Key(AppLabel), "app configs must be labeled with the app"))
+		}
+	case ConfigTypeShared:
+		if c.GetSharedName() == "" {
+			errs = append(errs, field.Required(labelsPath.Key(SharedConfigLabel), "shared configs must be labeled with their name"))
+		}
+	default:
+		errs = append(errs, field.NotSupported(field.NewPath("type"), c.Type,
+			[]string{string(ConfigTypeApp), string(ConfigTypeShared)}))
+	}
+
+	if len(c.ConfigYaml) > 0 {
+		out := yaml.Node{}
+		if err := yaml.Unmarshal(c.ConfigYaml, &out); err != nil {
+			errs = append(errs, field.Invalid(field.NewPath("config"), string(c.ConfigYaml), "config contains invalid YAML"))
+		}
+	}
+	return errs
+}
+
+// Validate checks that targets are listed once each
+func (l *LinkedServiceAccount) Validate() field.ErrorList {
+	var errs field.ErrorList
+	targetsPath := field.NewPath("spec", "targets")
+	seen := make(map[string]bool)
+	for i, target := range l.Spec.Targets {
+		if target == "" {
+			errs = append(errs, field.Required(targetsPath.Index(i), ""))
+		} else if seen[target] {
+			errs = append(errs, field.Duplicate(targetsPath.Index(i), target))
+		}
+		seen[target] = true
+	}
+	if l.Spec.AWS != nil && len(l.Spec.AWS.PolicyARNs) == 0 {
+		errs = append(errs, field.Required(field.NewPath("spec", "aws", "policyArns"), ""))
+	}
+	return errs
+}
+
+// Validate checks nodepool sizing
+func (n *Nodepool) Validate() field.ErrorList {
+	var errs field.ErrorList
+	specPath := field.NewPath("spec")
+	if n.Spec.MachineType == "" {
+		errs = append(errs, field.Required(specPath.Child("machineType"), ""))
+	}
+	if n.Spec.MinSize < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("minSize"), n.Spec.MinSize, "must not be negative"))
+	}
+	if n.Spec.MaxSize < n.Spec.MinSize {
+		errs = append(errs, field.Invalid(specPath.Child("maxSize"), n.Spec.MaxSize, "must be greater than or equal to minSize"))
+	}
+	if n.Spec.DiskSizeGiB < 0 {
+		errs = append(errs, field.Invalid(specPath.Child("diskSizeGiB"), n.Spec.DiskSizeGiB, "must not be negative"))
+	}
+	return errs
+}
+
+func validateScale(scale *ScaleSpec, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	if scale.Min < 0 {
+		errs = append(errs, field.Invalid(path.Child("min"), scale.Min, "must not be negative"))
+	}
+	if scale.Max != 0 && scale.Max < scale.Min {
+		errs = append(errs, field.Invalid(path.Child("max"), scale.Max, "must be greater than or equal to min"))
+	}
+	if scale.TargetCPUUtilization < 0 || scale.TargetCPUUtilization > 100 {
+		errs = append(errs, field.Invalid(path.Child("targetCPUUtilizationPercentage"),
+			scale.TargetCPUUtilization, "must be between 0 and 100"))
+	}
+	return errs
+}
+
+// validateResources checks that requests don't exceed their limits, the quantities themselves are parsed when decoding
+func validateResources(res *corev1.ResourceRequirements, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	names := make([]string, 0, len(res.Requests))
+	for name := range res.Requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		name := corev1.ResourceName(n)
+		request := res.Requests[name]
+		limit, ok := res.Limits[name]
+		if ok && request.Cmp(limit) > 0 {
+			errs = append(errs, field.Invalid(path.Child("requests").Key(string(name)), request.String(),
+				fmt.Sprintf("must be less than or equal to %s limit", name)))
+		}
+	}
+	return errs
+}
+
+func validateAlerts(alerts *AlertsSpec, hasPorts bool, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	slo := alerts.SLO
+	if slo == nil {
+		return errs
+	}
+	sloPath := path.Child("slo")
+	if !hasPorts && (slo.Availability != "" || slo.Latency != nil) {
+		errs = append(errs, field.Invalid(sloPath, "", "SLOs are measured on requests, the app needs to declare a port"))
+	}
+	if slo.Availability != "" {
+		if _, err := ErrorBudget(slo.Availability); err != nil {
+			errs = append(errs, field.Invalid(sloPath.Child("availability"), slo.Availability, err.Error()))
+		}
+	}
+	if slo.Latency != nil {
+		latencyPath := sloPath.Child("latency")
+		found := false
+		for _, bucket := range IstioLatencyBuckets {
+			if bucket == slo.Latency.ThresholdMillis {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, field.Invalid(latencyPath.Child("thresholdMs"), slo.Latency.ThresholdMillis,
+				fmt.Sprintf("must be one of %v", IstioLatencyBuckets)))
+		}
+		if _, err := ErrorBudget(slo.Latency.Target); err != nil {
+			errs = append(errs, field.Invalid(latencyPath.Child("target"), slo.Latency.Target, err.Error()))
+		}
+	}
+	return errs
+}
+
+func validateProbes(probes *ProbeConfig, ports map[string]bool, path *field.Path) field.ErrorList {
+	var errs field.ErrorList
+	check := func(probe *Probe, name string) {
+		if probe == nil || probe.HTTPGet == nil {
+			return
+		}
+		portPath := path.Child(name, "httpGet", "port")
+		if probe.HTTPGet.Port == "" {
+			errs = append(errs, field.Required(portPath, ""))
+		} else if !ports[probe.HTTPGet.Port] {
+			errs = append(errs, field.NotFound(portPath, probe.HTTPGet.Port))
+		}
+	}
+	check(probes.Liveness, "liveness")
+	check(probes.Readiness, "readiness")
+	check(probes.Startup, "startup")
+	return errs
+}
@@ -0,0 +1,164 @@
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+func errorFields(errs field.ErrorList) []string {
+	var fields []string
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	return fields
+}
+
+func TestAppValidate(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			Image: "myapp",
+			AppCommonSpec: AppCommonSpec{
+				Ports: []PortSpec{
+					{Name: "http", Port: 80},
+				},
+				Probes: ProbeConfig{
+					Readiness: &Probe{
+						Handler: Handler{HTTPGet: &HTTPGetAction{Path: "/", Port: "http"}},
+					},
+				},
+			},
+			Scale: ScaleSpec{Min: 1, Max: 5},
+			Targets: []TargetConfig{
+				{
+					Name:    "production",
+					Ingress: &IngressConfig{Hosts: []string{"myapp.com"}, Port: "http"},
+					Scale:   ScaleSpec{Min: 2},
+				},
+			},
+		},
+	}
+	assert.Empty(t, app.Validate())
+
+	app.Spec.Targets[0].Ingress.Port = "grpc"
+	app.Spec.Targets[0].Probes.Liveness = &Probe{
+		Handler: Handler{HTTPGet: &HTTPGetAction{Path: "/", Port: "admin"}},
+	}
+	app.Spec.Targets[0].Scale = ScaleSpec{Min: 6}
+	app.Spec.Targets = append(app.Spec.Targets, TargetConfig{Name: "production"})
+	assert.ElementsMatch(t, []string{
+		"spec.targets[0].ingress.port",
+		"spec.targets[0].probes.liveness.httpGet.port",
+		"spec.targets[0].scale.max",
+		"spec.targets[1].name",
+	}, errorFields(app.Validate()))
+}
+
+func TestAppValidateScale(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			Scale: ScaleSpec{Min: 4, Max: 2},
+			Targets: []TargetConfig{
+				{Name: "staging"},
+			},
+		},
+	}
+	assert.Equal(t, []string{"spec.scale.max"}, errorFields(app.Validate()))
+
+	// max defaults to min
+	app.Spec.Scale.Max = 0
+	assert.Empty(t, app.Validate())
+}
+
+func TestAppValidateResources(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			AppCommonSpec: AppCommonSpec{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("200Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("100Mi")},
+				},
+			},
+			Targets: []TargetConfig{
+				{
+					Name: "production",
+					Resources: corev1.ResourceRequirements{
+						Limits: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+					},
+				},
+			},
+		},
+	}
+	// the target raises the limit
+	assert.Equal(t, []string{"spec.resources.requests[memory]"}, errorFields(app.Validate()))
+
+	app.Spec.Resources.Limits = nil
+	assert.Empty(t, app.Validate())
+}
+
+func TestAppValidateAlerts(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			Alerts: &AlertsSpec{
+				SLO: &SLOSpec{
+					Availability: "100",
+					Latency:      &LatencySLO{ThresholdMillis: 300, Target: "99"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"spec.alerts.slo", "spec.alerts.slo.availability", "spec.alerts.slo.latency.thresholdMs"},
+		errorFields(app.Validate()))
+
+	app.Spec.Ports = []PortSpec{{Name: "http", Port: 80}}
+	app.Spec.Alerts.SLO.Availability = "99.9"
+	app.Spec.Alerts.SLO.Latency.ThresholdMillis = 250
+	assert.Empty(t, app.Validate())
+
+	budget, err := ErrorBudget("99.9")
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.001, budget, 1e-9)
+}
+
+func TestAppValidateTracing(t *testing.T) {
+	app := &App{
+		Spec: AppSpec{
+			AppCommonSpec: AppCommonSpec{
+				Tracing: &TracingSpec{SamplingRate: "150"},
+			},
+		},
+	}
+	assert.Equal(t, []string{"spec.tracing.samplingRate"}, errorFields(app.Validate()))
+
+	app.Spec.Tracing.SamplingRate = "2.5"
+	assert.Empty(t, app.Validate())
+	pct, err := app.Spec.Tracing.SamplingPercentage()
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, pct)
+}
+
+func TestAppConfigValidate(t *testing.T) {
+	conf := NewAppConfig("myapp", "")
+	assert.NoError(t, conf.SetConfig(map[string]interface{}{"key": "value"}))
+	assert.Empty(t, conf.Validate())
+
+	conf.Labels = nil
+	conf.ConfigYaml = []byte("key: [value")
+	assert.Equal(t, []string{"metadata.labels[k11n.dev/app]", "config"}, errorFields(conf.Validate()))
+}
+
+func TestNodepoolValidate(t *testing.T) {
+	np := &Nodepool{
+		ObjectMeta: metav1.ObjectMeta{Name: "np"},
+		Spec: NodepoolSpec{
+			MinSize:     3,
+			MaxSize:     1,
+			MachineType: "m5.large",
+		},
+	}
+	assert.Equal(t, []string{"spec.maxSize"}, errorFields(np.Validate()))
+}
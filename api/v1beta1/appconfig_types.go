@@ -0,0 +1,61 @@
+/*
+
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// +kubebuilder:validation:Enum=app;shared
+type ConfigType string
+
+const (
+	ConfigTypeApp    ConfigType = "app"
+	ConfigTypeShared ConfigType = "shared"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.type`
+
+// AppConfig is the Schema for the appconfigs API
+type AppConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Type ConfigType `json:"type"`
+
+	// config as a structured object, instead of a YAML document
+	// +kubebuilder:validation:Optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +nullable
+	Config *runtime.RawExtension `json:"config,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AppConfigList contains a list of AppConfig
+type AppConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AppConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AppConfig{}, &AppConfigList{})
+}
@@ -0,0 +1,6 @@
+package v1beta1
+
+// Hub marks v1beta1 as the version that other versions are converted through
+func (*App) Hub() {}
+
+func (*AppConfig) Hub() {}
@@ -9,7 +9,6 @@ import (
 	"os/exec"
 	"os/signal"
 	"regexp"
-	"strconv"
 	"strings"
 	"syscall"
 	"text/template"
@@ -51,6 +50,12 @@ var (
 		Usage:   "a specific pod to use",
 	}
 	cliDateFormat = "2006-01-02 15:04:05"
+	// subcommands of app that work without a selected cluster
+	offlineAppCommands = map[string]bool{
+		"import":   true,
+		"render":   true,
+		"validate": true,
+	}
 )
 
 var AppCommands = []*cli.Command{
@@ -59,6 +64,9 @@ var AppCommands = []*cli.Command{
 		Aliases: []string{"apps"},
 		Usage:   "App commands",
 		Before: func(c *cli.Context) error {
+			if offlineAppCommands[c.Args().First()] {
+				return nil
+			}
 			return ensureClusterSelected()
 		},
 		Category: "App",
@@ -74,12 +82,40 @@ var AppCommands = []*cli.Command{
 				Usage:     "Deploy a new version of an app",
 				Action:    appDeploy,
 				ArgsUsage: "<app>",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:     "tag",
 						Usage:    "image tag to use",
 						Required: true,
 					},
+					&cli.BoolFlag{
+						Name:  "wait",
+						Usage: "wait until the build is active in all targets. exits with 1 when a release fails, 2 on timeout",
+					},
+					&cli.DurationFlag{
+						Name:  "timeout",
+						Usage: "how long to wait with --wait",
+						Value: 15 * time.Minute,
+					},
+				}, buildMetadataFlags...),
+			},
+			{
+				Name:      "diagnose",
+				Usage:     "Explain why pods of a release are failing, using container states, logs and events",
+				ArgsUsage: "<app>",
+				Action:    appDiagnose,
+				Flags: []cli.Flag{
+					targetFlag,
+					&cli.StringFlag{
+						Name:    "release",
+						Aliases: []string{"r"},
+						Usage:   "release to diagnose, defaults to the release being rolled out",
+					},
+					&cli.IntFlag{
+						Name:  "tail",
+						Usage: "number of log lines to include from crashed containers",
+						Value: 20,
+					},
 				},
 			},
 			{
@@ -95,6 +131,25 @@ var AppCommands = []*cli.Command{
 					},
 				},
 			},
+			{
+				Name:      "events",
+				Usage:     "List Kubernetes events for the app's releases and pods",
+				ArgsUsage: "<app>",
+				Action:    appEvents,
+				Flags: []cli.Flag{
+					targetFlag,
+					&cli.StringFlag{
+						Name:    "release",
+						Aliases: []string{"r"},
+						Usage:   "only include events for a release, or --release target for the release being rolled out",
+					},
+					&cli.BoolFlag{
+						Name:    "warnings",
+						Aliases: []string{"w"},
+						Usage:   "only include warnings",
+					},
+				},
+			},
 			{
 				Name:      "halt",
 				Usage:     "Halt/unhalt an app. Halting an app scales it to down immediately",
@@ -112,6 +167,30 @@ var AppCommands = []*cli.Command{
 					},
 				},
 			},
+			{
+				Name:      "import",
+				Usage:     "Create app manifests from Deployments, Services, HorizontalPodAutoscalers and Ingresses",
+				ArgsUsage: "[<file>...]",
+				Action:    appImport,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "target",
+						Aliases:  []string{"t"},
+						Usage:    "target that the resources are for",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:    "namespace",
+						Aliases: []string{"n"},
+						Usage:   "import from a namespace on the selected cluster instead of files",
+					},
+					&cli.StringFlag{
+						Name:  "output-dir",
+						Usage: "directory to write manifests to",
+						Value: ".",
+					},
+				},
+			},
 			{
 				Name:   "list",
 				Usage:  "List apps on this cluster",
@@ -152,7 +231,7 @@ var AppCommands = []*cli.Command{
 			},
 			{
 				Name:      "logs",
-				Usage:     "Print logs from a pod for the app",
+				Usage:     "Print logs from a pod, or all pods of the app",
 				Aliases:   []string{"log"},
 				ArgsUsage: "<app>",
 				Action:    appLogs,
@@ -167,11 +246,61 @@ var AppCommands = []*cli.Command{
 						Usage: "number of lines to include from tail (default 100, -1 for all)",
 						Value: 100,
 					},
+					&cli.BoolFlag{
+						Name:    "all",
+						Aliases: []string{"a"},
+						Usage:   "stream logs from all pods, use --release to limit to a release, or --release target for the release being rolled out",
+					},
+					&cli.DurationFlag{
+						Name:  "since",
+						Usage: "only include logs newer than a duration, i.e. 10m",
+					},
+					&cli.StringFlag{
+						Name:    "grep",
+						Aliases: []string{"g"},
+						Usage:   "only print lines matching a regular expression",
+					},
+					&cli.BoolFlag{
+						Name:  "history",
+						Usage: "get logs stored in Loki, including ones from pods that no longer exist. defaults to --since 1h",
+					},
+					&cli.BoolFlag{
+						Name:  "previous",
+						Usage: "logs from the previous instance of the container, for ones that have crashed",
+					},
+					&cli.BoolFlag{
+						Name:  "no-color",
+						Usage: "don't color pod names with --all",
+					},
 					podFlag,
 					targetFlag,
 					releaseFlag,
 				},
 			},
+			{
+				Name:      "metrics",
+				Usage:     "Print request and resource metrics for each release of the app",
+				ArgsUsage: "<app>",
+				Action:    appMetrics,
+				Flags: []cli.Flag{
+					targetFlag,
+					&cli.DurationFlag{
+						Name:  "window",
+						Usage: "time window to compute rates and latencies over",
+						Value: 5 * time.Minute,
+					},
+					&cli.BoolFlag{
+						Name:    "watch",
+						Aliases: []string{"w"},
+						Usage:   "refresh until interrupted",
+					},
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "how often to refresh with --watch",
+						Value: 10 * time.Second,
+					},
+				},
+			},
 			{
 				Name:   "new",
 				Usage:  "Create a new app.yaml from template",
@@ -187,6 +316,28 @@ var AppCommands = []*cli.Command{
 					releaseFlag,
 				},
 			},
+			{
+				Name:      "render",
+				Usage:     "Print the Kubernetes resources that the app would create, without changing anything",
+				ArgsUsage: "<app.yaml>",
+				Action:    appRender,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "target",
+						Aliases:  []string{"t"},
+						Usage:    "target to render",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "include the AppTarget and AppRelease used to create the resources",
+					},
+					&cli.BoolFlag{
+						Name:  "offline",
+						Usage: "don't look up configs, dependencies and certificates on the selected cluster",
+					},
+				},
+			},
 			{
 				Name:      "restart",
 				Usage:     "Restart the current app",
@@ -230,6 +381,29 @@ var AppCommands = []*cli.Command{
 				ArgsUsage: "<app>",
 				Flags: []cli.Flag{
 					targetFlag,
+					&cli.BoolFlag{
+						Name:    "watch",
+						Aliases: []string{"w"},
+						Usage:   "follow the rollout live, until the target release is active or fails",
+					},
+				},
+			},
+			{
+				Name:      "validate",
+				Usage:     "Check app manifests for errors without loading them. Targets, dependencies and configs are checked against the selected cluster",
+				ArgsUsage: "<app.yaml> [<app.yaml>...]",
+				Action:    appValidate,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "output format, text or json",
+						Value:   "text",
+					},
+					&cli.BoolFlag{
+						Name:  "offline",
+						Usage: "skip checks against the selected cluster",
+					},
 				},
 			},
 		},
@@ -324,6 +498,23 @@ func appStatus(c *cli.Context) error {
 
 	// find all the app targets
 	requiredTarget := c.String("target")
+	if c.Bool("watch") {
+		cc, err := resources.GetClusterConfig(kclient)
+		if err != nil {
+			return err
+		}
+		var targets []string
+		for _, target := range app.Spec.Targets {
+			if (requiredTarget == "" || target.Name == requiredTarget) && isClusterTarget(cc, target.Name) {
+				targets = append(targets, target.Name)
+			}
+		}
+		if len(targets) == 0 {
+			// targets that aren't on this cluster never roll out, the watch would not end
+			return cli.Exit(fmt.Sprintf("%s has no targets on cluster %s", appName, cc.Name), exitCodeNoTargets)
+		}
+		return watchRollout(ac, newRolloutWatcher(app.Name, targets, ""), 0)
+	}
 	// what information is useful here?
 	// group by target
 	// Build, date deployed, status, numAvailable/Desired, traffic
@@ -365,12 +556,32 @@ func appStatus(c *cli.Context) error {
 		if at.Spec.DeployMode == v1alpha1.DeployHalt {
 			atTable.Append([]string{"Deploy mode:", string(at.Spec.DeployMode)})
 		}
+
+		if build, err := resources.GetBuildByName(kclient, at.Spec.Build); err == nil {
+			atTable.Append([]string{"Build:", build.ShortName()})
+			if build.Spec.Digest != "" {
+				atTable.Append([]string{"Digest:", build.Spec.Digest})
+			}
+			if source := build.Spec.Source; source != nil {
+				for _, row := range [][]string{
+					{"Commit:", source.Commit},
+					{"Branch:", source.Branch},
+					{"Author:", source.Author},
+					{"CI:", source.CIURL},
+					{"Message:", firstLine(source.Message)},
+				} {
+					if row[1] != "" {
+						atTable.Append(row)
+					}
+				}
+			}
+		}
 		atTable.Render()
 		fmt.Println()
 
 		table := tablewriter.NewWriter(os.Stdout)
 		table.SetHeader([]string{
-			"Release", "Build", "Date", "Pods", "Status", "Traffic",
+			"Release", "Build", "Commit", "Date", "Pods", "Status", "Traffic",
 		})
 
 		// find all releases of this app
@@ -378,7 +589,11 @@ func appStatus(c *cli.Context) error {
 		if err != nil {
 			return err
 		}
+		var failingReleases []*v1alpha1.AppRelease
 		for _, release := range releases {
+			if len(release.Status.PodErrors) > 0 {
+				failingReleases = append(failingReleases, release)
+			}
 			// loading build
 			build, err := resources.GetBuildByName(kclient, release.Spec.Build)
 			if err != nil {
@@ -388,6 +603,7 @@ func appStatus(c *cli.Context) error {
 			vals := []string{
 				release.Name,
 				build.ShortName(),
+				build.ShortCommit(),
 				release.GetCreationTimestamp().Format(cliDateFormat),
 				fmt.Sprintf("%d/%d", release.Status.NumAvailable, release.Status.NumDesired),
 				release.Status.State.String(),
@@ -409,7 +625,7 @@ func appStatus(c *cli.Context) error {
 				if err != nil {
 					fmt.Println("error getting reason", err)
 				} else if reason != "" {
-					vals[4] += ": " + reason
+					vals[5] += ": " + reason
 				}
 			}
 
@@ -418,6 +634,12 @@ func appStatus(c *cli.Context) error {
 		utils.FormatStandardTable(table)
 		table.Render()
 		fmt.Println()
+
+		for _, release := range failingReleases {
+			fmt.Printf("Failing pods of %s\n", release.Name)
+			printContainerStatuses(os.Stdout, release.Status.PodErrors, true)
+			fmt.Println()
+		}
 	}
 
 	if isStuck {
@@ -427,7 +649,8 @@ func appStatus(c *cli.Context) error {
 		table.SetAutoWrapText(false)
 		utils.FormatPlainTable(table)
 		table.Append([]string{fmt.Sprintf("kon app logs -f %s", appName), "tails logs for the current release"})
-		table.Append([]string{fmt.Sprintf("kubectl get events -n %s", firstTarget), "prints k8s event logs"})
+		table.Append([]string{fmt.Sprintf("kon app diagnose %s", appName), "explains why pods are failing"})
+		table.Append([]string{fmt.Sprintf("kon app events %s", appName), "prints k8s events for the app"})
 		table.Append([]string{"kon launch kubedash", "launches Kubernetes Dashboard"})
 		table.Render()
 	}
@@ -484,6 +707,15 @@ func appDeploy(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+
+	source, digest := buildMetadataFromContext(kclient, app.Spec.Registry, app.Spec.Image, tag, c)
+	if source != nil || digest != "" {
+		build := v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, tag)
+		if _, err = resources.SaveBuild(kclient, build, source, digest); err != nil {
+			return err
+		}
+	}
+
 	// edit app to use this build
 	app.Spec.ImageTag = tag
 
@@ -498,7 +730,45 @@ func appDeploy(c *cli.Context) error {
 		fmt.Printf("App %s has been set to deploy %s:%s.\n", appName, app.Spec.Image, app.Spec.ImageTag)
 	}
 
-	return nil
+	if !c.Bool("wait") {
+		return nil
+	}
+	cc, err := resources.GetClusterConfig(kclient)
+	if err != nil {
+		return err
+	}
+	targets := deployableTargets(app, cc)
+	if len(targets) == 0 {
+		// otherwise there'd be nothing to wait for, and it would succeed right away
+		return cli.Exit(fmt.Sprintf("%s has no targets on cluster %s that can deploy, halted targets won't roll out the build", appName, cc.Name),
+			exitCodeNoTargets)
+	}
+	build := v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, tag)
+	fmt.Printf("Waiting for the build to roll out to %s\n", strings.Join(targets, ", "))
+	w := newRolloutWatcher(app.Name, targets, build.Name)
+	w.logOnly = true
+	return watchRollout(ac, w, c.Duration("timeout"))
+}
+
+// deployableTargets returns the targets that will roll out new builds on the cluster.
+// Halted targets won't deploy, and neither will targets that the cluster doesn't run
+func deployableTargets(app *v1alpha1.App, cc *v1alpha1.ClusterConfig) []string {
+	var targets []string
+	for _, target := range app.Spec.Targets {
+		if app.Spec.DeployModeForTarget(target.Name) != v1alpha1.DeployHalt && isClusterTarget(cc, target.Name) {
+			targets = append(targets, target.Name)
+		}
+	}
+	return targets
+}
+
+func isClusterTarget(cc *v1alpha1.ClusterConfig, target string) bool {
+	for _, t := range cc.Spec.Targets {
+		if t == target {
+			return true
+		}
+	}
+	return false
 }
 
 func appHalt(c *cli.Context) error {
@@ -884,39 +1154,6 @@ func appLocal(c *cli.Context) error {
 	return err
 }
 
-func appLogs(c *cli.Context) error {
-	ac, err := getActiveCluster()
-	if err != nil {
-		return err
-	}
-	kclient := ac.kubernetesClient()
-
-	pc, err := choosePodHelper(kclient, c)
-	if err != nil {
-		return err
-	}
-
-	follow := c.Bool("follow")
-	verb := "getting"
-	if follow {
-		verb = "following"
-	}
-	fmt.Printf("%s logs for pod %s\n", verb, pc.pod)
-	namespace := pc.target
-	args := []string{
-		"logs", pc.pod, "-n", namespace, "-c", pc.app,
-		"--tail", strconv.Itoa(c.Int("tail")),
-	}
-	if c.Bool("follow") {
-		args = append(args, "-f")
-	}
-	cmd := exec.Command("kubectl", args...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
-}
-
 func appPods(c *cli.Context) error {
 	ac, err := getActiveCluster()
 	if err != nil {
@@ -1049,15 +1286,25 @@ func appRollback(c *cli.Context) error {
 			return err
 		}
 
-		names := funk.Map(releases, func(ar *v1alpha1.AppRelease) string {
-			return ar.Name
-		})
-		prompt := utils.NewPromptSelect("Select a release to mark as bad", names)
+		var items []string
+		for _, ar := range releases {
+			item := ar.Name
+			if build, err := resources.GetBuildByName(kclient, ar.Spec.Build); err == nil {
+				item = fmt.Sprintf("%s (%s", item, build.ShortName())
+				if commit := build.ShortCommit(); commit != "" {
+					item += " @ " + commit
+				}
+				item += ")"
+			}
+			items = append(items, item)
+		}
+		prompt := utils.NewPromptSelect("Select a release to mark as bad", items)
 		prompt.Size = 10
-		_, release, err = prompt.Run()
+		idx, _, err := prompt.Run()
 		if err != nil {
 			return err
 		}
+		release = releases[idx].Name
 	}
 
 	ar, err := resources.GetAppRelease(kclient, app, target, release)
@@ -1308,3 +1555,10 @@ func parseImageInfo(val string, ai *appInfo) {
 		ai.DockerImage = ai.DockerImage[slashIdx+1:]
 	}
 }
+
+func firstLine(val string) string {
+	if idx := strings.Index(val, "\n"); idx != -1 {
+		return strings.TrimSpace(val[:idx])
+	}
+	return val
+}
@@ -0,0 +1,250 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hako/durafmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/resources"
+	utilscli "github.com/k11n/konstellation/pkg/utils/cli"
+)
+
+const (
+	// events shown by diagnose, use `kon app events` for all of them
+	maxDiagnoseEvents = 10
+	// crashed containers to get logs from, one per cause
+	maxCrashLogs = 3
+)
+
+func appDiagnose(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	pc, err := chooseReleaseHelper(kclient, c)
+	if err != nil {
+		return err
+	}
+	ar, err := resources.GetAppRelease(kclient, pc.app, pc.target, pc.release)
+	if err != nil {
+		return err
+	}
+	pods, err := resources.GetPodsForAppRelease(kclient, pc.target, pc.release)
+	if err != nil {
+		return err
+	}
+	events, err := resources.GetEventsForAppReleases(kclient, pc.target, []string{pc.release})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Diagnosing release %s in %s, %d/%d pods available\n\n",
+		ar.Name, pc.target, ar.Status.NumAvailable, ar.Status.NumDesired)
+
+	causes := resources.DiagnosePods(pods, events)
+	printFailureCauses(os.Stdout, causes)
+
+	var statuses []v1alpha1.PodStatus
+	for _, pod := range pods {
+		statuses = append(statuses, resources.PodStatusForPod(pod))
+	}
+	if len(statuses) > 0 {
+		fmt.Println("\nContainers")
+		printContainerStatuses(os.Stdout, statuses, false)
+	}
+
+	// logs explain crashes better than exit codes
+	numLogs := 0
+	for _, cause := range causes {
+		if numLogs == maxCrashLogs {
+			break
+		}
+		for _, pod := range pods {
+			if pod.Name != cause.Pods[0] {
+				continue
+			}
+			args := crashLogArgs(pod, cause.Container, c.Int("tail"))
+			if args == nil {
+				continue
+			}
+			numLogs += 1
+			fmt.Printf("\nLast %d log lines of %s in pod %s\n", c.Int("tail"), cause.Container, pod.Name)
+			out, err := utilscli.RunBufferedCommand("kubectl", args...)
+			if err != nil && len(out) == 0 {
+				fmt.Println(err)
+			}
+			fmt.Println(strings.TrimRight(string(out), "\n"))
+		}
+	}
+
+	if len(events) > 0 {
+		fmt.Println("\nRecent events")
+		if len(events) > maxDiagnoseEvents {
+			events = events[len(events)-maxDiagnoseEvents:]
+		}
+		printEvents(os.Stdout, events, time.Now())
+		fmt.Printf("\nsee all events with `kon app events %s --release %s`\n", pc.app, pc.release)
+	}
+	return nil
+}
+
+func appEvents(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	app, target, release, err := logScopeFromFlags(kclient, c)
+	if err != nil {
+		return err
+	}
+	releases := []string{release}
+	if release == "" {
+		releases = nil
+		ars, err := resources.GetAppReleases(kclient, app, target)
+		if err != nil {
+			return err
+		}
+		for _, ar := range ars {
+			releases = append(releases, ar.Name)
+		}
+	}
+
+	events, err := resources.GetEventsForAppReleases(kclient, target, releases)
+	if err != nil {
+		return err
+	}
+	if c.Bool("warnings") {
+		var warnings []corev1.Event
+		for _, event := range events {
+			if event.Type == corev1.EventTypeWarning {
+				warnings = append(warnings, event)
+			}
+		}
+		events = warnings
+	}
+
+	if len(events) == 0 {
+		fmt.Println("No events found. Kubernetes keeps events for an hour")
+		return nil
+	}
+	printEvents(os.Stdout, events, time.Now())
+	return nil
+}
+
+// printFailureCauses prints a numbered explanation for each cause
+func printFailureCauses(out io.Writer, causes []*resources.FailureCause) {
+	if len(causes) == 0 {
+		fmt.Fprintln(out, "No problems found with the release's pods")
+		return
+	}
+
+	fmt.Fprintln(out, "Likely causes, most likely first:")
+	for i, cause := range causes {
+		fmt.Fprintln(out)
+		title := cause.Reason
+		if cause.Container != "" {
+			title += fmt.Sprintf(" in container %s", cause.Container)
+		}
+		pods := "pod " + cause.Pods[0]
+		if len(cause.Pods) > 1 {
+			pods = fmt.Sprintf("%d pods", len(cause.Pods))
+		}
+		fmt.Fprintf(out, "%d. %s, affecting %s\n", i+1, title, pods)
+		fmt.Fprintf(out, "   %s\n", cause.Explanation())
+		if cause.Message != "" && cause.Message != cause.Explanation() {
+			fmt.Fprintf(out, "   %s\n", firstLine(cause.Message))
+		}
+	}
+}
+
+// printContainerStatuses prints a row for each container, or only ones that aren't ready when failingOnly
+func printContainerStatuses(out io.Writer, statuses []v1alpha1.PodStatus, failingOnly bool) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Pod", "Container", "State", "Restarts", "Details"})
+	for _, status := range statuses {
+		if len(status.Containers) == 0 {
+			table.Append([]string{status.Pod, "", status.Reason, "", firstLine(status.Message)})
+			continue
+		}
+		for _, container := range status.Containers {
+			if failingOnly && container.Ready {
+				continue
+			}
+			state := container.State
+			if container.Reason != "" {
+				state = fmt.Sprintf("%s: %s", state, container.Reason)
+			}
+			var details string
+			switch {
+			case container.State == v1alpha1.ContainerStateTerminated:
+				details = fmt.Sprintf("exit code %d", container.ExitCode)
+			case container.LastTerminationReason != "":
+				details = fmt.Sprintf("last exit code %d (%s)", container.LastExitCode, container.LastTerminationReason)
+			}
+			table.Append([]string{
+				status.Pod,
+				container.Name,
+				state,
+				strconv.Itoa(int(container.RestartCount)),
+				details,
+			})
+		}
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+}
+
+func printEvents(out io.Writer, events []corev1.Event, now time.Time) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Last Seen", "Type", "Reason", "Object", "Message"})
+	for i := range events {
+		event := &events[i]
+		reason := event.Reason
+		if event.Count > 1 {
+			reason = fmt.Sprintf("%s (x%d)", reason, event.Count)
+		}
+		table.Append([]string{
+			durafmt.ParseShort(now.Sub(resources.EventLastSeen(event))).String() + " ago",
+			event.Type,
+			reason,
+			fmt.Sprintf("%s/%s", strings.ToLower(event.InvolvedObject.Kind), event.InvolvedObject.Name),
+			strings.TrimSpace(event.Message),
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+}
+
+// crashLogArgs returns kubectl args to get the last lines logged by a container that has crashed,
+// or nil if it hasn't run
+func crashLogArgs(pod *corev1.Pod, container string, tail int) []string {
+	args := []string{"logs", pod.Name, "-n", pod.Namespace, "-c", container, "--tail", strconv.Itoa(tail)}
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	for _, status := range append(statuses, pod.Status.ContainerStatuses...) {
+		if status.Name != container {
+			continue
+		}
+		switch {
+		case status.State.Terminated != nil:
+			return args
+		case status.LastTerminationState.Terminated != nil:
+			// container has been restarted, logs from the crash are in the previous instance
+			return append(args, "--previous")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func TestCrashLogArgs(t *testing.T) {
+	pod := newLogTestPod("myapp-1-a", "myapp-1", corev1.PodRunning, true, 0)
+	// hasn't crashed
+	assert.Nil(t, crashLogArgs(pod, "myapp", 20))
+	assert.Nil(t, crashLogArgs(pod, "sidecar", 20))
+
+	pod.Status.ContainerStatuses[0].LastTerminationState.Terminated = &corev1.ContainerStateTerminated{ExitCode: 1}
+	assert.Equal(t, []string{"logs", "myapp-1-a", "-n", "production", "-c", "myapp", "--tail", "20", "--previous"},
+		crashLogArgs(pod, "myapp", 20))
+
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{
+		Terminated: &corev1.ContainerStateTerminated{ExitCode: 1},
+	}
+	assert.Equal(t, []string{"logs", "myapp-1-a", "-n", "production", "-c", "myapp", "--tail", "20"},
+		crashLogArgs(pod, "myapp", 20))
+}
+
+func TestPrintFailureCauses(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	printFailureCauses(buf, nil)
+	assert.Equal(t, "No problems found with the release's pods\n", buf.String())
+
+	buf.Reset()
+	printFailureCauses(buf, []*resources.FailureCause{
+		{
+			Reason:    resources.ReasonOOMKilled,
+			Container: "myapp",
+			Pods:      []string{"myapp-1-a", "myapp-1-b"},
+		},
+		{
+			Reason:  resources.ReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient cpu.",
+			Pods:    []string{"myapp-1-c"},
+		},
+	})
+	assert.Equal(t, `Likely causes, most likely first:
+
+1. OOMKilled in container myapp, affecting 2 pods
+   the container ran out of memory and was killed. Increase its memory limit under resources in the app manifest
+
+2. Unschedulable, affecting pod myapp-1-c
+   pods could not be scheduled onto any node. The cluster may be out of capacity, or no nodes match the app's requirements
+   0/3 nodes are available: 3 Insufficient cpu.
+`, buf.String())
+}
@@ -0,0 +1,567 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	errorshelper "github.com/pkg/errors"
+	"github.com/thoas/go-funk"
+	"github.com/urfave/cli/v2"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscale "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	extv1beta1 "k8s.io/api/extensions/v1beta1"
+	netv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/kube"
+)
+
+// appImporter converts Deployments, along with the Services, HorizontalPodAutoscalers and Ingresses
+// that reference them, into apps
+type appImporter struct {
+	target      string
+	deployments []*appsv1.Deployment
+	services    []*corev1.Service
+	scalers     []*autoscale.HorizontalPodAutoscaler
+	ingresses   []*netv1beta1.Ingress
+	// objects that were not imported
+	skipped []string
+}
+
+type importedApp struct {
+	App    *v1alpha1.App
+	Config *v1alpha1.AppConfig
+	// features of the source resources that apps don't support
+	Unsupported []string
+}
+
+func appImport(c *cli.Context) error {
+	importer := &appImporter{target: c.String("target")}
+
+	namespace := c.String("namespace")
+	if namespace != "" {
+		if c.NArg() > 0 {
+			return fmt.Errorf("pass in either files or --namespace, not both")
+		}
+		ac, err := getActiveCluster()
+		if err != nil {
+			return err
+		}
+		if err = importer.loadNamespace(ac.kubernetesClient(), namespace); err != nil {
+			return err
+		}
+	} else {
+		if c.NArg() == 0 {
+			return fmt.Errorf("files or --namespace is required")
+		}
+		for _, file := range c.Args().Slice() {
+			content, err := ioutil.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			if err = importer.loadManifests(content); err != nil {
+				return errorshelper.Wrapf(err, "could not read %s", file)
+			}
+		}
+	}
+
+	apps := importer.importApps()
+	if len(apps) == 0 {
+		return fmt.Errorf("no deployments found")
+	}
+
+	outputDir := c.String("output-dir")
+	encoder := kube.GetKubeEncoder()
+	for _, ia := range apps {
+		files := []string{path.Join(outputDir, ia.App.Name+".yaml")}
+		objs := []runtime.Object{ia.App}
+		if ia.Config != nil {
+			files = append(files, path.Join(outputDir, ia.App.Name+"-config.yaml"))
+			objs = append(objs, ia.Config)
+		}
+		for i, obj := range objs {
+			buf := bytes.NewBuffer(nil)
+			if err := encoder.Encode(obj, buf); err != nil {
+				return err
+			}
+			if err := ioutil.WriteFile(files[i], buf.Bytes(), 0644); err != nil {
+				return err
+			}
+		}
+
+		fmt.Printf("Imported %s: %s\n", ia.App.Name, strings.Join(files, ", "))
+		for _, msg := range ia.Unsupported {
+			fmt.Printf("  not imported: %s\n", msg)
+		}
+	}
+	for _, msg := range importer.skipped {
+		fmt.Printf("Skipped %s\n", msg)
+	}
+	return nil
+}
+
+// loadManifests reads resources from a multi-document YAML, including List resources
+func (i *appImporter) loadManifests(content []byte) error {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		tm := metav1.TypeMeta{}
+		if err = yaml.Unmarshal(doc, &tm); err != nil {
+			return err
+		}
+		if tm.Kind == "" {
+			continue
+		}
+
+		obj, _, err := kube.GetKubeDecoder().Decode(doc, nil, nil)
+		if err != nil {
+			i.skipped = append(i.skipped, fmt.Sprintf("%s: %v", tm.Kind, err))
+			continue
+		}
+		if err = i.add(obj); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *appImporter) loadNamespace(kclient client.Client, namespace string) error {
+	ctx := context.TODO()
+	inNamespace := client.InNamespace(namespace)
+	deployments := appsv1.DeploymentList{}
+	if err := kclient.List(ctx, &deployments, inNamespace); err != nil {
+		return err
+	}
+	services := corev1.ServiceList{}
+	if err := kclient.List(ctx, &services, inNamespace); err != nil {
+		return err
+	}
+	scalers := autoscale.HorizontalPodAutoscalerList{}
+	if err := kclient.List(ctx, &scalers, inNamespace); err != nil {
+		return err
+	}
+	ingresses := netv1beta1.IngressList{}
+	if err := kclient.List(ctx, &ingresses, inNamespace); err != nil {
+		return err
+	}
+	for _, list := range []runtime.Object{&deployments, &services, &scalers, &ingresses} {
+		if err := i.add(list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *appImporter) add(obj runtime.Object) error {
+	switch o := obj.(type) {
+	case *corev1.List:
+		for _, item := range o.Items {
+			if err := i.loadManifests(item.Raw); err != nil {
+				return err
+			}
+		}
+	case *appsv1.DeploymentList:
+		for idx := range o.Items {
+			i.deployments = append(i.deployments, &o.Items[idx])
+		}
+	case *corev1.ServiceList:
+		for idx := range o.Items {
+			i.services = append(i.services, &o.Items[idx])
+		}
+	case *autoscale.HorizontalPodAutoscalerList:
+		for idx := range o.Items {
+			i.scalers = append(i.scalers, &o.Items[idx])
+		}
+	case *netv1beta1.IngressList:
+		for idx := range o.Items {
+			i.ingresses = append(i.ingresses, &o.Items[idx])
+		}
+	case *appsv1.Deployment:
+		i.deployments = append(i.deployments, o)
+	case *corev1.Service:
+		i.services = append(i.services, o)
+	case *autoscale.HorizontalPodAutoscaler:
+		i.scalers = append(i.scalers, o)
+	case *autoscalingv1.HorizontalPodAutoscaler:
+		i.scalers = append(i.scalers, scalerFromV1(o))
+	case *netv1beta1.Ingress:
+		i.ingresses = append(i.ingresses, o)
+	case *extv1beta1.Ingress:
+		// identical to the networking version
+		in := &netv1beta1.Ingress{}
+		content, err := yaml.Marshal(o)
+		if err != nil {
+			return err
+		}
+		if err = yaml.Unmarshal(content, in); err != nil {
+			return err
+		}
+		i.ingresses = append(i.ingresses, in)
+	default:
+		gvk := obj.GetObjectKind().GroupVersionKind()
+		i.skipped = append(i.skipped, fmt.Sprintf("%s: only Deployments, Services, HorizontalPodAutoscalers and Ingresses are imported", gvk.Kind))
+	}
+	return nil
+}
+
+func (i *appImporter) importApps() []*importedApp {
+	var apps []*importedApp
+	for _, d := range i.deployments {
+		apps = append(apps, i.importDeployment(d))
+	}
+	sort.Slice(apps, func(a, b int) bool {
+		return apps[a].App.Name < apps[b].App.Name
+	})
+	return apps
+}
+
+func (i *appImporter) importDeployment(d *appsv1.Deployment) *importedApp {
+	ia := &importedApp{}
+	unsupported := func(format string, args ...interface{}) {
+		ia.Unsupported = append(ia.Unsupported, fmt.Sprintf(format, args...))
+	}
+
+	app := &v1alpha1.App{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "App",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: d.Name},
+	}
+	ia.App = app
+	podSpec := &d.Spec.Template.Spec
+
+	// use the container named after the deployment, or the first one
+	var container *corev1.Container
+	for idx := range podSpec.Containers {
+		if podSpec.Containers[idx].Name == d.Name {
+			container = &podSpec.Containers[idx]
+		}
+	}
+	if container == nil && len(podSpec.Containers) > 0 {
+		container = &podSpec.Containers[0]
+	}
+	if container == nil {
+		unsupported("deployment has no containers")
+		return ia
+	}
+	for _, c := range podSpec.Containers {
+		if c.Name != container.Name {
+			unsupported("container %s, apps run a single container", c.Name)
+		}
+	}
+	if len(podSpec.InitContainers) > 0 {
+		unsupported("init containers")
+	}
+
+	image := container.Image
+	if idx := strings.Index(image, "@"); idx != -1 {
+		unsupported("image digest %s, the app uses the image without it", image[idx+1:])
+		image = image[:idx]
+	}
+	ai := appInfo{}
+	parseImageInfo(image, &ai)
+	app.Spec.Registry = ai.Registry
+	app.Spec.Image = ai.DockerImage
+	app.Spec.ImageTag = ai.DockerTag
+
+	app.Spec.Command = container.Command
+	app.Spec.Args = container.Args
+	app.Spec.Resources = container.Resources
+	app.Spec.ServiceAccount = podSpec.ServiceAccountName
+	for _, s := range podSpec.ImagePullSecrets {
+		app.Spec.ImagePullSecrets = append(app.Spec.ImagePullSecrets, s.Name)
+	}
+
+	// ports need names so that other fields can reference them
+	portNames := make(map[int32]string)
+	for _, p := range container.Ports {
+		name := p.Name
+		if name == "" {
+			name = fmt.Sprintf("port-%d", p.ContainerPort)
+		}
+		portNames[p.ContainerPort] = name
+		app.Spec.Ports = append(app.Spec.Ports, v1alpha1.PortSpec{
+			Name:     name,
+			Port:     p.ContainerPort,
+			Protocol: p.Protocol,
+		})
+		if p.HostPort != 0 {
+			unsupported("host port %d", p.HostPort)
+		}
+	}
+	portName := func(port intstr.IntOrString) string {
+		if port.Type == intstr.String {
+			return port.StrVal
+		}
+		return portNames[port.IntVal]
+	}
+
+	app.Spec.Probes.Liveness = importProbe(container.LivenessProbe, "liveness", portName, unsupported)
+	app.Spec.Probes.Readiness = importProbe(container.ReadinessProbe, "readiness", portName, unsupported)
+	app.Spec.Probes.Startup = importProbe(container.StartupProbe, "startup", portName, unsupported)
+
+	// environment goes into the app config
+	config := make(map[string]interface{})
+	for _, env := range container.Env {
+		if env.ValueFrom != nil {
+			unsupported("env %s, values from other resources can't be imported", env.Name)
+			continue
+		}
+		config[env.Name] = env.Value
+	}
+	if len(container.EnvFrom) > 0 {
+		unsupported("envFrom, add the values to the app config")
+	}
+	if len(config) > 0 {
+		ia.Config = v1alpha1.NewAppConfig(d.Name, "")
+		ia.Config.TypeMeta = metav1.TypeMeta{
+			APIVersion: v1alpha1.GroupVersion.String(),
+			Kind:       "AppConfig",
+		}
+		// values are strings, this can't fail
+		_ = ia.Config.SetConfig(config)
+	}
+
+	if len(podSpec.Volumes) > 0 || len(container.VolumeMounts) > 0 {
+		unsupported("volumes")
+	}
+	if len(podSpec.NodeSelector) > 0 || podSpec.Affinity != nil || len(podSpec.Tolerations) > 0 {
+		unsupported("node selectors, affinity and tolerations")
+	}
+	if podSpec.SecurityContext != nil || container.SecurityContext != nil {
+		unsupported("security context")
+	}
+	if container.Lifecycle != nil {
+		unsupported("lifecycle hooks")
+	}
+
+	// scale from the autoscaler, or a fixed number of replicas
+	scaler := i.scalerForDeployment(d)
+	if scaler != nil {
+		app.Spec.Scale.Max = scaler.Spec.MaxReplicas
+		if scaler.Spec.MinReplicas != nil {
+			app.Spec.Scale.Min = *scaler.Spec.MinReplicas
+		}
+		for _, m := range scaler.Spec.Metrics {
+			if m.Type == autoscale.ResourceMetricSourceType && m.Resource != nil &&
+				m.Resource.Name == corev1.ResourceCPU && m.Resource.Target.AverageUtilization != nil {
+				app.Spec.Scale.TargetCPUUtilization = *m.Resource.Target.AverageUtilization
+			} else {
+				unsupported("autoscaler %s metric, only CPU utilization is supported", m.Type)
+			}
+		}
+	} else if d.Spec.Replicas != nil {
+		app.Spec.Scale.Min = *d.Spec.Replicas
+		app.Spec.Scale.Max = *d.Spec.Replicas
+	}
+
+	targetConfig := v1alpha1.TargetConfig{Name: i.target}
+	services := i.servicesForDeployment(d)
+	for _, svc := range services {
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer || svc.Spec.Type == corev1.ServiceTypeNodePort {
+			unsupported("service %s of type %s, use an ingress instead", svc.Name, svc.Spec.Type)
+		}
+		for _, p := range svc.Spec.Ports {
+			if p.TargetPort.Type == intstr.Int && p.TargetPort.IntVal != 0 && p.TargetPort.IntVal != p.Port {
+				unsupported("service %s maps port %d to %d, apps expose container ports directly",
+					svc.Name, p.Port, p.TargetPort.IntVal)
+			}
+		}
+	}
+
+	for _, in := range i.ingresses {
+		ingressConfig := importIngress(in, services, portName, unsupported)
+		if ingressConfig == nil {
+			continue
+		}
+		if targetConfig.Ingress == nil {
+			targetConfig.Ingress = ingressConfig
+		} else {
+			targetConfig.Ingress.Hosts = append(targetConfig.Ingress.Hosts, ingressConfig.Hosts...)
+		}
+	}
+	app.Spec.Targets = []v1alpha1.TargetConfig{targetConfig}
+
+	return ia
+}
+
+func (i *appImporter) scalerForDeployment(d *appsv1.Deployment) *autoscale.HorizontalPodAutoscaler {
+	for _, scaler := range i.scalers {
+		ref := scaler.Spec.ScaleTargetRef
+		if ref.Kind == "Deployment" && ref.Name == d.Name && scaler.Namespace == d.Namespace {
+			return scaler
+		}
+	}
+	return nil
+}
+
+// servicesForDeployment returns services that select the deployment's pods
+func (i *appImporter) servicesForDeployment(d *appsv1.Deployment) []*corev1.Service {
+	var services []*corev1.Service
+	podLabels := labels.Set(d.Spec.Template.Labels)
+	for _, svc := range i.services {
+		if len(svc.Spec.Selector) == 0 || svc.Namespace != d.Namespace {
+			continue
+		}
+		if labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+			services = append(services, svc)
+		}
+	}
+	return services
+}
+
+func importProbe(probe *corev1.Probe, name string, portName func(intstr.IntOrString) string,
+	unsupported func(string, ...interface{})) *v1alpha1.Probe {
+	if probe == nil {
+		return nil
+	}
+	p := &v1alpha1.Probe{
+		InitialDelaySeconds: probe.InitialDelaySeconds,
+		TimeoutSeconds:      probe.TimeoutSeconds,
+		PeriodSeconds:       probe.PeriodSeconds,
+		SuccessThreshold:    probe.SuccessThreshold,
+		FailureThreshold:    probe.FailureThreshold,
+	}
+	switch {
+	case probe.HTTPGet != nil:
+		port := portName(probe.HTTPGet.Port)
+		if port == "" {
+			unsupported("%s probe port %s, it's not a container port", name, probe.HTTPGet.Port.String())
+			return nil
+		}
+		p.HTTPGet = &v1alpha1.HTTPGetAction{
+			Path:        probe.HTTPGet.Path,
+			Port:        port,
+			Host:        probe.HTTPGet.Host,
+			Scheme:      probe.HTTPGet.Scheme,
+			HTTPHeaders: probe.HTTPGet.HTTPHeaders,
+		}
+	case probe.Exec != nil:
+		p.Exec = probe.Exec
+	default:
+		unsupported("%s probe, only httpGet and exec probes are supported", name)
+		return nil
+	}
+	return p
+}
+
+// importIngress returns the ingress config for rules that route to one of the services, or nil
+func importIngress(in *netv1beta1.Ingress, services []*corev1.Service, portName func(intstr.IntOrString) string,
+	unsupported func(string, ...interface{})) *v1alpha1.IngressConfig {
+	var config *v1alpha1.IngressConfig
+	backendPort := func(backend netv1beta1.IngressBackend) (string, bool) {
+		for _, svc := range services {
+			if svc.Name != backend.ServiceName || svc.Namespace != in.Namespace {
+				continue
+			}
+			for _, p := range svc.Spec.Ports {
+				if (backend.ServicePort.Type == intstr.String && p.Name == backend.ServicePort.StrVal) ||
+					(backend.ServicePort.Type == intstr.Int && p.Port == backend.ServicePort.IntVal) {
+					if p.TargetPort.Type == intstr.String {
+						return p.TargetPort.StrVal, true
+					}
+					targetPort := p.TargetPort
+					if targetPort.IntVal == 0 {
+						targetPort = intstr.FromInt(int(p.Port))
+					}
+					return portName(targetPort), true
+				}
+			}
+			return "", true
+		}
+		return "", false
+	}
+
+	for _, rule := range in.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			port, ok := backendPort(p.Backend)
+			if !ok {
+				continue
+			}
+			if config == nil {
+				config = &v1alpha1.IngressConfig{Port: port}
+			}
+			if p.Path != "" && p.Path != "/" && p.Path != "/*" {
+				unsupported("ingress %s path %s, ingresses route all paths to the app", in.Name, p.Path)
+			}
+			if rule.Host == "" {
+				unsupported("ingress %s rule without a host", in.Name)
+			} else if !funk.ContainsString(config.Hosts, rule.Host) {
+				config.Hosts = append(config.Hosts, rule.Host)
+			}
+		}
+	}
+	if config == nil {
+		return nil
+	}
+
+	if len(in.Spec.TLS) > 0 {
+		unsupported("ingress %s TLS secrets, certificates are managed with kon certificate", in.Name)
+	}
+	for key, val := range in.Annotations {
+		if strings.HasPrefix(key, "alb.ingress") {
+			if config.Annotations == nil {
+				config.Annotations = make(map[string]string)
+			}
+			config.Annotations[key] = val
+		}
+	}
+	return config
+}
+
+func scalerFromV1(s *autoscalingv1.HorizontalPodAutoscaler) *autoscale.HorizontalPodAutoscaler {
+	scaler := &autoscale.HorizontalPodAutoscaler{
+		ObjectMeta: s.ObjectMeta,
+		Spec: autoscale.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscale.CrossVersionObjectReference{
+				Kind:       s.Spec.ScaleTargetRef.Kind,
+				Name:       s.Spec.ScaleTargetRef.Name,
+				APIVersion: s.Spec.ScaleTargetRef.APIVersion,
+			},
+			MinReplicas: s.Spec.MinReplicas,
+			MaxReplicas: s.Spec.MaxReplicas,
+		},
+	}
+	if s.Spec.TargetCPUUtilizationPercentage != nil {
+		scaler.Spec.Metrics = []autoscale.MetricSpec{
+			{
+				Type: autoscale.ResourceMetricSourceType,
+				Resource: &autoscale.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscale.MetricTarget{
+						Type:               autoscale.UtilizationMetricType,
+						AverageUtilization: s.Spec.TargetCPUUtilizationPercentage,
+					},
+				},
+			},
+		}
+	}
+	return scaler
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const testImportManifests = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 2
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: ecr.registry.com/repo/web:1.2
+          ports:
+            - containerPort: 8080
+            - name: metrics
+              containerPort: 9090
+          env:
+            - name: LOG_LEVEL
+              value: debug
+            - name: DB_PASSWORD
+              valueFrom:
+                secretKeyRef:
+                  name: db
+                  key: password
+          readinessProbe:
+            httpGet:
+              path: /ready
+              port: 8080
+          livenessProbe:
+            tcpSocket:
+              port: 8080
+        - name: proxy
+          image: envoy
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+  namespace: default
+spec:
+  selector:
+    app: web
+  ports:
+    - name: http
+      port: 80
+      targetPort: 8080
+---
+apiVersion: autoscaling/v1
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+  namespace: default
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: web
+  minReplicas: 2
+  maxReplicas: 10
+  targetCPUUtilizationPercentage: 70
+---
+apiVersion: extensions/v1beta1
+kind: Ingress
+metadata:
+  name: web
+  namespace: default
+  annotations:
+    alb.ingress.kubernetes.io/scheme: internal
+spec:
+  rules:
+    - host: web.com
+      http:
+        paths:
+          - path: /
+            backend:
+              serviceName: web
+              servicePort: 80
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+`
+
+func TestImportApps(t *testing.T) {
+	importer := &appImporter{target: "production"}
+	assert.NoError(t, importer.loadManifests([]byte(testImportManifests)))
+	assert.Len(t, importer.skipped, 1)
+
+	apps := importer.importApps()
+	assert.Len(t, apps, 1)
+	ia := apps[0]
+	app := ia.App
+	assert.Equal(t, "web", app.Name)
+	assert.Equal(t, "ecr.registry.com", app.Spec.Registry)
+	assert.Equal(t, "repo/web", app.Spec.Image)
+	assert.Equal(t, "1.2", app.Spec.ImageTag)
+
+	assert.Equal(t, "port-8080", app.Spec.Ports[0].Name)
+	assert.Equal(t, "metrics", app.Spec.Ports[1].Name)
+	assert.Equal(t, "port-8080", app.Spec.Probes.Readiness.HTTPGet.Port)
+	assert.Nil(t, app.Spec.Probes.Liveness)
+
+	assert.Equal(t, int32(2), app.Spec.Scale.Min)
+	assert.Equal(t, int32(10), app.Spec.Scale.Max)
+	assert.Equal(t, int32(70), app.Spec.Scale.TargetCPUUtilization)
+
+	assert.Len(t, app.Spec.Targets, 1)
+	tc := app.Spec.Targets[0]
+	assert.Equal(t, "production", tc.Name)
+	assert.Equal(t, []string{"web.com"}, tc.Ingress.Hosts)
+	assert.Equal(t, "port-8080", tc.Ingress.Port)
+	assert.Equal(t, "internal", tc.Ingress.Annotations["alb.ingress.kubernetes.io/scheme"])
+
+	assert.Equal(t, map[string]interface{}{"LOG_LEVEL": "debug"}, ia.Config.GetConfig())
+	assert.Equal(t, "web", ia.Config.GetAppName())
+
+	// sidecar, secret env, tcp probe and the port mapping can't be represented
+	assert.Len(t, ia.Unsupported, 4)
+	assert.Empty(t, app.Validate())
+}
+
+func TestImportReplicas(t *testing.T) {
+	importer := &appImporter{target: "production"}
+	assert.NoError(t, importer.loadManifests([]byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+        - name: worker
+          image: worker@sha256:abcd
+`)))
+	apps := importer.importApps()
+	assert.Len(t, apps, 1)
+	assert.Equal(t, "worker", apps[0].App.Spec.Image)
+	assert.Equal(t, int32(3), apps[0].App.Spec.Scale.Min)
+	assert.Equal(t, int32(3), apps[0].App.Spec.Scale.Max)
+	assert.Nil(t, apps[0].Config)
+	assert.Len(t, apps[0].Unsupported, 1)
+	assert.Equal(t, corev1.ResourceRequirements{}, apps[0].App.Spec.Resources)
+}
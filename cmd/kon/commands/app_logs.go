@@ -0,0 +1,403 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+const (
+	// --release value that selects the release currently being rolled out
+	targetReleaseAlias = "target"
+	podPollInterval    = 2 * time.Second
+	colorReset         = "\033[0m"
+)
+
+var podColors = []string{
+	"\033[36m", // cyan
+	"\033[33m", // yellow
+	"\033[32m", // green
+	"\033[35m", // magenta
+	"\033[34m", // blue
+	"\033[91m", // bright red
+	"\033[96m", // bright cyan
+	"\033[93m", // bright yellow
+	"\033[92m", // bright green
+	"\033[95m", // bright magenta
+}
+
+type logOptions struct {
+	follow   bool
+	tail     int
+	since    time.Duration
+	previous bool
+	grep     *regexp.Regexp
+	color    bool
+	// prefix lines with the pod they came from
+	prefix bool
+}
+
+// podLogStreamer streams logs from the app's pods through kubectl, and picks up new pods when following
+type podLogStreamer struct {
+	kclient client.Client
+	app     string
+	target  string
+	// only stream pods of this release, or a single pod, when set
+	release string
+	pod     string
+	opts    logOptions
+	out     io.Writer
+
+	lock      sync.Mutex
+	wg        sync.WaitGroup
+	started   bool
+	streaming map[string]bool
+	stoppedAt map[string]time.Time
+	colors    map[string]string
+}
+
+func appLogs(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	opts, err := logOptionsFromFlags(c)
+	if err != nil {
+		return err
+	}
+	verb := "getting"
+	if opts.follow {
+		verb = "following"
+	}
+
+	history := c.Bool("history")
+	if history && (opts.follow || opts.previous) {
+		return fmt.Errorf("--history can't be used with --follow or --previous")
+	}
+
+	var streamer *podLogStreamer
+	if c.Bool("all") || history {
+		// pods may be gone when looking at history, so there's nothing to choose from
+		app, target, release, err := logScopeFromFlags(kclient, c)
+		if err != nil {
+			return err
+		}
+		streamer = newPodLogStreamer(kclient, app, target, opts)
+		streamer.release = release
+		streamer.pod = c.String("pod")
+		streamer.opts.prefix = streamer.pod == ""
+		if history {
+			return streamer.printLogHistory(kclient)
+		}
+		if release != "" {
+			fmt.Printf("%s logs for release %s\n", verb, release)
+		} else {
+			fmt.Printf("%s logs for all pods of %s in %s\n", verb, app, target)
+		}
+	} else {
+		pc, err := choosePodHelper(kclient, c)
+		if err != nil {
+			return err
+		}
+		streamer = newPodLogStreamer(kclient, pc.app, pc.target, opts)
+		streamer.pod = pc.pod
+		fmt.Printf("%s logs for pod %s\n", verb, pc.pod)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	go func() {
+		<-sigChan
+		cancel()
+	}()
+
+	return streamer.Run(ctx)
+}
+
+// logScopeFromFlags returns the target and the optional release to get logs from
+func logScopeFromFlags(kclient client.Client, c *cli.Context) (app, target, release string, err error) {
+	app, err = getAppArg(c)
+	if err != nil {
+		return
+	}
+	target = c.String("target")
+	if target == "" {
+		if target, err = selectAppTarget(kclient, app); err != nil {
+			return
+		}
+	}
+	release = c.String("release")
+	if release == targetReleaseAlias {
+		ar, err := resources.GetTargetRelease(kclient, app, target)
+		if err != nil {
+			return "", "", "", err
+		}
+		if ar == nil {
+			return "", "", "", fmt.Errorf("could not find a target release")
+		}
+		release = ar.Name
+	}
+	return
+}
+
+func logOptionsFromFlags(c *cli.Context) (opts logOptions, err error) {
+	opts = logOptions{
+		follow:   c.Bool("follow"),
+		tail:     c.Int("tail"),
+		since:    c.Duration("since"),
+		previous: c.Bool("previous"),
+		color:    !c.Bool("no-color"),
+	}
+	if opts.previous && opts.follow {
+		err = fmt.Errorf("--previous can't be used with --follow, logs from terminated containers are complete")
+		return
+	}
+	if grep := c.String("grep"); grep != "" {
+		opts.grep, err = regexp.Compile(grep)
+		if err != nil {
+			err = fmt.Errorf("invalid --grep pattern: %v", err)
+			return
+		}
+	}
+	return
+}
+
+func newPodLogStreamer(kclient client.Client, app, target string, opts logOptions) *podLogStreamer {
+	return &podLogStreamer{
+		kclient:   kclient,
+		app:       app,
+		target:    target,
+		opts:      opts,
+		out:       os.Stdout,
+		streaming: make(map[string]bool),
+		stoppedAt: make(map[string]time.Time),
+		colors:    make(map[string]string),
+	}
+}
+
+// Run streams until logs are complete, or until ctx is cancelled when following
+func (s *podLogStreamer) Run(ctx context.Context) error {
+	numStreams, err := s.streamNewPods(ctx)
+	if err != nil {
+		return err
+	}
+	if !s.opts.follow && numStreams == 0 {
+		fmt.Fprintln(s.out, "No pods with logs found")
+		return nil
+	}
+	if !s.opts.follow {
+		s.wg.Wait()
+		return nil
+	}
+
+	ticker := time.NewTicker(podPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.wg.Wait()
+			return nil
+		case <-ticker.C:
+			if _, err := s.streamNewPods(ctx); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *podLogStreamer) listPods() (pods []*corev1.Pod, err error) {
+	if s.pod != "" {
+		pod := &corev1.Pod{}
+		err = s.kclient.Get(context.TODO(), client.ObjectKey{Namespace: s.target, Name: s.pod}, pod)
+		if err != nil {
+			return
+		}
+		pods = append(pods, pod)
+		return
+	}
+	if s.release != "" {
+		pods, err = resources.GetPodsForAppRelease(s.kclient, s.target, s.release)
+	} else {
+		pods, err = resources.GetPodsForAppTarget(s.kclient, s.target, s.app)
+	}
+	if err != nil {
+		return
+	}
+	// keep colors consistent between runs
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	return
+}
+
+// streamNewPods starts streams for pods that aren't being streamed, and returns the number started
+func (s *podLogStreamer) streamNewPods(ctx context.Context) (int, error) {
+	pods, err := s.listPods()
+	if err != nil {
+		return 0, err
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	numStreams := 0
+	for _, pod := range pods {
+		args := s.nextStreamArgs(pod)
+		if args == nil {
+			continue
+		}
+		s.streaming[pod.Name] = true
+		s.wg.Add(1)
+		go s.stream(ctx, pod.Name, args)
+		numStreams += 1
+	}
+	s.started = true
+	return numStreams, nil
+}
+
+// nextStreamArgs returns kubectl args to stream the pod, or nil if it shouldn't be streamed (again).
+// Pods seen at the start get the last --tail lines, pods started later are streamed from the beginning, and
+// containers that have restarted are resumed from where their previous stream stopped
+func (s *podLogStreamer) nextStreamArgs(pod *corev1.Pod) []string {
+	if s.streaming[pod.Name] || pod.Status.Phase == corev1.PodPending {
+		return nil
+	}
+	if s.opts.previous && containerRestarts(pod, s.app) == 0 {
+		return nil
+	}
+	stoppedAt, streamed := s.stoppedAt[pod.Name]
+	if streamed && !containerRunning(pod, s.app) {
+		return nil
+	}
+
+	args := []string{"logs", pod.Name, "-n", s.target, "-c", s.app}
+	if s.opts.follow {
+		args = append(args, "-f")
+	}
+	if s.opts.previous {
+		args = append(args, "--previous")
+	}
+	switch {
+	case streamed:
+		args = append(args, "--since-time", stoppedAt.Format(time.RFC3339))
+	case s.started:
+		if s.opts.since != 0 {
+			args = append(args, "--since", s.opts.since.String())
+		}
+	default:
+		args = append(args, "--tail", strconv.Itoa(s.opts.tail))
+		if s.opts.since != 0 {
+			args = append(args, "--since", s.opts.since.String())
+		}
+	}
+	return args
+}
+
+func (s *podLogStreamer) stream(ctx context.Context, pod string, args []string) {
+	defer s.wg.Done()
+	defer func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.streaming[pod] = false
+		s.stoppedAt[pod] = time.Now()
+	}()
+
+	cmd := exec.CommandContext(ctx, "kubectl", args...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		s.writeLine(pod, err.Error(), false)
+		return
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		s.writeLine(pod, err.Error(), false)
+		return
+	}
+	if err = cmd.Start(); err != nil {
+		s.writeLine(pod, err.Error(), false)
+		return
+	}
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		s.copyLines(pod, stdout, true)
+	}()
+	go func() {
+		defer readers.Done()
+		s.copyLines(pod, stderr, false)
+	}()
+	readers.Wait()
+	_ = cmd.Wait()
+}
+
+func (s *podLogStreamer) copyLines(pod string, r io.Reader, filter bool) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		s.writeLine(pod, scanner.Text(), filter)
+	}
+}
+
+// writeLine prints a line with the pod's prefix, dropping it if it doesn't match --grep
+func (s *podLogStreamer) writeLine(pod, line string, filter bool) {
+	if filter && s.opts.grep != nil && !s.opts.grep.MatchString(line) {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.opts.prefix {
+		fmt.Fprintln(s.out, line)
+		return
+	}
+	prefix := fmt.Sprintf("[%s]", pod)
+	if s.opts.color {
+		color, ok := s.colors[pod]
+		if !ok {
+			color = podColors[len(s.colors)%len(podColors)]
+			s.colors[pod] = color
+		}
+		prefix = color + prefix + colorReset
+	}
+	fmt.Fprintln(s.out, prefix, line)
+}
+
+func containerRestarts(pod *corev1.Pod, container string) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
+
+func containerRunning(pod *corev1.Pod, container string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == container {
+			return status.State.Running != nil
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/pkg/components/loki"
+	"github.com/k11n/konstellation/pkg/resources"
+	utilscli "github.com/k11n/konstellation/pkg/utils/cli"
+	"github.com/k11n/konstellation/pkg/utils/retry"
+)
+
+const (
+	defaultHistorySince = time.Hour
+	// Loki's default max_entries_limit_per_query
+	maxHistoryLines = 5000
+)
+
+type logEntry struct {
+	time time.Time
+	pod  string
+	line string
+}
+
+type lokiQueryResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][]string        `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// printLogHistory prints logs stored in Loki, including ones from pods that are gone
+func (s *podLogStreamer) printLogHistory(kclient client.Client) error {
+	cc, err := resources.GetClusterConfig(kclient)
+	if err != nil {
+		return err
+	}
+	if cc.GetComponentConfig(loki.ComponentName) == nil {
+		return fmt.Errorf("%s is not installed on this cluster, log history isn't available. Run `kon cluster reinstall` to install it",
+			loki.ComponentName)
+	}
+
+	proxy, err := utilscli.NewKubeProxyForService(kclient, resources.KonSystemNamespace, loki.ServiceName, loki.ServicePort)
+	if err != nil {
+		return err
+	}
+	if err = proxy.Start(); err != nil {
+		return err
+	}
+	defer proxy.Stop()
+
+	since := s.opts.since
+	if since == 0 {
+		since = defaultHistorySince
+	}
+	limit := s.opts.tail
+	if limit <= 0 || limit > maxHistoryLines {
+		limit = maxHistoryLines
+	}
+	end := time.Now()
+	query := s.lokiLogQuery()
+
+	var entries []*logEntry
+	// port-forward takes a moment to be ready
+	err = retry.Retry(func() error {
+		entries, err = queryLogHistory(proxy.URL(), query, end.Add(-since), end, limit)
+		return err
+	}, 5, 500)
+	if err != nil {
+		return fmt.Errorf("could not query Loki: %v", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Fprintln(s.out, "No logs found")
+		return nil
+	}
+	for _, entry := range entries {
+		// --grep is applied by Loki
+		s.writeLine(entry.pod, entry.line, false)
+	}
+	if len(entries) == limit {
+		fmt.Fprintf(s.out, "\nshowing the latest %d lines, use --tail or --since to see more\n", limit)
+	}
+	return nil
+}
+
+// lokiLogQuery returns a LogQL query for the app's container, using labels set by Promtail
+func (s *podLogStreamer) lokiLogQuery() string {
+	selectors := []string{
+		fmt.Sprintf("namespace=%q", s.target),
+		fmt.Sprintf("app=%q", s.app),
+		fmt.Sprintf("container=%q", s.app),
+	}
+	if s.release != "" {
+		selectors = append(selectors, fmt.Sprintf("release=%q", s.release))
+	}
+	if s.pod != "" {
+		selectors = append(selectors, fmt.Sprintf("pod=%q", s.pod))
+	}
+	query := fmt.Sprintf("{%s}", strings.Join(selectors, ", "))
+	if s.opts.grep != nil {
+		query += fmt.Sprintf(" |~ %q", s.opts.grep.String())
+	}
+	return query
+}
+
+// queryLogHistory returns the latest entries in the time range, sorted from oldest to newest
+func queryLogHistory(baseURL, query string, start, end time.Time, limit int) ([]*logEntry, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("direction", "backward")
+
+	httpClient := &http.Client{Timeout: time.Minute}
+	res, err := httpClient.Get(fmt.Sprintf("%s/loki/api/v1/query_range?%s", baseURL, params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from Loki: %s", res.Status)
+	}
+
+	lr := lokiQueryResponse{}
+	if err = json.NewDecoder(res.Body).Decode(&lr); err != nil {
+		return nil, err
+	}
+	if lr.Data.ResultType != "streams" {
+		return nil, fmt.Errorf("unexpected result type %s", lr.Data.ResultType)
+	}
+
+	var entries []*logEntry
+	for _, stream := range lr.Data.Result {
+		for _, value := range stream.Values {
+			if len(value) != 2 {
+				continue
+			}
+			ts, err := strconv.ParseInt(value[0], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, &logEntry{
+				time: time.Unix(0, ts),
+				pod:  stream.Stream["pod"],
+				line: value[1],
+			})
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].time.Before(entries[j].time)
+	})
+	return entries, nil
+}
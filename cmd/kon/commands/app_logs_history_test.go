@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLokiLogQuery(t *testing.T) {
+	s := newPodLogStreamer(nil, "myapp", "production", logOptions{})
+	assert.Equal(t, `{namespace="production", app="myapp", container="myapp"}`, s.lokiLogQuery())
+
+	s.release = "myapp-1"
+	s.opts.grep = regexp.MustCompile(`status="5\d\d"`)
+	assert.Equal(t, `{namespace="production", app="myapp", container="myapp", release="myapp-1"} |~ "status=\"5\\d\\d\""`,
+		s.lokiLogQuery())
+}
+
+func TestQueryLogHistory(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/loki/api/v1/query_range", r.URL.Path)
+		query = r.URL.Query()
+		w.Write([]byte(`{
+  "status": "success",
+  "data": {
+    "resultType": "streams",
+    "result": [
+      {"stream": {"pod": "myapp-1-a"}, "values": [["3000", "third"], ["1000", "first"]]},
+      {"stream": {"pod": "myapp-2-a"}, "values": [["2000", "second"]]}
+    ]
+  }
+}`))
+	}))
+	defer server.Close()
+
+	end := time.Unix(0, 5000)
+	entries, err := queryLogHistory(server.URL, `{app="myapp"}`, end.Add(-4000), end, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{`{app="myapp"}`}, query["query"])
+	assert.Equal(t, []string{"1000"}, query["start"])
+	assert.Equal(t, []string{"backward"}, query["direction"])
+
+	var lines []string
+	for _, entry := range entries {
+		lines = append(lines, entry.pod+" "+entry.line)
+	}
+	assert.Equal(t, []string{"myapp-1-a first", "myapp-2-a second", "myapp-1-a third"}, lines)
+}
@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func newLogTestPod(name, release string, phase corev1.PodPhase, running bool, restarts int32) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "production",
+			Labels: map[string]string{
+				resources.AppLabel:        "myapp",
+				resources.AppReleaseLabel: release,
+			},
+		},
+		Status: corev1.PodStatus{
+			Phase: phase,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "myapp", RestartCount: restarts},
+			},
+		},
+	}
+	if running {
+		pod.Status.ContainerStatuses[0].State.Running = &corev1.ContainerStateRunning{}
+	}
+	return pod
+}
+
+func TestPodLogStreamerArgs(t *testing.T) {
+	s := newPodLogStreamer(nil, "myapp", "production", logOptions{follow: true, tail: 100, since: 10 * time.Minute})
+	running := newLogTestPod("myapp-1-a", "myapp-1", corev1.PodRunning, true, 0)
+	pending := newLogTestPod("myapp-1-b", "myapp-1", corev1.PodPending, false, 0)
+
+	assert.Equal(t, []string{"logs", "myapp-1-a", "-n", "production", "-c", "myapp", "-f", "--tail", "100", "--since", "10m0s"},
+		s.nextStreamArgs(running))
+	assert.Nil(t, s.nextStreamArgs(pending))
+
+	// already streaming
+	s.streaming[running.Name] = true
+	s.started = true
+	assert.Nil(t, s.nextStreamArgs(running))
+
+	// pods started mid-stream get their logs from the beginning
+	pending.Status.Phase = corev1.PodRunning
+	assert.Equal(t, []string{"logs", "myapp-1-b", "-n", "production", "-c", "myapp", "-f", "--since", "10m0s"},
+		s.nextStreamArgs(pending))
+
+	// resumes after the container restarts
+	stoppedAt := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+	s.streaming[running.Name] = false
+	s.stoppedAt[running.Name] = stoppedAt
+	running.Status.ContainerStatuses[0].State.Running = nil
+	assert.Nil(t, s.nextStreamArgs(running))
+	running.Status.ContainerStatuses[0].State.Running = &corev1.ContainerStateRunning{}
+	assert.Equal(t, []string{"logs", "myapp-1-a", "-n", "production", "-c", "myapp", "-f", "--since-time", "2020-07-01T10:00:00Z"},
+		s.nextStreamArgs(running))
+
+	// only containers that have crashed have previous logs
+	s = newPodLogStreamer(nil, "myapp", "production", logOptions{previous: true, tail: -1})
+	assert.Nil(t, s.nextStreamArgs(newLogTestPod("myapp-1-a", "myapp-1", corev1.PodRunning, true, 0)))
+	assert.Equal(t, []string{"logs", "myapp-1-a", "-n", "production", "-c", "myapp", "--previous", "--tail", "-1"},
+		s.nextStreamArgs(newLogTestPod("myapp-1-a", "myapp-1", corev1.PodRunning, true, 2)))
+}
+
+func TestPodLogStreamerListPods(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		newLogTestPod("myapp-2-a", "myapp-2", corev1.PodRunning, true, 0),
+		newLogTestPod("myapp-1-b", "myapp-1", corev1.PodRunning, true, 0),
+		newLogTestPod("myapp-1-a", "myapp-1", corev1.PodRunning, true, 0),
+	)
+
+	s := newPodLogStreamer(kclient, "myapp", "production", logOptions{})
+	pods, err := s.listPods()
+	assert.NoError(t, err)
+	var names []string
+	for _, pod := range pods {
+		names = append(names, pod.Name)
+	}
+	assert.Equal(t, []string{"myapp-1-a", "myapp-1-b", "myapp-2-a"}, names)
+
+	s.release = "myapp-2"
+	pods, err = s.listPods()
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, "myapp-2-a", pods[0].Name)
+
+	s.release = ""
+	s.pod = "myapp-1-b"
+	pods, err = s.listPods()
+	assert.NoError(t, err)
+	assert.Len(t, pods, 1)
+	assert.Equal(t, "myapp-1-b", pods[0].Name)
+}
+
+func TestPodLogStreamerWriteLine(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	s := newPodLogStreamer(nil, "myapp", "production", logOptions{
+		prefix: true,
+		color:  true,
+		grep:   regexp.MustCompile("error"),
+	})
+	s.out = buf
+
+	s.writeLine("pod-a", "an error occurred", true)
+	s.writeLine("pod-a", "all good", true)
+	s.writeLine("pod-b", "another error", true)
+	// errors from kubectl aren't filtered
+	s.writeLine("pod-b", "container not found", false)
+	assert.Equal(t, podColors[0]+"[pod-a]"+colorReset+" an error occurred\n"+
+		podColors[1]+"[pod-b]"+colorReset+" another error\n"+
+		podColors[1]+"[pod-b]"+colorReset+" container not found\n", buf.String())
+
+	buf.Reset()
+	s.opts.color = false
+	s.writeLine("pod-a", "an error occurred", true)
+	assert.Equal(t, "[pod-a] an error occurred\n", buf.String())
+
+	buf.Reset()
+	s.opts.prefix = false
+	s.writeLine("pod-a", "an error occurred", true)
+	assert.Equal(t, "an error occurred\n", buf.String())
+}
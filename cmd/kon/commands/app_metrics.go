@@ -0,0 +1,283 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	promapi "github.com/prometheus/client_golang/api"
+	promquery "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/resources"
+	utilscli "github.com/k11n/konstellation/pkg/utils/cli"
+	"github.com/k11n/konstellation/pkg/utils/retry"
+)
+
+const (
+	metricRequestRate = "rps"
+	metricErrorRate   = "errors"
+	metricP50         = "p50"
+	metricP95         = "p95"
+	metricP99         = "p99"
+	metricCPU         = "cpu"
+	metricMemory      = "memory"
+
+	// kube-state-metrics label for the release that a pod belongs to
+	podReleaseLabel = "label_k11n_dev_appRelease"
+	// pod label that envoy-stats scraping copies onto Istio series
+	istioReleaseLabel = "k11n_dev_appRelease"
+)
+
+// promQueryFunc runs an instant query against Prometheus
+type promQueryFunc func(query string) (model.Vector, error)
+
+type metricQuery struct {
+	name string
+	// label that identifies the release in the result
+	label string
+	query string
+}
+
+type releaseMetrics struct {
+	Release string
+	Traffic int32
+	Values  map[string]float64
+}
+
+func appMetrics(c *cli.Context) error {
+	appName, err := getAppArg(c)
+	if err != nil {
+		return err
+	}
+
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	target := c.String("target")
+	if target == "" {
+		target, err = selectAppTarget(kclient, appName)
+		if err != nil {
+			return err
+		}
+	}
+	window := c.Duration("window")
+	if window < time.Minute {
+		return fmt.Errorf("window must be at least 1m")
+	}
+
+	query, proxy, err := newPrometheusQuery(kclient)
+	if err != nil {
+		return err
+	}
+	defer proxy.Stop()
+
+	printMetrics := func() error {
+		releases, err := resources.GetAppReleases(kclient, appName, target)
+		if err != nil {
+			return err
+		}
+		metrics, err := collectAppMetrics(query, appName, target, window, releases)
+		if err != nil {
+			return err
+		}
+		if c.Bool("watch") {
+			utils.ClearScreen()
+		}
+		fmt.Printf("App: %s, target: %s, over the last %s\n\n", appName, target, model.Duration(window))
+		printAppMetrics(os.Stdout, metrics)
+		return nil
+	}
+
+	if err = printMetrics(); err != nil || !c.Bool("watch") {
+		return err
+	}
+
+	ticker := time.NewTicker(c.Duration("interval"))
+	defer ticker.Stop()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	for {
+		select {
+		case <-sigChan:
+			return nil
+		case <-ticker.C:
+			if err = printMetrics(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// newPrometheusQuery starts a proxy to the cluster's Prometheus, the caller is responsible for stopping it
+func newPrometheusQuery(kclient client.Client) (promQueryFunc, *utilscli.KubeProxy, error) {
+	proxy, err := utilscli.NewKubeProxyForService(kclient, resources.KonSystemNamespace, "prometheus-k8s", 9090)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = proxy.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	pc, err := promapi.NewClient(promapi.Config{Address: proxy.URL()})
+	if err != nil {
+		proxy.Stop()
+		return nil, nil, err
+	}
+	api := promquery.NewAPI(pc)
+	query := func(q string) (model.Vector, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		val, _, err := api.Query(ctx, q, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		vec, ok := val.(model.Vector)
+		if !ok {
+			return nil, fmt.Errorf("unexpected result type %s", val.Type())
+		}
+		return vec, nil
+	}
+
+	// port-forward takes a moment to be ready
+	err = retry.Retry(func() error {
+		_, err := query("vector(1)")
+		return err
+	}, 5, 500)
+	if err != nil {
+		proxy.Stop()
+		return nil, nil, fmt.Errorf("could not connect to Prometheus: %v", err)
+	}
+	return query, proxy, nil
+}
+
+func appMetricQueries(app, target string, window time.Duration) []metricQuery {
+	w := model.Duration(window).String()
+	requests := fmt.Sprintf(`reporter="destination", destination_workload_namespace="%s", destination_app="%s"`, target, app)
+	podLabels := fmt.Sprintf(`kube_pod_labels{namespace="%s", label_k11n_dev_app="%s"}`, target, app)
+	container := fmt.Sprintf(`namespace="%s", container="%s"`, target, app)
+
+	queries := []metricQuery{
+		{
+			name:  metricRequestRate,
+			label: istioReleaseLabel,
+			query: fmt.Sprintf(`sum by (%s) (rate(istio_requests_total{%s}[%s]))`, istioReleaseLabel, requests, w),
+		},
+		{
+			name:  metricErrorRate,
+			label: istioReleaseLabel,
+			query: fmt.Sprintf(`sum by (%s) (rate(istio_requests_total{%s, response_code=~"5.."}[%s])) / sum by (%s) (rate(istio_requests_total{%s}[%s]))`,
+				istioReleaseLabel, requests, w, istioReleaseLabel, requests, w),
+		},
+	}
+	for _, q := range [][]string{{metricP50, "0.5"}, {metricP95, "0.95"}, {metricP99, "0.99"}} {
+		name, quantile := q[0], q[1]
+		queries = append(queries, metricQuery{
+			name:  name,
+			label: istioReleaseLabel,
+			query: fmt.Sprintf(`histogram_quantile(%s, sum by (%s, le) (rate(istio_request_duration_milliseconds_bucket{%s}[%s])))`,
+				quantile, istioReleaseLabel, requests, w),
+		})
+	}
+	queries = append(queries,
+		metricQuery{
+			name:  metricCPU,
+			label: podReleaseLabel,
+			query: fmt.Sprintf(`sum by (%s) (rate(container_cpu_usage_seconds_total{%s}[%s]) * on(namespace, pod) group_left(%s) %s)`,
+				podReleaseLabel, container, w, podReleaseLabel, podLabels),
+		},
+		metricQuery{
+			name:  metricMemory,
+			label: podReleaseLabel,
+			query: fmt.Sprintf(`sum by (%s) (container_memory_working_set_bytes{%s} * on(namespace, pod) group_left(%s) %s)`,
+				podReleaseLabel, container, podReleaseLabel, podLabels),
+		},
+	)
+	return queries
+}
+
+// collectAppMetrics returns metrics for releases that are running or receiving traffic
+func collectAppMetrics(query promQueryFunc, app, target string, window time.Duration, releases []*v1alpha1.AppRelease) ([]*releaseMetrics, error) {
+	byRelease := make(map[string]map[string]float64)
+	for _, mq := range appMetricQueries(app, target, window) {
+		vec, err := query(mq.query)
+		if err != nil {
+			return nil, err
+		}
+		for _, sample := range vec {
+			v := float64(sample.Value)
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				continue
+			}
+			key := string(sample.Metric[model.LabelName(mq.label)])
+			if byRelease[key] == nil {
+				byRelease[key] = make(map[string]float64)
+			}
+			byRelease[key][mq.name] = v
+		}
+	}
+
+	var metrics []*releaseMetrics
+	for _, ar := range releases {
+		if ar.Spec.NumDesired == 0 && ar.Spec.TrafficPercentage == 0 && ar.Status.NumAvailable == 0 {
+			continue
+		}
+		rm := &releaseMetrics{
+			Release: ar.Name,
+			Traffic: ar.Spec.TrafficPercentage,
+			Values:  make(map[string]float64),
+		}
+		for name, v := range byRelease[ar.Name] {
+			rm.Values[name] = v
+		}
+		metrics = append(metrics, rm)
+	}
+	return metrics, nil
+}
+
+func printAppMetrics(w io.Writer, metrics []*releaseMetrics) {
+	if len(metrics) == 0 {
+		fmt.Fprintln(w, "No running releases")
+		return
+	}
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{
+		"Release", "Traffic", "Requests/s", "Errors", "P50", "P95", "P99", "CPU", "Memory",
+	})
+	for _, rm := range metrics {
+		format := func(name, f string, scale float64) string {
+			v, ok := rm.Values[name]
+			if !ok {
+				return "-"
+			}
+			return fmt.Sprintf(f, v*scale)
+		}
+		table.Append([]string{
+			rm.Release,
+			fmt.Sprintf("%d%%", rm.Traffic),
+			format(metricRequestRate, "%.2f", 1),
+			format(metricErrorRate, "%.2f%%", 100),
+			format(metricP50, "%.0fms", 1),
+			format(metricP95, "%.0fms", 1),
+			format(metricP99, "%.0fms", 1),
+			format(metricCPU, "%.0fm", 1000),
+			format(metricMemory, "%.0fMi", 1.0/(1024*1024)),
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+}
@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestCollectAppMetrics(t *testing.T) {
+	releases := []*v1alpha1.AppRelease{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-v2"},
+			Spec:       v1alpha1.AppReleaseSpec{Build: "build-v2", NumDesired: 1, TrafficPercentage: 10},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-v1"},
+			Spec:       v1alpha1.AppReleaseSpec{Build: "build-v1", NumDesired: 2, TrafficPercentage: 90},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-v0"},
+			Spec:       v1alpha1.AppReleaseSpec{Build: "build-v0"},
+		},
+	}
+
+	var queries []string
+	query := func(q string) (model.Vector, error) {
+		queries = append(queries, q)
+		switch {
+		case strings.HasPrefix(q, "histogram_quantile(0.99"):
+			return model.Vector{
+				{Metric: model.Metric{istioReleaseLabel: "myapp-v1"}, Value: 120},
+				{Metric: model.Metric{istioReleaseLabel: "myapp-v2"}, Value: model.SampleValue(math.NaN())},
+			}, nil
+		case strings.Contains(q, "istio_requests_total") && !strings.Contains(q, "5.."):
+			return model.Vector{
+				{Metric: model.Metric{istioReleaseLabel: "myapp-v1"}, Value: 9},
+				{Metric: model.Metric{istioReleaseLabel: "myapp-v2"}, Value: 1},
+			}, nil
+		case strings.Contains(q, "container_cpu_usage_seconds_total"):
+			return model.Vector{
+				{Metric: model.Metric{podReleaseLabel: "myapp-v1"}, Value: 0.25},
+			}, nil
+		}
+		return nil, nil
+	}
+
+	metrics, err := collectAppMetrics(query, "myapp", "production", 5*time.Minute, releases)
+	assert.NoError(t, err)
+	assert.Len(t, queries, 7)
+	assert.Contains(t, queries[0], `destination_app="myapp"`)
+	assert.Contains(t, queries[0], "[5m]")
+	assert.Contains(t, queries[0], "sum by (k11n_dev_appRelease)")
+
+	// releases without pods or traffic are left out
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, 1.0, metrics[0].Values[metricRequestRate])
+	_, ok := metrics[0].Values[metricP99]
+	assert.False(t, ok)
+	assert.Equal(t, 120.0, metrics[1].Values[metricP99])
+	assert.Equal(t, 0.25, metrics[1].Values[metricCPU])
+
+	buf := bytes.NewBuffer(nil)
+	printAppMetrics(buf, metrics)
+	assert.Contains(t, buf.String(), "250m")
+	assert.Contains(t, buf.String(), "120ms")
+}
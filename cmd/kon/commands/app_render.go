@@ -0,0 +1,98 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	errorshelper "github.com/pkg/errors"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/config"
+	"github.com/k11n/konstellation/cmd/kon/kube"
+	"github.com/k11n/konstellation/controllers"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func appRender(c *cli.Context) error {
+	appFile, err := getAppArg(c)
+	if err != nil {
+		return err
+	}
+	content, err := ioutil.ReadFile(appFile)
+	if err != nil {
+		return err
+	}
+	obj, _, err := kube.GetKubeDecoder().Decode(content, nil, &v1alpha1.App{})
+	if err != nil {
+		return errorshelper.Wrap(err, "could not load app")
+	}
+	app := obj.(*v1alpha1.App)
+	target := c.String("target")
+
+	renderer := &controllers.AppRenderer{
+		Log:                 logf.Log.WithName("render"),
+		Scheme:              kube.GetKubeScheme(),
+		IncludeIntermediate: c.Bool("all"),
+	}
+	if !c.Bool("offline") && config.GetConfig().SelectedCluster != "" {
+		ac, err := getActiveCluster()
+		if err != nil {
+			return err
+		}
+		renderer.Client = ac.kubernetesClient()
+	} else {
+		fmt.Fprintln(os.Stderr, "Rendering without a cluster. Configs and dependencies are left out, and the ingress is configured for AWS")
+		renderer.Client = offlineRenderClient(target)
+		renderer.SkipDependencies = true
+	}
+
+	objs, err := renderer.Render(app, target)
+	if err != nil {
+		return err
+	}
+	return printObjects(os.Stdout, objs)
+}
+
+// offlineRenderClient returns a client with the resources that rendering expects to find on a cluster
+func offlineRenderClient(target string) client.Client {
+	return fake.NewFakeClientWithScheme(kube.GetKubeScheme(),
+		&v1alpha1.ClusterConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "offline"},
+			Spec: v1alpha1.ClusterConfigSpec{
+				Cloud:   "aws",
+				Targets: []string{target},
+			},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: resources.IstioNamespace,
+				Name:      resources.IngressBackendName,
+			},
+		},
+	)
+}
+
+// printObjects writes objects as a multi-document YAML
+func printObjects(w io.Writer, objs []runtime.Object) error {
+	encoder := kube.GetKubeEncoder()
+	for i, obj := range objs {
+		if i > 0 {
+			if _, err := fmt.Fprintln(w, "---"); err != nil {
+				return err
+			}
+		}
+		if err := encoder.Encode(obj, w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/kube"
+	"github.com/k11n/konstellation/controllers"
+)
+
+func TestRenderOffline(t *testing.T) {
+	app := &v1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp"},
+		Spec: v1alpha1.AppSpec{
+			Image: "myapp",
+			AppCommonSpec: v1alpha1.AppCommonSpec{
+				Ports:        []v1alpha1.PortSpec{{Name: "http", Port: 80}},
+				Dependencies: []v1alpha1.AppReference{{Name: "backend"}},
+			},
+			Targets: []v1alpha1.TargetConfig{
+				{Name: "staging", Ingress: &v1alpha1.IngressConfig{Hosts: []string{"myapp.com"}}},
+			},
+		},
+	}
+	renderer := &controllers.AppRenderer{
+		Client:           offlineRenderClient("staging"),
+		Log:              logf.Log,
+		Scheme:           kube.GetKubeScheme(),
+		SkipDependencies: true,
+	}
+	objs, err := renderer.Render(app, "staging")
+	assert.NoError(t, err)
+
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, printObjects(buf, objs))
+	docs := strings.Split(buf.String(), "---\n")
+	assert.Len(t, docs, len(objs))
+	assert.Contains(t, docs[0], "kind: ReplicaSet")
+	assert.Contains(t, docs[len(docs)-1], "kind: Ingress")
+}
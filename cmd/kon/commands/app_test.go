@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
 )
 
 func TestParseImageInfo(t *testing.T) {
@@ -27,3 +29,44 @@ func TestParseImageInfo(t *testing.T) {
 	assert.Equal(t, "1", ai.DockerTag)
 	assert.Equal(t, "ecr.registry.com", ai.Registry)
 }
+
+func TestDeployableTargets(t *testing.T) {
+	app := &v1alpha1.App{
+		Spec: v1alpha1.AppSpec{
+			Targets: []v1alpha1.TargetConfig{
+				{Name: "staging"},
+				{Name: "production", DeployMode: v1alpha1.DeployHalt},
+			},
+		},
+	}
+	cc := &v1alpha1.ClusterConfig{
+		Spec: v1alpha1.ClusterConfigSpec{
+			Targets: []string{"staging", "production"},
+		},
+	}
+	assert.Equal(t, []string{"staging"}, deployableTargets(app, cc))
+
+	app.Spec.Targets[0].DeployMode = v1alpha1.DeployHalt
+	assert.Empty(t, deployableTargets(app, cc))
+}
+
+func TestDeployableTargetsOnCluster(t *testing.T) {
+	app := &v1alpha1.App{
+		Spec: v1alpha1.AppSpec{
+			Targets: []v1alpha1.TargetConfig{
+				{Name: "staging"},
+				{Name: "production"},
+			},
+		},
+	}
+	cc := &v1alpha1.ClusterConfig{
+		Spec: v1alpha1.ClusterConfigSpec{
+			Targets: []string{"production"},
+		},
+	}
+	assert.Equal(t, []string{"production"}, deployableTargets(app, cc))
+
+	// a cluster that runs none of the app's targets has nothing to deploy
+	cc.Spec.Targets = []string{"development"}
+	assert.Empty(t, deployableTargets(app, cc))
+}
@@ -0,0 +1,325 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/api/v1beta1"
+	"github.com/k11n/konstellation/cmd/kon/config"
+	"github.com/k11n/konstellation/cmd/kon/kube"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+const (
+	// issue types that don't come from field validation
+	issueDecode       = "DecodeError"
+	issueUnknownField = "UnknownField"
+	issueUnverified   = "Unverified"
+	issueSkipped      = "Skipped"
+)
+
+type manifestIssue struct {
+	Field   string `json:"field,omitempty"`
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+type manifestResult struct {
+	File     string          `json:"file"`
+	Kind     string          `json:"kind,omitempty"`
+	Name     string          `json:"name,omitempty"`
+	Errors   []manifestIssue `json:"errors"`
+	Warnings []manifestIssue `json:"warnings"`
+
+	app    *v1alpha1.App
+	config *v1alpha1.AppConfig
+	// only v1beta1 requires targets to be listed
+	requireTargets bool
+}
+
+type validationReport struct {
+	Valid   bool              `json:"valid"`
+	Results []*manifestResult `json:"results"`
+}
+
+// manifestValidator checks references between manifests. When kclient is set, references that
+// aren't part of the manifests are looked up on the cluster
+type manifestValidator struct {
+	kclient        client.Client
+	clusterTargets []string
+	apps           map[string]*v1alpha1.App
+	sharedConfigs  map[string]bool
+}
+
+func appValidate(c *cli.Context) error {
+	if c.NArg() == 0 {
+		return fmt.Errorf("at least one app.yaml is required")
+	}
+	output := c.String("output")
+	if output != "text" && output != "json" {
+		return fmt.Errorf("unsupported output format: %s", output)
+	}
+
+	var results []*manifestResult
+	for _, file := range c.Args().Slice() {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		results = append(results, decodeManifests(file, content)...)
+	}
+
+	v := &manifestValidator{}
+	if !c.Bool("offline") && config.GetConfig().SelectedCluster != "" {
+		ac, err := getActiveCluster()
+		if err != nil {
+			return err
+		}
+		v.kclient = ac.kubernetesClient()
+		cc, err := resources.GetClusterConfig(v.kclient)
+		if err == nil {
+			v.clusterTargets = cc.Spec.Targets
+		} else if err != resources.ErrNotFound {
+			return err
+		}
+	}
+
+	report, err := v.validate(results)
+	if err != nil {
+		return err
+	}
+
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err = encoder.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		printValidationReport(report, v.kclient == nil)
+	}
+
+	if !report.Valid {
+		// issues are already printed
+		return cli.Exit("", 1)
+	}
+	return nil
+}
+
+// decodeManifests returns a result for each document in the file. Decoding errors are recorded in the result
+func decodeManifests(file string, content []byte) []*manifestResult {
+	var results []*manifestResult
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		result := &manifestResult{File: file}
+		if err != nil {
+			result.addIssue(issueDecode, "", err.Error(), false)
+			results = append(results, result)
+			break
+		}
+
+		tm := metav1.TypeMeta{}
+		if err := yaml.Unmarshal(doc, &tm); err != nil {
+			result.addIssue(issueDecode, "", err.Error(), false)
+			results = append(results, result)
+			continue
+		}
+		if tm.Kind == "" {
+			// skip empty documents, or ones with only comments
+			var fields map[string]interface{}
+			if err := yaml.Unmarshal(doc, &fields); err == nil && len(fields) == 0 {
+				continue
+			}
+			result.addIssue(string(field.ErrorTypeRequired), "kind", "kind is required", false)
+			results = append(results, result)
+			continue
+		}
+		result.Kind = tm.Kind
+		decodeManifest(doc, tm, result)
+		results = append(results, result)
+	}
+	return results
+}
+
+func decodeManifest(doc []byte, tm metav1.TypeMeta, result *manifestResult) {
+	var obj runtime.Object
+	var strict interface{}
+	var convert func() error
+
+	switch {
+	case tm.Kind == "App" && tm.APIVersion == v1alpha1.GroupVersion.String():
+		result.app = &v1alpha1.App{}
+		obj, strict = result.app, &v1alpha1.App{}
+	case tm.Kind == "App" && tm.APIVersion == v1beta1.GroupVersion.String():
+		hub := &v1beta1.App{}
+		result.app = &v1alpha1.App{}
+		result.requireTargets = true
+		obj, strict = hub, &v1beta1.App{}
+		convert = func() error { return result.app.ConvertFrom(hub) }
+	case tm.Kind == "AppConfig" && tm.APIVersion == v1alpha1.GroupVersion.String():
+		result.config = &v1alpha1.AppConfig{}
+		obj, strict = result.config, &v1alpha1.AppConfig{}
+	case tm.Kind == "AppConfig" && tm.APIVersion == v1beta1.GroupVersion.String():
+		hub := &v1beta1.AppConfig{}
+		result.config = &v1alpha1.AppConfig{}
+		obj, strict = hub, &v1beta1.AppConfig{}
+		convert = func() error { return result.config.ConvertFrom(hub) }
+	case tm.Kind == "App" || tm.Kind == "AppConfig":
+		result.addIssue(string(field.ErrorTypeNotSupported), "apiVersion",
+			fmt.Sprintf("unsupported apiVersion %q, expected %s or %s", tm.APIVersion,
+				v1alpha1.GroupVersion.String(), v1beta1.GroupVersion.String()), false)
+		return
+	default:
+		result.addIssue(issueSkipped, "kind", fmt.Sprintf("%s is not a Konstellation app or config", tm.Kind), true)
+		return
+	}
+
+	if _, _, err := kube.GetKubeDecoder().Decode(doc, nil, obj); err != nil {
+		result.app = nil
+		result.config = nil
+		result.addIssue(issueDecode, "", err.Error(), false)
+		return
+	}
+	if convert != nil {
+		if err := convert(); err != nil {
+			result.app = nil
+			result.config = nil
+			result.addIssue(issueDecode, "", err.Error(), false)
+			return
+		}
+	}
+	if result.app != nil {
+		result.Name = result.app.Name
+	} else {
+		result.Name = result.config.Name
+	}
+
+	// fields that aren't part of the spec are dropped silently by the API server, these are usually typos
+	if err := yaml.UnmarshalStrict(doc, strict); err != nil {
+		result.addIssue(issueUnknownField, "", err.Error(), true)
+	}
+}
+
+// validate runs intrinsic validation on each manifest, and then checks its references
+func (v *manifestValidator) validate(results []*manifestResult) (*validationReport, error) {
+	v.apps = make(map[string]*v1alpha1.App)
+	v.sharedConfigs = make(map[string]bool)
+	for _, result := range results {
+		if result.app != nil {
+			v.apps[result.app.Name] = result.app
+		} else if result.config != nil && result.config.Type == v1alpha1.ConfigTypeShared {
+			v.sharedConfigs[result.config.GetSharedName()] = true
+		}
+	}
+
+	report := &validationReport{Valid: true, Results: results}
+	for _, result := range results {
+		refs := v.referenceValidator(result)
+		var errs field.ErrorList
+		if result.app != nil {
+			errs = result.app.Validate()
+			if result.requireTargets && len(result.app.Spec.Targets) == 0 {
+				errs = append(errs, field.Required(field.NewPath("spec", "targets"), "at least one target is required"))
+			}
+			refErrs, err := refs.ValidateAppRefs(result.app)
+			if err != nil {
+				return nil, err
+			}
+			errs = append(errs, refErrs...)
+		} else if result.config != nil {
+			errs = result.config.Validate()
+			errs = append(errs, refs.ValidateAppConfigRefs(result.config)...)
+		}
+		for _, err := range errs {
+			result.addIssue(string(err.Type), err.Field, err.ErrorBody(), false)
+		}
+		if len(result.Errors) > 0 {
+			report.Valid = false
+		}
+	}
+	return report, nil
+}
+
+// referenceValidator checks references against the other manifests first, and then the cluster when one is selected
+func (v *manifestValidator) referenceValidator(result *manifestResult) *resources.ReferenceValidator {
+	rv := &resources.ReferenceValidator{
+		ClusterTargets: v.clusterTargets,
+		Apps:           v.apps,
+		SharedConfigs:  v.sharedConfigs,
+		Unverified: func(path *field.Path, message string) {
+			result.addIssue(issueUnverified, path.String(), message+", select a cluster to verify it", true)
+		},
+	}
+	// avoid a typed nil
+	if v.kclient != nil {
+		rv.Reader = v.kclient
+	}
+	return rv
+}
+
+func (r *manifestResult) addIssue(issueType, path, message string, warning bool) {
+	issue := manifestIssue{
+		Field:   path,
+		Type:    issueType,
+		Message: message,
+	}
+	if warning {
+		r.Warnings = append(r.Warnings, issue)
+	} else {
+		r.Errors = append(r.Errors, issue)
+	}
+}
+
+func printValidationReport(report *validationReport, offline bool) {
+	numErrors := 0
+	for _, result := range report.Results {
+		name := result.File
+		if result.Name != "" {
+			name = fmt.Sprintf("%s (%s %s)", result.File, result.Kind, result.Name)
+		}
+		if len(result.Errors) == 0 && len(result.Warnings) == 0 {
+			fmt.Printf("%s: OK\n", name)
+			continue
+		}
+		fmt.Printf("%s:\n", name)
+		for _, issue := range result.Errors {
+			fmt.Printf("  error: %s\n", issue.String())
+		}
+		for _, issue := range result.Warnings {
+			fmt.Printf("  warning: %s\n", issue.String())
+		}
+		numErrors += len(result.Errors)
+	}
+
+	if offline {
+		fmt.Println("\nValidated without a cluster, targets and references outside of these files were not checked")
+	}
+	if !report.Valid {
+		fmt.Printf("\nFound %d errors\n", numErrors)
+	}
+}
+
+func (i manifestIssue) String() string {
+	if i.Field == "" {
+		return i.Message
+	}
+	return fmt.Sprintf("%s: %s", i.Field, i.Message)
+}
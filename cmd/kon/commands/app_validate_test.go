@@ -0,0 +1,138 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+const testManifests = `
+apiVersion: k11n.dev/v1alpha1
+kind: App
+metadata:
+  name: frontend
+spec:
+  image: frontend
+  ports:
+    - name: http
+      port: 80
+  dependencies:
+    - name: backend
+      port: grpc
+    - name: database
+  configs:
+    - common
+  targets:
+    - name: staging
+      ingress:
+        hosts: [frontend.com]
+        port: http
+---
+# backend
+apiVersion: k11n.dev/v1beta1
+kind: App
+metadata:
+  name: backend
+spec:
+  image: backend
+  ports:
+    - name: grpc
+      port: 9000
+  scale:
+    min: 2
+    mx: 4
+  targets:
+    - name: development
+---
+`
+
+func issueFields(issues []manifestIssue) []string {
+	var fields []string
+	for _, issue := range issues {
+		fields = append(fields, issue.Field)
+	}
+	return fields
+}
+
+func TestDecodeManifests(t *testing.T) {
+	results := decodeManifests("app.yaml", []byte(testManifests))
+	assert.Len(t, results, 2)
+	assert.Equal(t, "frontend", results[0].Name)
+	assert.Empty(t, results[0].Warnings)
+	assert.Equal(t, "backend", results[1].Name)
+	assert.Equal(t, int32(2), results[1].app.Spec.Scale.Min)
+	// typo in max
+	assert.Len(t, results[1].Warnings, 1)
+	assert.Equal(t, issueUnknownField, results[1].Warnings[0].Type)
+
+	results = decodeManifests("app.yaml", []byte(`
+apiVersion: k11n.dev/v1alpha1
+kind: App
+metadata:
+  name: myapp
+spec:
+  resources:
+    requests:
+      memory: 100 megabytes
+`))
+	assert.Len(t, results, 1)
+	assert.Nil(t, results[0].app)
+	assert.Equal(t, issueDecode, results[0].Errors[0].Type)
+
+	results = decodeManifests("app.yaml", []byte("apiVersion: v1\nkind: Service\n"))
+	assert.Empty(t, results[0].Errors)
+	assert.Equal(t, issueSkipped, results[0].Warnings[0].Type)
+}
+
+func TestValidateManifestsOffline(t *testing.T) {
+	v := &manifestValidator{}
+	report, err := v.validate(decodeManifests("app.yaml", []byte(testManifests)))
+	assert.NoError(t, err)
+	assert.True(t, report.Valid)
+
+	// database and common can't be verified
+	assert.Equal(t, []string{"spec.dependencies[1].name", "spec.configs[0]"},
+		issueFields(report.Results[0].Warnings))
+	assert.Equal(t, issueUnverified, report.Results[0].Warnings[0].Type)
+
+	// dependencies in the same manifests are checked
+	results := decodeManifests("app.yaml", []byte(testManifests))
+	results[1].app.Spec.Ports[0].Name = "http"
+	report, err = v.validate(results)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, []string{"spec.dependencies[0].port"}, issueFields(report.Results[0].Errors))
+}
+
+func TestValidateManifestsWithCluster(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	common := v1alpha1.NewSharedConfig("common", "")
+	v := &manifestValidator{
+		kclient: fake.NewFakeClientWithScheme(scheme,
+			&v1alpha1.App{ObjectMeta: metav1.ObjectMeta{Name: "database"}},
+			common,
+		),
+		clusterTargets: []string{"staging", "production"},
+	}
+
+	report, err := v.validate(decodeManifests("app.yaml", []byte(testManifests)))
+	assert.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Empty(t, report.Results[0].Errors)
+	assert.Empty(t, report.Results[0].Warnings)
+	assert.Equal(t, []string{"spec.targets[0].name"}, issueFields(report.Results[1].Errors))
+
+	v.kclient = fake.NewFakeClientWithScheme(scheme)
+	report, err = v.validate(decodeManifests("app.yaml", []byte(testManifests)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"spec.dependencies[1].name", "spec.configs[0]"},
+		issueFields(report.Results[0].Errors))
+}
@@ -0,0 +1,476 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+type rolloutOutcome int
+
+const (
+	rolloutPending rolloutOutcome = iota
+	rolloutSucceeded
+	rolloutFailed
+)
+
+const (
+	// exit codes of `kon app deploy --wait`, for CI pipelines
+	exitCodeRolloutFailed  = 1
+	exitCodeRolloutTimeout = 2
+	exitCodeNoTargets      = 3
+
+	maxWatchTransitions = 10
+	watchTimeFormat     = "15:04:05"
+)
+
+type watchEvent struct {
+	obj     interface{}
+	deleted bool
+}
+
+// rolloutWatcher follows AppTargets and AppReleases of an app as they are updated, and keeps track of
+// state transitions until the rollout completes or fails
+type rolloutWatcher struct {
+	app     string
+	targets []string
+	// when set, wait for a release of this build to become active
+	build string
+	// print transitions as they happen, instead of refreshing the screen
+	logOnly bool
+	out     io.Writer
+	now     func() time.Time
+
+	appTargets  map[string]*v1alpha1.AppTarget
+	releases    map[string]map[string]*v1alpha1.AppRelease
+	transitions []string
+}
+
+func newRolloutWatcher(app string, targets []string, build string) *rolloutWatcher {
+	w := &rolloutWatcher{
+		app:        app,
+		targets:    targets,
+		build:      build,
+		out:        os.Stdout,
+		now:        time.Now,
+		appTargets: make(map[string]*v1alpha1.AppTarget),
+		releases:   make(map[string]map[string]*v1alpha1.AppRelease),
+	}
+	for _, target := range targets {
+		w.releases[target] = make(map[string]*v1alpha1.AppRelease)
+	}
+	return w
+}
+
+// watchRollout watches the app's targets until the rollout is complete, returning an error for cli to exit with
+// when it fails or times out. A timeout of 0 waits until interrupted
+func watchRollout(ac *activeCluster, w *rolloutWatcher, timeout time.Duration) error {
+	kcache, err := ac.newKubernetesCache()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if timeout != 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	interrupted := make(chan struct{})
+	go func() {
+		select {
+		case <-sigChan:
+			close(interrupted)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	outcome, err := w.Run(ctx, kcache)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w.out)
+	w.printOutcome()
+	switch outcome {
+	case rolloutFailed:
+		return cli.Exit("", exitCodeRolloutFailed)
+	case rolloutPending:
+		select {
+		case <-interrupted:
+			return nil
+		default:
+		}
+		return cli.Exit(fmt.Sprintf("timed out after %s waiting for the rollout to complete", timeout), exitCodeRolloutTimeout)
+	}
+	return nil
+}
+
+// Run starts informers for AppTargets and AppReleases, and processes changes until the rollout completes,
+// or until ctx is done
+func (w *rolloutWatcher) Run(ctx context.Context, kcache cache.Cache) (rolloutOutcome, error) {
+	events := make(chan watchEvent, 100)
+	send := func(e watchEvent) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	}
+	handler := toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			send(watchEvent{obj: obj})
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			send(watchEvent{obj: obj})
+		},
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			send(watchEvent{obj: obj, deleted: true})
+		},
+	}
+	for _, obj := range []runtime.Object{&v1alpha1.AppTarget{}, &v1alpha1.AppRelease{}} {
+		informer, err := kcache.GetInformer(ctx, obj)
+		if err != nil {
+			return rolloutPending, err
+		}
+		informer.AddEventHandler(handler)
+	}
+
+	go func() {
+		_ = kcache.Start(ctx.Done())
+	}()
+	if !kcache.WaitForCacheSync(ctx.Done()) {
+		return rolloutPending, nil
+	}
+
+	// load the initial state, so that only changes show up as transitions
+	atList := v1alpha1.AppTargetList{}
+	if err := kcache.List(ctx, &atList); err != nil {
+		return rolloutPending, err
+	}
+	for i := range atList.Items {
+		w.updateAppTarget(&atList.Items[i], false)
+	}
+	arList := v1alpha1.AppReleaseList{}
+	if err := kcache.List(ctx, &arList); err != nil {
+		return rolloutPending, err
+	}
+	for i := range arList.Items {
+		w.updateAppRelease(&arList.Items[i], false)
+	}
+
+	w.render()
+	if outcome := w.outcome(); outcome != rolloutPending {
+		return outcome, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return rolloutPending, nil
+		case e := <-events:
+			w.handleEvent(e)
+			w.render()
+			if outcome := w.outcome(); outcome != rolloutPending {
+				return outcome, nil
+			}
+		}
+	}
+}
+
+func (w *rolloutWatcher) handleEvent(e watchEvent) {
+	switch obj := e.obj.(type) {
+	case *v1alpha1.AppTarget:
+		if e.deleted {
+			if w.isWatching(obj.Spec.App, obj.Spec.Target) {
+				delete(w.appTargets, obj.Spec.Target)
+				w.addTransition("%s: target was deleted", obj.Spec.Target)
+			}
+			return
+		}
+		w.updateAppTarget(obj, true)
+	case *v1alpha1.AppRelease:
+		if e.deleted {
+			if w.isWatching(obj.Spec.App, obj.Spec.Target) {
+				delete(w.releases[obj.Spec.Target], obj.Name)
+			}
+			return
+		}
+		w.updateAppRelease(obj, true)
+	}
+}
+
+func (w *rolloutWatcher) isWatching(app, target string) bool {
+	if app != w.app {
+		return false
+	}
+	_, ok := w.releases[target]
+	return ok
+}
+
+// updateAppTarget stores the latest AppTarget, recording changes to its phase and releases
+func (w *rolloutWatcher) updateAppTarget(at *v1alpha1.AppTarget, record bool) {
+	target := at.Spec.Target
+	if !w.isWatching(at.Spec.App, target) {
+		return
+	}
+	prev := w.appTargets[target]
+	w.appTargets[target] = at.DeepCopy()
+	if !record || prev == nil {
+		return
+	}
+
+	if at.Status.TargetRelease != prev.Status.TargetRelease && at.Status.TargetRelease != at.Status.ActiveRelease {
+		w.addTransition("%s: rolling out %s", target, at.Status.TargetRelease)
+	}
+	if at.Status.ActiveRelease != prev.Status.ActiveRelease {
+		w.addTransition("%s: %s is now active", target, at.Status.ActiveRelease)
+	}
+	if at.Status.Phase != prev.Status.Phase {
+		w.addTransition("%s: %s -> %s", target, prev.Status.Phase, at.Status.Phase)
+	}
+}
+
+// updateAppRelease stores the latest AppRelease, recording changes to its state, pods, traffic and pod errors
+func (w *rolloutWatcher) updateAppRelease(ar *v1alpha1.AppRelease, record bool) {
+	target := ar.Spec.Target
+	if !w.isWatching(ar.Spec.App, target) {
+		return
+	}
+	prev := w.releases[target][ar.Name]
+	w.releases[target][ar.Name] = ar.DeepCopy()
+	if !record {
+		return
+	}
+	if prev == nil {
+		w.addTransition("%s: created release %s", target, ar.Name)
+		return
+	}
+
+	if ar.Status.State != prev.Status.State && ar.Status.State != "" {
+		w.addTransition("%s: %s -> %s", ar.Name, prev.Status.State, ar.Status.State)
+	}
+	if ar.Spec.TrafficPercentage != prev.Spec.TrafficPercentage {
+		w.addTransition("%s: traffic %d%% -> %d%%", ar.Name, prev.Spec.TrafficPercentage, ar.Spec.TrafficPercentage)
+	}
+	if ar.Status.NumAvailable != prev.Status.NumAvailable || ar.Spec.NumDesired != prev.Spec.NumDesired {
+		w.addTransition("%s: %d/%d pods available", ar.Name, ar.Status.NumAvailable, ar.Spec.NumDesired)
+	}
+	if summary := podErrorSummary(ar); summary != "" && summary != podErrorSummary(prev) {
+		w.addTransition("%s: %s", ar.Name, summary)
+	}
+}
+
+func (w *rolloutWatcher) addTransition(format string, args ...interface{}) {
+	line := w.now().Format(watchTimeFormat) + "  " + fmt.Sprintf(format, args...)
+	w.transitions = append(w.transitions, line)
+	if w.logOnly {
+		fmt.Fprintln(w.out, line)
+	}
+}
+
+// outcome is failed when any of the targets has failed, and succeeded once all of them have
+func (w *rolloutWatcher) outcome() rolloutOutcome {
+	outcome := rolloutSucceeded
+	for _, target := range w.targets {
+		switch o, _ := w.targetOutcome(target); o {
+		case rolloutFailed:
+			return rolloutFailed
+		case rolloutPending:
+			outcome = rolloutPending
+		}
+	}
+	return outcome
+}
+
+// targetOutcome returns the state of the rollout in the target, along with a description
+func (w *rolloutWatcher) targetOutcome(target string) (rolloutOutcome, string) {
+	at := w.appTargets[target]
+	if at == nil {
+		return rolloutPending, "waiting for the target to be created"
+	}
+	if w.build != "" && at.Spec.Build != w.build {
+		return rolloutPending, fmt.Sprintf("waiting for build %s", w.build)
+	}
+	if at.Status.Phase == v1alpha1.AppTargetPhaseHalted {
+		return rolloutFailed, "target is halted"
+	}
+
+	release := w.releases[target][at.Status.TargetRelease]
+	if w.build != "" {
+		release = w.latestReleaseForBuild(target)
+	}
+	if release == nil {
+		return rolloutPending, "waiting for the release to be created"
+	}
+	switch {
+	case release.Status.State == v1alpha1.ReleaseStateFailed:
+		return rolloutFailed, fmt.Sprintf("release %s has failed", release.Name)
+	case release.Spec.Role == v1alpha1.ReleaseRoleBad || release.Status.State == v1alpha1.ReleaseStateBad:
+		return rolloutFailed, fmt.Sprintf("release %s has been marked as bad", release.Name)
+	case at.Status.ActiveRelease == release.Name && at.Status.Phase == v1alpha1.AppTargetPhaseRunning:
+		return rolloutSucceeded, fmt.Sprintf("release %s is active", release.Name)
+	default:
+		return rolloutPending, fmt.Sprintf("rolling out %s", release.Name)
+	}
+}
+
+func (w *rolloutWatcher) latestReleaseForBuild(target string) *v1alpha1.AppRelease {
+	var latest *v1alpha1.AppRelease
+	for _, ar := range w.releases[target] {
+		if ar.Spec.Build != w.build {
+			continue
+		}
+		if latest == nil || latest.CreationTimestamp.Before(&ar.CreationTimestamp) {
+			latest = ar
+		}
+	}
+	return latest
+}
+
+func (w *rolloutWatcher) printOutcome() {
+	failed := false
+	for _, target := range w.targets {
+		outcome, description := w.targetOutcome(target)
+		if outcome == rolloutFailed {
+			failed = true
+		}
+		fmt.Fprintf(w.out, "%s: %s\n", target, description)
+	}
+	if failed {
+		fmt.Fprintf(w.out, "Run `kon app diagnose %s` to find out why\n", w.app)
+	}
+}
+
+// render refreshes the screen with the current state, or prints the outcome of each target once when logging
+func (w *rolloutWatcher) render() {
+	if w.logOnly {
+		return
+	}
+
+	utils.ClearScreen()
+	fmt.Fprintf(w.out, "App: %s, watching until the rollout completes (ctrl-c to stop)\n\n", w.app)
+	for _, target := range w.targets {
+		at := w.appTargets[target]
+		_, description := w.targetOutcome(target)
+		if at == nil {
+			fmt.Fprintf(w.out, "Target: %s, %s\n\n", target, description)
+			continue
+		}
+		fmt.Fprintf(w.out, "Target: %s, phase: %s, %s\n", target, at.Status.Phase, description)
+		w.printReleases(at)
+		fmt.Fprintln(w.out)
+
+		for _, ar := range w.sortedReleases(target) {
+			if len(ar.Status.PodErrors) == 0 || ar.Spec.NumDesired == 0 {
+				continue
+			}
+			fmt.Fprintf(w.out, "Failing pods of %s\n", ar.Name)
+			printContainerStatuses(w.out, ar.Status.PodErrors, true)
+			fmt.Fprintln(w.out)
+		}
+	}
+
+	if len(w.transitions) > 0 {
+		fmt.Fprintln(w.out, "Recent changes")
+		transitions := w.transitions
+		if len(transitions) > maxWatchTransitions {
+			transitions = transitions[len(transitions)-maxWatchTransitions:]
+		}
+		for _, line := range transitions {
+			fmt.Fprintln(w.out, "  "+line)
+		}
+	}
+}
+
+func (w *rolloutWatcher) printReleases(at *v1alpha1.AppTarget) {
+	table := tablewriter.NewWriter(w.out)
+	table.SetHeader([]string{"Release", "Role", "State", "Desired", "Ready", "Available", "Traffic"})
+	for _, ar := range w.sortedReleases(at.Spec.Target) {
+		// skip retired releases
+		if ar.Spec.NumDesired == 0 && ar.Status.NumAvailable == 0 &&
+			ar.Name != at.Status.TargetRelease && ar.Name != at.Status.ActiveRelease {
+			continue
+		}
+		table.Append([]string{
+			ar.Name,
+			string(ar.Spec.Role),
+			ar.Status.State.String(),
+			fmt.Sprintf("%d", ar.Spec.NumDesired),
+			fmt.Sprintf("%d", ar.Status.NumReady),
+			fmt.Sprintf("%d", ar.Status.NumAvailable),
+			fmt.Sprintf("%d%%", ar.Spec.TrafficPercentage),
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+}
+
+func (w *rolloutWatcher) sortedReleases(target string) []*v1alpha1.AppRelease {
+	var releases []*v1alpha1.AppRelease
+	for _, ar := range w.releases[target] {
+		releases = append(releases, ar)
+	}
+	sort.Slice(releases, func(i, j int) bool {
+		return releases[i].Name < releases[j].Name
+	})
+	resources.SortAppReleasesByLatest(releases)
+	return releases
+}
+
+// podErrorSummary describes why pods of the release are failing, i.e. "2 pods failing: CrashLoopBackOff"
+func podErrorSummary(ar *v1alpha1.AppRelease) string {
+	if len(ar.Status.PodErrors) == 0 {
+		return ""
+	}
+	var reasons []string
+	seen := make(map[string]bool)
+	addReason := func(reason string) {
+		if reason != "" && !seen[reason] {
+			seen[reason] = true
+			reasons = append(reasons, reason)
+		}
+	}
+	for _, status := range ar.Status.PodErrors {
+		addReason(status.Reason)
+		for _, container := range status.Containers {
+			if container.Ready {
+				continue
+			}
+			addReason(container.Reason)
+			if container.State == v1alpha1.ContainerStateRunning {
+				addReason(container.LastTerminationReason)
+			}
+		}
+	}
+	sort.Strings(reasons)
+
+	summary := "1 pod failing"
+	if len(ar.Status.PodErrors) > 1 {
+		summary = fmt.Sprintf("%d pods failing", len(ar.Status.PodErrors))
+	}
+	if len(reasons) > 0 {
+		summary += ": " + strings.Join(reasons, ", ")
+	}
+	return summary
+}
@@ -0,0 +1,141 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func newWatchTestTarget(phase v1alpha1.AppTargetPhase, build, active, target string) *v1alpha1.AppTarget {
+	return &v1alpha1.AppTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-production"},
+		Spec: v1alpha1.AppTargetSpec{
+			App:    "myapp",
+			Target: "production",
+			Build:  build,
+		},
+		Status: v1alpha1.AppTargetStatus{
+			Phase:         phase,
+			ActiveRelease: active,
+			TargetRelease: target,
+		},
+	}
+}
+
+func newWatchTestRelease(name, build string, state v1alpha1.ReleaseState, traffic, available int32) *v1alpha1.AppRelease {
+	return &v1alpha1.AppRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "production"},
+		Spec: v1alpha1.AppReleaseSpec{
+			App:               "myapp",
+			Target:            "production",
+			Build:             build,
+			NumDesired:        2,
+			TrafficPercentage: traffic,
+		},
+		Status: v1alpha1.AppReleaseStatus{
+			State:        state,
+			NumAvailable: available,
+		},
+	}
+}
+
+func newTestRolloutWatcher(build string) (*rolloutWatcher, *bytes.Buffer) {
+	buf := bytes.NewBuffer(nil)
+	w := newRolloutWatcher("myapp", []string{"production"}, build)
+	w.out = buf
+	w.logOnly = true
+	w.now = func() time.Time {
+		return time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+	}
+	return w, buf
+}
+
+func TestRolloutWatcherTransitions(t *testing.T) {
+	w, buf := newTestRolloutWatcher("")
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseRunning, "build-1", "myapp-1", "myapp-1"), false)
+	w.updateAppRelease(newWatchTestRelease("myapp-1", "build-1", v1alpha1.ReleaseStateReleased, 100, 2), false)
+	// other apps are ignored
+	other := newWatchTestRelease("other-1", "build-1", v1alpha1.ReleaseStateReleased, 100, 2)
+	other.Spec.App = "other"
+	w.updateAppRelease(other, true)
+	assert.Empty(t, buf.String())
+	assert.Equal(t, rolloutSucceeded, w.outcome())
+
+	w.handleEvent(watchEvent{obj: newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateNew, 0, 0)})
+	w.handleEvent(watchEvent{obj: newWatchTestTarget(v1alpha1.AppTargetPhaseDeploying, "build-2", "myapp-1", "myapp-2")})
+	assert.Equal(t, rolloutPending, w.outcome())
+
+	w.handleEvent(watchEvent{obj: newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateReleasing, 50, 1)})
+	failing := newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateReleasing, 50, 1)
+	failing.Status.PodErrors = []v1alpha1.PodStatus{
+		{
+			Pod: "myapp-2-a",
+			Containers: []v1alpha1.PodContainerStatus{
+				{Name: "myapp", State: v1alpha1.ContainerStateWaiting, Reason: "CrashLoopBackOff"},
+			},
+		},
+	}
+	w.handleEvent(watchEvent{obj: failing})
+	w.handleEvent(watchEvent{obj: newWatchTestTarget(v1alpha1.AppTargetPhaseRunning, "build-2", "myapp-2", "myapp-2")})
+	w.handleEvent(watchEvent{obj: newWatchTestRelease("myapp-1", "build-1", v1alpha1.ReleaseStateRetiring, 0, 2)})
+
+	assert.Equal(t, `10:00:00  production: created release myapp-2
+10:00:00  production: rolling out myapp-2
+10:00:00  production: running -> deploying
+10:00:00  myapp-2: new -> releasing
+10:00:00  myapp-2: traffic 0% -> 50%
+10:00:00  myapp-2: 1/2 pods available
+10:00:00  myapp-2: 1 pod failing: CrashLoopBackOff
+10:00:00  production: myapp-2 is now active
+10:00:00  production: deploying -> running
+10:00:00  myapp-1: released -> retiring
+10:00:00  myapp-1: traffic 100% -> 0%
+`, buf.String())
+	assert.Equal(t, rolloutSucceeded, w.outcome())
+}
+
+func TestRolloutWatcherOutcome(t *testing.T) {
+	w, _ := newTestRolloutWatcher("build-2")
+	outcome, _ := w.targetOutcome("production")
+	assert.Equal(t, rolloutPending, outcome)
+
+	// target hasn't picked up the build yet
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseRunning, "build-1", "myapp-1", "myapp-1"), false)
+	w.updateAppRelease(newWatchTestRelease("myapp-1", "build-1", v1alpha1.ReleaseStateReleased, 100, 2), false)
+	outcome, description := w.targetOutcome("production")
+	assert.Equal(t, rolloutPending, outcome)
+	assert.Equal(t, "waiting for build build-2", description)
+
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseDeploying, "build-2", "myapp-1", "myapp-2"), false)
+	w.updateAppRelease(newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateReleasing, 0, 0), false)
+	outcome, description = w.targetOutcome("production")
+	assert.Equal(t, rolloutPending, outcome)
+	assert.Equal(t, "rolling out myapp-2", description)
+
+	w.updateAppRelease(newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateFailed, 0, 0), false)
+	outcome, description = w.targetOutcome("production")
+	assert.Equal(t, rolloutFailed, outcome)
+	assert.Equal(t, "release myapp-2 has failed", description)
+	assert.Equal(t, rolloutFailed, w.outcome())
+
+	// rolled back after being marked as bad, target goes back to the previous release
+	bad := newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateBad, 0, 0)
+	bad.Spec.Role = v1alpha1.ReleaseRoleBad
+	w.updateAppRelease(bad, false)
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseRunning, "build-2", "myapp-1", "myapp-1"), false)
+	outcome, description = w.targetOutcome("production")
+	assert.Equal(t, rolloutFailed, outcome)
+	assert.Equal(t, "release myapp-2 has been marked as bad", description)
+
+	w.updateAppRelease(newWatchTestRelease("myapp-2", "build-2", v1alpha1.ReleaseStateReleased, 100, 2), false)
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseRunning, "build-2", "myapp-2", "myapp-2"), false)
+	assert.Equal(t, rolloutSucceeded, w.outcome())
+
+	w.updateAppTarget(newWatchTestTarget(v1alpha1.AppTargetPhaseHalted, "build-2", "myapp-2", "myapp-2"), false)
+	assert.Equal(t, rolloutFailed, w.outcome())
+}
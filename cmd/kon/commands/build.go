@@ -0,0 +1,470 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/registry"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+var (
+	buildImageFlag = &cli.StringFlag{
+		Name:  "image",
+		Usage: "only include builds of this image",
+	}
+	buildMetadataFlags = []cli.Flag{
+		&cli.StringFlag{
+			Name:  "commit",
+			Usage: "git commit SHA of the build",
+		},
+		&cli.StringFlag{
+			Name:  "branch",
+			Usage: "git branch of the build",
+		},
+		&cli.StringFlag{
+			Name:  "author",
+			Usage: "author of the commit",
+		},
+		&cli.StringFlag{
+			Name:  "ci-url",
+			Usage: "link to the CI job that produced the build",
+		},
+		&cli.StringFlag{
+			Name:  "message",
+			Usage: "commit message or changelog",
+		},
+		&cli.StringFlag{
+			Name:  "digest",
+			Usage: "image digest (sha256:...)",
+		},
+	}
+)
+
+var BuildCommands = []*cli.Command{
+	{
+		Name:     "build",
+		Usage:    "Build commands",
+		Category: "App",
+		Before: func(c *cli.Context) error {
+			return ensureClusterSelected()
+		},
+		Subcommands: []*cli.Command{
+			{
+				Name:   "create",
+				Usage:  "Register a build without deploying it",
+				Action: buildCreate,
+				Flags: append([]cli.Flag{
+					&cli.StringFlag{
+						Name:     "image",
+						Usage:    "docker image, including the registry when not using Docker Hub",
+						Required: true,
+					},
+					&cli.StringFlag{
+						Name:     "tag",
+						Usage:    "image tag",
+						Required: true,
+					},
+				}, buildMetadataFlags...),
+			},
+			{
+				Name:   "list",
+				Usage:  "List builds, most recent first",
+				Action: buildList,
+				Flags: []cli.Flag{
+					buildImageFlag,
+				},
+			},
+			{
+				Name:   "prune",
+				Usage:  "Delete builds that are no longer used by any app or release",
+				Action: buildPrune,
+				Flags: []cli.Flag{
+					&cli.DurationFlag{
+						Name:  "min-age",
+						Usage: "only delete builds older than this",
+						Value: resources.DefaultBuildRetention,
+					},
+					&cli.IntFlag{
+						Name:  "keep",
+						Usage: "number of most recent builds to keep for each image",
+						Value: resources.DefaultBuildsKeptPerImage,
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "list builds that would be deleted without deleting them",
+					},
+				},
+			},
+			{
+				Name:      "refresh",
+				Usage:     "Resolve the image digest of builds again, in case their tags were re-pushed",
+				Action:    buildRefresh,
+				ArgsUsage: "[build...]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "image",
+						Usage: "refresh all builds of this image",
+					},
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "Show details of a build",
+				Action:    buildShow,
+				ArgsUsage: "<build>",
+			},
+			{
+				Name:      "usage",
+				Usage:     "Show apps, targets and releases that run each build",
+				Action:    buildUsage,
+				ArgsUsage: "[build...]",
+				Flags: []cli.Flag{
+					buildImageFlag,
+				},
+			},
+		},
+	},
+}
+
+func buildCreate(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	ai := &appInfo{}
+	parseImageInfo(c.String("image"), ai)
+	tag := c.String("tag")
+
+	source, digest := buildMetadataFromContext(kclient, ai.Registry, ai.DockerImage, tag, c)
+	build := v1alpha1.NewBuild(ai.Registry, ai.DockerImage, tag)
+	op, err := resources.SaveBuild(kclient, build, source, digest)
+	if err != nil {
+		return err
+	}
+
+	switch op {
+	case controllerutil.OperationResultCreated:
+		fmt.Printf("Created build %s\n", build.Name)
+	case controllerutil.OperationResultUpdated:
+		fmt.Printf("Updated metadata of existing build %s\n", build.Name)
+	default:
+		fmt.Printf("Build %s already exists\n", build.Name)
+	}
+	return nil
+}
+
+func buildList(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	builds, err := listBuilds(kclient, c.String("image"))
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Build", "Registry", "Image", "Tag", "Commit", "Created", "Latest"})
+	for _, build := range builds {
+		latest := ""
+		if build.Labels[resources.BuildTypeLabel] == resources.BuildTypeLatest {
+			latest = "yes"
+		}
+		table.Append([]string{
+			build.Name,
+			build.Spec.Registry,
+			build.Spec.Image,
+			build.Spec.Tag,
+			build.ShortCommit(),
+			build.CreationTimestamp.Format(cliDateFormat),
+			latest,
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+	return nil
+}
+
+func buildShow(c *cli.Context) error {
+	if c.NArg() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("required arg <build> was not passed in")
+	}
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	build, err := resources.GetBuildByName(kclient, c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetAutoWrapText(false)
+	utils.FormatPlainTable(table)
+	table.Append([]string{"Build:", build.Name})
+	table.Append([]string{"Image:", build.FullImageWithTag()})
+	table.Append([]string{"Digest:", build.Spec.Digest})
+	table.Append([]string{"Created:", build.CreationTimestamp.Format(cliDateFormat)})
+	table.Append([]string{"Latest:", fmt.Sprintf("%t", build.Labels[resources.BuildTypeLabel] == resources.BuildTypeLatest)})
+	if source := build.Spec.Source; source != nil {
+		table.Append([]string{"Commit:", source.Commit})
+		table.Append([]string{"Branch:", source.Branch})
+		table.Append([]string{"Author:", source.Author})
+		table.Append([]string{"CI:", source.CIURL})
+		table.Append([]string{"Message:", source.Message})
+	}
+	table.Render()
+	fmt.Println()
+
+	usage, err := resources.GetBuildUsage(kclient)
+	if err != nil {
+		return err
+	}
+	printBuildUsage([]*v1alpha1.Build{build}, usage)
+	return nil
+}
+
+func buildUsage(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	var builds []*v1alpha1.Build
+	if c.NArg() > 0 {
+		for _, name := range c.Args().Slice() {
+			build, err := resources.GetBuildByName(kclient, name)
+			if err != nil {
+				return err
+			}
+			builds = append(builds, build)
+		}
+	} else {
+		if builds, err = listBuilds(kclient, c.String("image")); err != nil {
+			return err
+		}
+	}
+
+	usage, err := resources.GetBuildUsage(kclient)
+	if err != nil {
+		return err
+	}
+	if c.NArg() == 0 {
+		// only show builds that are used when listing all
+		var used []*v1alpha1.Build
+		for _, build := range builds {
+			if usage[build.Name] != nil {
+				used = append(used, build)
+			}
+		}
+		builds = used
+	}
+	printBuildUsage(builds, usage)
+	return nil
+}
+
+func printBuildUsage(builds []*v1alpha1.Build, usage map[string]*resources.BuildUsage) {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Build", "Image", "Apps", "Targets", "Releases"})
+	table.SetAutoWrapText(false)
+	for _, build := range builds {
+		u := usage[build.Name]
+		if u == nil {
+			u = &resources.BuildUsage{}
+		}
+		var targets, releases []string
+		for _, at := range u.Targets {
+			targets = append(targets, fmt.Sprintf("%s/%s", at.Spec.App, at.Spec.Target))
+		}
+		for _, ar := range u.Releases {
+			release := fmt.Sprintf("%s/%s", ar.Namespace, ar.Name)
+			if ar.Spec.TrafficPercentage > 0 {
+				release += fmt.Sprintf(" (%d%%)", ar.Spec.TrafficPercentage)
+			}
+			releases = append(releases, release)
+		}
+		table.Append([]string{
+			build.Name,
+			build.ShortName(),
+			strings.Join(u.Apps, "\n"),
+			strings.Join(targets, "\n"),
+			strings.Join(releases, "\n"),
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.SetRowLine(true)
+	table.Render()
+}
+
+func buildPrune(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	builds, err := resources.FindBuildsToPrune(kclient, resources.BuildPrunePolicy{
+		MinAge:       c.Duration("min-age"),
+		KeepPerImage: c.Int("keep"),
+	})
+	if err != nil {
+		return err
+	}
+	if len(builds) == 0 {
+		fmt.Println("There are no builds to prune.")
+		return nil
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Build", "Image", "Created"})
+	for _, build := range builds {
+		table.Append([]string{
+			build.Name,
+			build.ShortName(),
+			build.CreationTimestamp.Format(cliDateFormat),
+		})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+
+	if c.Bool("dry-run") {
+		fmt.Printf("Dry run, %d builds would be deleted.\n", len(builds))
+		return nil
+	}
+
+	err = utils.ExplicitConfirmationPrompt(fmt.Sprintf("Delete %d builds?", len(builds)))
+	if err != nil {
+		return err
+	}
+	for _, build := range builds {
+		if err = client.IgnoreNotFound(kclient.Delete(context.TODO(), build)); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("Deleted %d builds.\n", len(builds))
+	return nil
+}
+
+func buildRefresh(c *cli.Context) error {
+	image := c.String("image")
+	if c.NArg() == 0 && image == "" {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("either builds or --image is required")
+	}
+
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+
+	var builds []*v1alpha1.Build
+	for _, name := range c.Args().Slice() {
+		build, err := resources.GetBuildByName(kclient, name)
+		if err != nil {
+			return err
+		}
+		builds = append(builds, build)
+	}
+	if image != "" {
+		imageBuilds, err := listBuilds(kclient, image)
+		if err != nil {
+			return err
+		}
+		builds = append(builds, imageBuilds...)
+	}
+
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Build", "Image", "Previous Digest", "Digest"})
+	table.SetAutoWrapText(false)
+	for _, build := range builds {
+		digest, err := resources.ResolveBuildDigest(kclient, kclient, build)
+		if err != nil {
+			fmt.Printf("Could not resolve digest for %s: %v\n", build.ShortName(), err)
+			continue
+		}
+		previous := build.Spec.Digest
+		if digest != previous {
+			build.Spec.Digest = digest
+			if _, err = resources.UpdateResource(kclient, build, nil, nil); err != nil {
+				return err
+			}
+		}
+		table.Append([]string{build.Name, build.ShortName(), previous, digest})
+	}
+	utils.FormatStandardTable(table)
+	table.Render()
+	return nil
+}
+
+// lists builds sorted by most recent first, optionally limited to an image
+func listBuilds(kclient client.Client, image string) ([]*v1alpha1.Build, error) {
+	var builds []*v1alpha1.Build
+	err := resources.ForEach(kclient, &v1alpha1.BuildList{}, func(item interface{}) error {
+		build := item.(v1alpha1.Build)
+		if image == "" || build.Spec.Image == image || build.ImagePath() == image {
+			builds = append(builds, &build)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(builds, func(i, j int) bool {
+		return builds[i].CreationTimestamp.After(builds[j].CreationTimestamp.Time)
+	})
+	return builds, nil
+}
+
+// reads source metadata from image labels when the registry is reachable, with flags taking precedence.
+// returns nil source if nothing is known
+func buildMetadataFromContext(kclient client.Client, registryName, image, tag string, c *cli.Context) (*v1alpha1.BuildSource, string) {
+	source := &v1alpha1.BuildSource{}
+	digest := c.String("digest")
+
+	creds, err := resources.GetImagePullCredentialsForImage(kclient, kclient, registryName, image)
+	if err != nil {
+		fmt.Printf("Could not read image pull secrets: %v\n", err)
+	}
+	rc := registry.NewClient(creds)
+	repository := registry.NormalizeRepository(registryName, image)
+	if config, err := rc.GetImageConfig(registryName, repository, tag); err == nil {
+		source.MergeWith(resources.BuildSourceFromLabels(config.Labels))
+	} else {
+		fmt.Printf("Could not read image labels: %v\n", err)
+	}
+	if digest == "" {
+		digest, _ = rc.GetDigest(registryName, repository, tag)
+	}
+
+	source.MergeWith(&v1alpha1.BuildSource{
+		Commit:  c.String("commit"),
+		Branch:  c.String("branch"),
+		Author:  c.String("author"),
+		CIURL:   c.String("ci-url"),
+		Message: c.String("message"),
+	})
+	if source.IsEmpty() {
+		source = nil
+	}
+	return source, digest
+}
@@ -13,6 +13,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/providers"
 	"github.com/k11n/konstellation/cmd/kon/utils"
 	"github.com/k11n/konstellation/pkg/cloud/types"
 	"github.com/k11n/konstellation/pkg/resources"
@@ -111,6 +112,9 @@ func certSync(c *cli.Context) error {
 		return err
 	}
 
+	if ac.Manager.CertificateProvider() == nil {
+		return providers.ErrUnsupported
+	}
 	certs, err := ac.Manager.CertificateProvider().ListCertificates(context.TODO())
 	if err != nil {
 		return err
@@ -169,6 +173,9 @@ func certImport(c *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if ac.Manager.CertificateProvider() == nil {
+		return providers.ErrUnsupported
+	}
 	domain := c.String("domain")
 	certPath := c.String("certificate")
 	pkeyPath := c.String("private-key")
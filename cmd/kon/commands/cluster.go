@@ -30,6 +30,7 @@ import (
 	"github.com/k11n/konstellation/cmd/kon/config"
 	"github.com/k11n/konstellation/cmd/kon/kube"
 	"github.com/k11n/konstellation/cmd/kon/providers"
+	"github.com/k11n/konstellation/cmd/kon/providers/kubeconfig"
 	"github.com/k11n/konstellation/cmd/kon/utils"
 	"github.com/k11n/konstellation/pkg/cloud/types"
 	"github.com/k11n/konstellation/pkg/resources"
@@ -358,13 +359,17 @@ func clusterCreate(c *cli.Context) error {
 			return err
 		}
 
+		// nodes of some clusters are managed outside of Konstellation
 		nodepool, err = generator.CreateNodepoolConfig(cc)
-		if err != nil {
-			return err
-		}
-		err = utils.SaveKubeObject(kube.GetKubeEncoder(), nodepool, nodepoolConfigFile)
-		if err != nil {
+		if err == providers.ErrUnsupported {
+			nodepool = nil
+		} else if err != nil {
 			return err
+		} else {
+			err = utils.SaveKubeObject(kube.GetKubeEncoder(), nodepool, nodepoolConfigFile)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
@@ -374,9 +379,11 @@ func clusterCreate(c *cli.Context) error {
 		return err
 	}
 
-	err = cm.CreateNodepool(cc, nodepool)
-	if err != nil {
-		return err
+	if nodepool != nil {
+		err = cm.CreateNodepool(cc, nodepool)
+		if err != nil {
+			return err
+		}
 	}
 
 	// load CRD types into cluster and set it up
@@ -401,16 +408,20 @@ func clusterCreate(c *cli.Context) error {
 	if _, err = resources.UpdateResource(kclient, cc, nil, nil); err != nil {
 		return err
 	}
-	if _, err = resources.UpdateResource(kclient, nodepool, nil, nil); err != nil {
-		return err
+	if nodepool != nil {
+		if _, err = resources.UpdateResource(kclient, nodepool, nil, nil); err != nil {
+			return err
+		}
 	}
 
 	// update status as well
 	if err = kclient.Status().Update(context.Background(), cc); err != nil {
 		return err
 	}
-	if err = kclient.Status().Update(context.Background(), nodepool); err != nil {
-		return err
+	if nodepool != nil {
+		if err = kclient.Status().Update(context.Background(), nodepool); err != nil {
+			return err
+		}
 	}
 
 	// activate
@@ -605,7 +616,11 @@ func clusterSelect(clusterName string) error {
 
 	// see if we have a nodepool
 	pools, err := resources.GetNodepools(kclient)
-	if len(pools) == 0 {
+	if err != nil {
+		return err
+	}
+	// nodes of adopted clusters are managed outside of Konstellation
+	if len(pools) == 0 && cm.Cloud() != kubeconfig.CloudID {
 		fmt.Println()
 		fmt.Println("Your cluster requires a nodepool to function, let's create that now")
 		err := ac.configureNodepool()
@@ -613,9 +628,6 @@ func clusterSelect(clusterName string) error {
 			return err
 		}
 	}
-	if err != nil {
-		return err
-	}
 
 	// see if targets are set
 	if len(cc.Spec.Targets) == 0 {
@@ -783,6 +795,9 @@ func printClusterSection(section providers.ClusterManager, clusters []*clusterIn
 				c.Status = types.StatusUnconfigured
 			}
 			targets = ci.Config.Spec.Targets
+			if c.Version == "" {
+				c.Version = ci.Config.Spec.KubeVersion
+			}
 			konVersion = ci.Config.Spec.Version
 			if konVersion != version.Version {
 				konVersion = fmt.Sprintf("%s (current %s)", konVersion, version.Version)
@@ -932,6 +947,12 @@ func generateKubeConfig() error {
 				CAData:      cluster.CertificateAuthorityData,
 				EndpointUrl: cluster.Endpoint,
 			}
+			if source, ok := cm.(providers.KubeConfigSource); ok {
+				cc.ClusterInfo, cc.AuthInfo, err = source.KubeConfigForCluster(cluster.Name)
+				if err != nil {
+					return err
+				}
+			}
 			clusterConfs = append(clusterConfs, cc)
 
 			if selectedIdx == -1 || (selectedCluster == cluster.Name && selectedCloud == cm.Cloud()) {
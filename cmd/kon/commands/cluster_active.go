@@ -6,6 +6,7 @@ import (
 	"log"
 
 	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
@@ -180,6 +181,11 @@ func (c *activeCluster) kubernetesClient() client.Client {
 	return c.kclient
 }
 
+// newKubernetesCache returns a cache for watching resources through informers
+func (c *activeCluster) newKubernetesCache() (cache.Cache, error) {
+	return kube.KubernetesCacheWithContext(resources.ContextNameForCluster(c.Manager.Cloud(), c.Cluster))
+}
+
 func (c *activeCluster) initClient() error {
 	kclient, err := kube.KubernetesClientWithContext(resources.ContextNameForCluster(c.Manager.Cloud(), c.Cluster))
 	if err != nil {
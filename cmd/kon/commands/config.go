@@ -1,21 +1,28 @@
 package commands
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
+	"strings"
 
 	"github.com/olekukonko/tablewriter"
 	"github.com/pkg/errors"
 	"github.com/thoas/go-funk"
 	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
 	"github.com/k11n/konstellation/cmd/kon/utils"
 	"github.com/k11n/konstellation/pkg/resources"
 	utilscli "github.com/k11n/konstellation/pkg/utils/cli"
+	"github.com/k11n/konstellation/pkg/utils/files"
 )
 
 // app configs
@@ -36,6 +43,16 @@ var (
 	}
 )
 
+const (
+	configFormatYAML   = "yaml"
+	configFormatJSON   = "json"
+	configFormatDotenv = "dotenv"
+)
+
+var (
+	configFormats = []string{configFormatYAML, configFormatJSON, configFormatDotenv}
+)
+
 var ConfigCommands = []*cli.Command{
 	{
 		Name:  "config",
@@ -69,6 +86,55 @@ var ConfigCommands = []*cli.Command{
 						Name:  "target",
 						Usage: "edit config only for a specific target (target values will override the base config)",
 					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "show apps that would be affected without saving the config",
+					},
+				},
+			},
+			{
+				Name:   "export",
+				Usage:  "Export a config as yaml, json, or dotenv. With --target, exports the config merged with target overrides",
+				Action: configExport,
+				Flags: []cli.Flag{
+					nameFlag,
+					appFlag,
+					&cli.StringFlag{
+						Name:  "target",
+						Usage: "merge in overrides for a specific target",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output format: yaml, json, or dotenv",
+						Value: configFormatYAML,
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "file to write to, defaults to stdout",
+					},
+				},
+			},
+			{
+				Name:      "import",
+				Usage:     "Create or replace a config from a yaml, json, or dotenv file",
+				Action:    configImport,
+				ArgsUsage: "<file>",
+				Flags: []cli.Flag{
+					nameFlag,
+					appFlag,
+					&cli.StringFlag{
+						Name:  "target",
+						Usage: "import as overrides for a specific target",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "input format: yaml, json, or dotenv. detected from file extension when not set",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "show apps that would be affected without saving the config",
+					},
 				},
 			},
 			{
@@ -220,10 +286,29 @@ func configEdit(c *cli.Context) error {
 		return fmt.Errorf("config not saved, file is empty")
 	}
 
-	// persist
 	if err = appConfig.SetConfigYAML(data); err != nil {
 		return errors.Wrap(err, "could not update config")
 	}
+
+	// preview releases that will be created by this change
+	impacts, err := getConfigImpact(kclient, appConfig)
+	if err != nil {
+		return errors.Wrap(err, "could not determine affected apps")
+	}
+	printConfigImpact(impacts)
+
+	if c.Bool("dry-run") {
+		fmt.Println("Dry run, config was not saved.")
+		return nil
+	}
+	if len(impacts) > 0 {
+		err = utils.ExplicitConfirmationPrompt(fmt.Sprintf("Save config and redeploy %d app target(s)?", len(impacts)))
+		if err != nil {
+			return err
+		}
+	}
+
+	// persist
 	err = resources.SaveAppConfig(kclient, appConfig)
 	if err != nil {
 		return err
@@ -237,6 +322,152 @@ func configEdit(c *cli.Context) error {
 	return nil
 }
 
+func configExport(c *cli.Context) error {
+	confType, name, err := getAppOrShared(c)
+	if err != nil {
+		return err
+	}
+	format := c.String("format")
+	if !funk.ContainsString(configFormats, format) {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+
+	kclient := ac.kubernetesClient()
+	appConfig, err := resources.GetMergedConfigForType(kclient, confType, name, c.String("target"))
+	if err != nil {
+		return err
+	}
+	if appConfig == nil {
+		return fmt.Errorf("config does not exist")
+	}
+
+	out := os.Stdout
+	if output := c.String("output"); output != "" {
+		if out, err = os.Create(output); err != nil {
+			return err
+		}
+		defer out.Close()
+	}
+
+	switch format {
+	case configFormatJSON:
+		data, err := json.MarshalIndent(appConfig.GetConfig(), "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(out, string(data))
+		return err
+	case configFormatDotenv:
+		envs, err := configToDotenv(appConfig, confType)
+		if err != nil {
+			return err
+		}
+		return files.WriteDotenv(out, envs)
+	default:
+		_, err = out.Write(appConfig.ConfigYaml)
+		return err
+	}
+}
+
+func configImport(c *cli.Context) error {
+	confType, name, err := getAppOrShared(c)
+	if err != nil {
+		return err
+	}
+	if c.NArg() == 0 {
+		cli.ShowSubcommandHelp(c)
+		return fmt.Errorf("required argument <file> was not passed in")
+	}
+	filename := c.Args().Get(0)
+
+	format := c.String("format")
+	if format == "" {
+		format = configFormatForFile(filename)
+	}
+	if !funk.ContainsString(configFormats, format) {
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+
+	var data []byte
+	if filename == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(filename)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "could not read config from %s", filename)
+	}
+
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+
+	target := c.String("target")
+	kclient := ac.kubernetesClient()
+
+	var appConfig *v1alpha1.AppConfig
+	if confType == v1alpha1.ConfigTypeApp {
+		appConfig = v1alpha1.NewAppConfig(name, target)
+	} else {
+		appConfig = v1alpha1.NewSharedConfig(name, target)
+	}
+
+	switch format {
+	case configFormatJSON:
+		// JSON is valid YAML, convert it so the stored config is consistent
+		config := make(map[string]interface{})
+		if err = yaml.Unmarshal(data, &config); err != nil {
+			return errors.Wrap(err, "config contains invalid JSON")
+		}
+		err = appConfig.SetConfig(config)
+	case configFormatDotenv:
+		var envs map[string]string
+		if envs, err = files.ReadDotenv(bytes.NewReader(data)); err != nil {
+			return errors.Wrap(err, "config contains invalid dotenv")
+		}
+		err = appConfig.SetConfig(configFromDotenv(envs))
+	default:
+		err = appConfig.SetConfigYAML(data)
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not import config")
+	}
+
+	impacts, err := getConfigImpact(kclient, appConfig)
+	if err != nil {
+		return errors.Wrap(err, "could not determine affected apps")
+	}
+	printConfigImpact(impacts)
+
+	if c.Bool("dry-run") {
+		fmt.Println("Dry run, config was not saved.")
+		return nil
+	}
+	if len(impacts) > 0 {
+		err = utils.ExplicitConfirmationPrompt(fmt.Sprintf("Save config and redeploy %d app target(s)?", len(impacts)))
+		if err != nil {
+			return err
+		}
+	}
+
+	if err = resources.SaveAppConfig(kclient, appConfig); err != nil {
+		return err
+	}
+
+	targetStr := ""
+	if target != "" {
+		targetStr = fmt.Sprintf(", target %s", target)
+	}
+	fmt.Printf("Imported %s config for %s%s.\n", confType, name, targetStr)
+	return nil
+}
+
 func configDelete(c *cli.Context) error {
 	confType, name, err := getAppOrShared(c)
 	if err != nil {
@@ -267,6 +498,18 @@ func configDelete(c *cli.Context) error {
 	return nil
 }
 
+func configFormatForFile(filename string) string {
+	base := filepath.Base(filename)
+	switch {
+	case strings.HasSuffix(base, ".json"):
+		return configFormatJSON
+	case base == ".env" || strings.HasSuffix(base, ".env"):
+		return configFormatDotenv
+	default:
+		return configFormatYAML
+	}
+}
+
 func getAppOrShared(c *cli.Context) (t v1alpha1.ConfigType, n string, err error) {
 	app := c.String("app")
 	name := c.String("name")
@@ -292,3 +535,40 @@ func getAppOrShared(c *cli.Context) (t v1alpha1.ConfigType, n string, err error)
 	err = utils.ValidateKubeName(n)
 	return
 }
+
+// configToDotenv returns the variables that the config is passed to apps as. Keys that wouldn't be imported
+// back unchanged are rejected: nested and boolean values are left out of the environment, and keys are
+// renamed to valid env vars
+func configToDotenv(appConfig *v1alpha1.AppConfig, confType v1alpha1.ConfigType) (map[string]string, error) {
+	envs := appConfig.ToEnvMap()
+	var incompatible []string
+	for key := range appConfig.GetConfig() {
+		if _, ok := envs[key]; !ok || key == v1alpha1.ConfigEnvVar {
+			incompatible = append(incompatible, key)
+		}
+	}
+	if len(incompatible) > 0 {
+		sort.Strings(incompatible)
+		return nil, fmt.Errorf("config can't be exported as dotenv without changing keys: %s. "+
+			"Only uppercase keys with string or number values are supported, use yaml or json instead",
+			strings.Join(incompatible, ", "))
+	}
+	if confType == v1alpha1.ConfigTypeShared {
+		// shared configs are not passed to apps as APP_CONFIG
+		delete(envs, v1alpha1.ConfigEnvVar)
+	}
+	return envs, nil
+}
+
+// configFromDotenv reads a config from variables written by configToDotenv. Values are imported as strings
+func configFromDotenv(envs map[string]string) map[string]interface{} {
+	config := make(map[string]interface{})
+	for key, val := range envs {
+		if key == v1alpha1.ConfigEnvVar {
+			// generated from the config itself
+			continue
+		}
+		config[key] = val
+	}
+	return config
+}
@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/thoas/go-funk"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// configImpact describes how a config change alters the ConfigMap of an app target.
+// any difference in the ConfigMap leads to a new release for that target
+type configImpact struct {
+	App     string
+	Target  string
+	Changes []envChange
+}
+
+type envChange struct {
+	Key      string
+	OldValue string
+	NewValue string
+}
+
+func (c envChange) Kind() string {
+	if c.OldValue == "" {
+		return "added"
+	} else if c.NewValue == "" {
+		return "removed"
+	}
+	return "changed"
+}
+
+// computes the impact of saving the edited config, returning an entry for every app target that would be redeployed
+func getConfigImpact(kclient client.Client, edited *v1alpha1.AppConfig) (impacts []*configImpact, err error) {
+	var appTargets []v1alpha1.AppTarget
+	err = resources.ForEach(kclient, &v1alpha1.AppTargetList{}, func(item interface{}) error {
+		at := item.(v1alpha1.AppTarget)
+		if edited.GetTarget() != "" && edited.GetTarget() != at.Spec.Target {
+			return nil
+		}
+		if edited.Type == v1alpha1.ConfigTypeApp {
+			if at.Spec.App != edited.GetAppName() {
+				return nil
+			}
+		} else if !funk.ContainsString(at.Spec.Configs, edited.GetSharedName()) {
+			return nil
+		}
+		appTargets = append(appTargets, at)
+		return nil
+	})
+	if err != nil {
+		return
+	}
+
+	sort.SliceStable(appTargets, func(i, j int) bool {
+		return appTargets[i].Name < appTargets[j].Name
+	})
+
+	for _, at := range appTargets {
+		current, err := configMapDataForAppTarget(kclient, &at, nil)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := configMapDataForAppTarget(kclient, &at, edited)
+		if err != nil {
+			return nil, err
+		}
+
+		changes := diffEnvData(current, updated)
+		if len(changes) == 0 {
+			continue
+		}
+		impacts = append(impacts, &configImpact{
+			App:     at.Spec.App,
+			Target:  at.Spec.Target,
+			Changes: changes,
+		})
+	}
+	return
+}
+
+// generates ConfigMap data in the same way as the DeploymentReconciler, optionally replacing a stored config
+// with the one being edited
+func configMapDataForAppTarget(kclient client.Client, at *v1alpha1.AppTarget, override *v1alpha1.AppConfig) (map[string]string, error) {
+	ac, err := mergedConfigWithOverride(kclient, v1alpha1.ConfigTypeApp, at.Spec.App, at.Spec.Target, override)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedConfigs := make([]*v1alpha1.AppConfig, 0, len(at.Spec.Configs))
+	for _, config := range at.Spec.Configs {
+		sc, err := mergedConfigWithOverride(kclient, v1alpha1.ConfigTypeShared, config, at.Spec.Target, override)
+		if err != nil {
+			return nil, err
+		}
+		if sc == nil {
+			// reconciler skips missing shared configs
+			continue
+		}
+		sharedConfigs = append(sharedConfigs, sc)
+	}
+
+	if ac == nil && len(sharedConfigs) == 0 {
+		return map[string]string{}, nil
+	}
+	return resources.CreateConfigMap(at.Spec.App, ac, sharedConfigs).Data, nil
+}
+
+func mergedConfigWithOverride(kclient client.Client, confType v1alpha1.ConfigType, name, target string, override *v1alpha1.AppConfig) (*v1alpha1.AppConfig, error) {
+	if override != nil {
+		kclient = &configOverrideClient{Client: kclient, override: override}
+	}
+	return resources.GetMergedConfigForType(kclient, confType, name, target)
+}
+
+// configOverrideClient lists AppConfigs as if the override had already been saved
+type configOverrideClient struct {
+	client.Client
+	override *v1alpha1.AppConfig
+}
+
+func (c *configOverrideClient) List(ctx context.Context, list runtime.Object, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	configList, ok := list.(*v1alpha1.AppConfigList)
+	if !ok {
+		return nil
+	}
+
+	items := make([]v1alpha1.AppConfig, 0, len(configList.Items)+1)
+	for _, item := range configList.Items {
+		if item.Name != c.override.Name {
+			items = append(items, item)
+		}
+	}
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.LabelSelector == nil || listOpts.LabelSelector.Matches(labels.Set(c.override.Labels)) {
+		items = append(items, *c.override.DeepCopy())
+	}
+	configList.Items = items
+	return nil
+}
+
+func diffEnvData(current, updated map[string]string) []envChange {
+	keys := make(map[string]bool)
+	for key := range current {
+		keys[key] = true
+	}
+	for key := range updated {
+		keys[key] = true
+	}
+
+	var changes []envChange
+	for key := range keys {
+		if current[key] == updated[key] {
+			continue
+		}
+		changes = append(changes, envChange{
+			Key:      key,
+			OldValue: current[key],
+			NewValue: updated[key],
+		})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+func printConfigImpact(impacts []*configImpact) {
+	if len(impacts) == 0 {
+		fmt.Println("No apps are affected by this change.")
+		return
+	}
+
+	fmt.Printf("This change will create new releases for %d app target(s):\n\n", len(impacts))
+	for _, impact := range impacts {
+		fmt.Printf("App: %s, target: %s\n", impact.App, impact.Target)
+		table := tablewriter.NewWriter(os.Stdout)
+		table.SetHeader([]string{"Env", "Change", "Old Value", "New Value"})
+		table.SetAutoWrapText(false)
+		for _, change := range impact.Changes {
+			table.Append([]string{
+				change.Key,
+				change.Kind(),
+				strings.TrimSpace(change.OldValue),
+				strings.TrimSpace(change.NewValue),
+			})
+		}
+		utils.FormatStandardTable(table)
+		table.SetRowLine(true)
+		table.Render()
+		fmt.Println()
+	}
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestDiffEnvData(t *testing.T) {
+	current := map[string]string{
+		"UNCHANGED": "1",
+		"MODIFIED":  "old",
+		"REMOVED":   "gone",
+	}
+	updated := map[string]string{
+		"UNCHANGED": "1",
+		"MODIFIED":  "new",
+		"ADDED":     "here",
+	}
+
+	changes := diffEnvData(current, updated)
+	assert.Len(t, changes, 3)
+	assert.Equal(t, "ADDED", changes[0].Key)
+	assert.Equal(t, "added", changes[0].Kind())
+	assert.Equal(t, "MODIFIED", changes[1].Key)
+	assert.Equal(t, "changed", changes[1].Kind())
+	assert.Equal(t, "REMOVED", changes[2].Key)
+	assert.Equal(t, "removed", changes[2].Kind())
+
+	assert.Empty(t, diffEnvData(current, current))
+}
+
+func TestMergedConfigWithOverride(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	base := v1alpha1.NewAppConfig("myapp", "")
+	assert.NoError(t, base.SetConfigYAML([]byte("port: 80\nlevel: info\n")))
+	target := v1alpha1.NewAppConfig("myapp", "production")
+	assert.NoError(t, target.SetConfigYAML([]byte("level: warn\n")))
+	kclient := fake.NewFakeClientWithScheme(scheme, base, target)
+
+	// editing the target config
+	edited := target.DeepCopy()
+	assert.NoError(t, edited.SetConfigYAML([]byte("level: error\n")))
+	ac, err := mergedConfigWithOverride(kclient, v1alpha1.ConfigTypeApp, "myapp", "production", edited)
+	assert.NoError(t, err)
+	assert.Equal(t, "80", ac.ToEnvMap()["PORT"])
+	assert.Equal(t, "error", ac.ToEnvMap()["LEVEL"])
+
+	// a new target config that hasn't been saved yet
+	edited = v1alpha1.NewAppConfig("myapp", "staging")
+	assert.NoError(t, edited.SetConfigYAML([]byte("level: debug\n")))
+	ac, err = mergedConfigWithOverride(kclient, v1alpha1.ConfigTypeApp, "myapp", "staging", edited)
+	assert.NoError(t, err)
+	assert.Equal(t, "debug", ac.ToEnvMap()["LEVEL"])
+
+	// other apps are left alone
+	ac, err = mergedConfigWithOverride(kclient, v1alpha1.ConfigTypeApp, "myapp", "production", v1alpha1.NewAppConfig("other", "production"))
+	assert.NoError(t, err)
+	assert.Equal(t, "warn", ac.ToEnvMap()["LEVEL"])
+}
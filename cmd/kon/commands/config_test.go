@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/utils/files"
+)
+
+func TestConfigToDotenv(t *testing.T) {
+	ac := v1alpha1.NewAppConfig("myapp", "")
+	assert.NoError(t, ac.SetConfigYAML([]byte(`PORT: 80
+hosts:
+  - a.example.com
+db:
+  user: admin
+DEBUG: true
+log-level: info
+`)))
+	_, err := configToDotenv(ac, v1alpha1.ConfigTypeApp)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "DEBUG, db, hosts, log-level")
+}
+
+func TestConfigDotenvRoundTrip(t *testing.T) {
+	ac := v1alpha1.NewSharedConfig("common", "")
+	assert.NoError(t, ac.SetConfigYAML([]byte("PORT: 80\nLOG_LEVEL: info\nGREETING: \"hello # world\"\n")))
+
+	envs, err := configToDotenv(ac, v1alpha1.ConfigTypeShared)
+	assert.NoError(t, err)
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, files.WriteDotenv(buf, envs))
+
+	read, err := files.ReadDotenv(buf)
+	assert.NoError(t, err)
+	imported := v1alpha1.NewSharedConfig("common", "")
+	assert.NoError(t, imported.SetConfig(configFromDotenv(read)))
+	// same keys, numbers are imported as strings
+	assert.Equal(t, map[string]interface{}{
+		"PORT":      "80",
+		"LOG_LEVEL": "info",
+		"GREETING":  "hello # world",
+	}, imported.GetConfig())
+
+	// lowercase keys would come back renamed
+	assert.NoError(t, ac.SetConfigYAML([]byte("port: 80\n")))
+	_, err = configToDotenv(ac, v1alpha1.ConfigTypeShared)
+	assert.Error(t, err)
+}
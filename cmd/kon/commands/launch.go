@@ -8,6 +8,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/urfave/cli/v2"
 
+	"github.com/k11n/konstellation/pkg/components/jaeger"
 	"github.com/k11n/konstellation/pkg/components/kubedash"
 	"github.com/k11n/konstellation/pkg/resources"
 	koncli "github.com/k11n/konstellation/pkg/utils/cli"
@@ -67,6 +68,11 @@ var LaunchCommands = []*cli.Command{
 					},
 				},
 			},
+			{
+				Name:   "tracing",
+				Usage:  "Launch Jaeger to view request traces",
+				Action: launchTracing,
+			},
 		},
 	},
 }
@@ -132,6 +138,19 @@ func launchGrafana(c *cli.Context) error {
 	return startProxyAndWait(proxy, "Grafana")
 }
 
+func launchTracing(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+
+	proxy, err := koncli.NewKubeProxyForService(ac.kubernetesClient(), resources.KonSystemNamespace, jaeger.QueryService, jaeger.QueryPort)
+	if err != nil {
+		return err
+	}
+	return startProxyAndWait(proxy, "Jaeger")
+}
+
 func launchAlertManager(c *cli.Context) error {
 	ac, err := getActiveCluster()
 	if err != nil {
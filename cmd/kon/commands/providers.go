@@ -6,13 +6,16 @@ import (
 	"github.com/k11n/konstellation/cmd/kon/config"
 	"github.com/k11n/konstellation/cmd/kon/providers"
 	"github.com/k11n/konstellation/cmd/kon/providers/aws"
+	"github.com/k11n/konstellation/cmd/kon/providers/kubeconfig"
 	"github.com/k11n/konstellation/cmd/kon/utils"
 )
 
 var (
 	AWSCloud        = aws.NewAWSProvider()
+	KubeconfigCloud = kubeconfig.NewKubeconfigProvider()
 	AvailableClouds = []providers.CloudProvider{
 		AWSCloud,
+		KubeconfigCloud,
 	}
 )
 
@@ -85,12 +88,18 @@ func GetClusterManagers() []providers.ClusterManager {
 	for _, r := range awsConf.Regions {
 		items = append(items, aws.NewAWSManager(r))
 	}
+	if conf.Clouds.Kubeconfig.IsSetup() {
+		items = append(items, kubeconfig.NewKubeconfigManager(conf.Clouds.Kubeconfig.Path))
+	}
 	return items
 }
 
 func NewClusterManager(cloud string, region string) providers.ClusterManager {
-	if cloud == "aws" {
+	switch cloud {
+	case "aws":
 		return aws.NewAWSManager(region)
+	case kubeconfig.CloudID:
+		return kubeconfig.NewKubeconfigManager(region)
 	}
 	return nil
 }
@@ -103,5 +112,8 @@ func PromptClusterGenerator(cloud providers.CloudProvider, region string) (provi
 		creds := config.GetConfig().Clouds.AWS.GetDefaultCredentials()
 		return aws.NewPromptConfigGenerator(region, creds)
 	}
+	if cloud.ID() == kubeconfig.CloudID {
+		return kubeconfig.NewPromptConfigGenerator(region), nil
+	}
 	return nil, fmt.Errorf("unsupported cloud %s", cloud.ID())
 }
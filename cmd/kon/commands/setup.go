@@ -5,6 +5,7 @@ import (
 	"os/exec"
 
 	"github.com/manifoldco/promptui"
+	"github.com/thoas/go-funk"
 	"github.com/urfave/cli/v2"
 
 	"github.com/k11n/konstellation/cmd/kon/config"
@@ -23,22 +24,31 @@ var SetupCommands = []*cli.Command{
 
 var neededExes = []string{
 	"kubectl",
-	"terraform",
+}
+
+// clouds that provision clusters with terraform
+var terraformClouds = []string{
+	"aws",
 }
 
 func setupStart(c *cli.Context) error {
-	if err := checkDependencies(); err != nil {
+	cloud, err := ChooseCloudPrompt("Choose a cloud provider to configure")
+	if err != nil {
 		return err
 	}
 
-	if err := installBundledCli(); err != nil {
+	exes := neededExes
+	if funk.ContainsString(terraformClouds, cloud.ID()) {
+		exes = append(exes[:len(exes):len(exes)], "terraform")
+	}
+	if err := checkDependencies(exes); err != nil {
 		return err
 	}
 
-	cloud, err := ChooseCloudPrompt("Choose a cloud provider to configure")
-	if err != nil {
+	if err := installBundledCli(); err != nil {
 		return err
 	}
+
 	return cloud.Setup()
 }
 
@@ -50,8 +60,8 @@ func ensureSetup(c *cli.Context) error {
 	return nil
 }
 
-func checkDependencies() error {
-	for _, exe := range neededExes {
+func checkDependencies(exes []string) error {
+	for _, exe := range exes {
 		_, err := exec.LookPath(exe)
 		if err != nil {
 			return fmt.Errorf("Konstellation requires %s, but could find it. Please ensure it's installed and in your PATH", exe)
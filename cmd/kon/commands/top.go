@@ -0,0 +1,366 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/hako/durafmt"
+	"github.com/olekukonko/tablewriter"
+	"github.com/urfave/cli/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metrics "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+var TopCommands = []*cli.Command{
+	{
+		Name:     "top",
+		Usage:    "Dashboard of apps and nodepools on the current cluster, with their resource usage",
+		Category: "Cluster",
+		Before: func(c *cli.Context) error {
+			return ensureClusterSelected()
+		},
+		Action: top,
+		Flags: []cli.Flag{
+			targetFlag,
+			&cli.BoolFlag{
+				Name:    "watch",
+				Aliases: []string{"w"},
+				Usage:   "refresh until interrupted",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "how often to refresh with --watch",
+				Value: 10 * time.Second,
+			},
+		},
+	},
+}
+
+// clusterSnapshot holds the state of apps and nodepools at a point in time
+type clusterSnapshot struct {
+	appTargets []v1alpha1.AppTarget
+	releases   []v1alpha1.AppRelease
+	nodepools  []*v1alpha1.Nodepool
+	nodes      []corev1.Node
+	// app pods, PodMetrics don't carry the pod's labels
+	pods []corev1.Pod
+	// metrics are nil when metrics-server isn't available
+	podMetrics  []metrics.PodMetrics
+	nodeMetrics []metrics.NodeMetrics
+	metricsErr  error
+}
+
+// resource usage summed across pods or nodes
+type resourceUsage struct {
+	cpu    resource.Quantity
+	memory resource.Quantity
+}
+
+func top(c *cli.Context) error {
+	ac, err := getActiveCluster()
+	if err != nil {
+		return err
+	}
+	kclient := ac.kubernetesClient()
+	target := c.String("target")
+
+	printTop := func() error {
+		snapshot, err := loadClusterSnapshot(kclient, target)
+		if err != nil {
+			return err
+		}
+		if c.Bool("watch") {
+			utils.ClearScreen()
+		}
+		fmt.Printf("Cluster: %s, %s\n\n", ac.Cluster, time.Now().Format(cliDateFormat))
+		printClusterSnapshot(os.Stdout, snapshot, time.Now())
+		return nil
+	}
+
+	if err = printTop(); err != nil || !c.Bool("watch") {
+		return err
+	}
+
+	ticker := time.NewTicker(c.Duration("interval"))
+	defer ticker.Stop()
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+	for {
+		select {
+		case <-sigChan:
+			return nil
+		case <-ticker.C:
+			if err = printTop(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func loadClusterSnapshot(kclient client.Client, target string) (*clusterSnapshot, error) {
+	s := &clusterSnapshot{}
+	var opts []client.ListOption
+	if target != "" {
+		opts = append(opts, client.MatchingLabels{resources.TargetLabel: target})
+	}
+
+	err := resources.ForEach(kclient, &v1alpha1.AppTargetList{}, func(item interface{}) error {
+		s.appTargets = append(s.appTargets, item.(v1alpha1.AppTarget))
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	err = resources.ForEach(kclient, &v1alpha1.AppReleaseList{}, func(item interface{}) error {
+		s.releases = append(s.releases, item.(v1alpha1.AppRelease))
+		return nil
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if s.nodepools, err = resources.GetNodepools(kclient); err != nil {
+		return nil, err
+	}
+	nodeList := corev1.NodeList{}
+	if err = kclient.List(context.TODO(), &nodeList); err != nil {
+		return nil, err
+	}
+	s.nodes = nodeList.Items
+	podOpts := []client.ListOption{client.HasLabels{resources.AppLabel}}
+	if target != "" {
+		podOpts = append(podOpts, client.InNamespace(target))
+	}
+	podList := corev1.PodList{}
+	if err = kclient.List(context.TODO(), &podList, podOpts...); err != nil {
+		return nil, err
+	}
+	s.pods = podList.Items
+
+	// usage is optional, the dashboard is still useful without it
+	podMetricsList := metrics.PodMetricsList{}
+	if s.metricsErr = kclient.List(context.TODO(), &podMetricsList); s.metricsErr != nil {
+		return s, nil
+	}
+	s.podMetrics = podMetricsList.Items
+	nodeMetricsList := metrics.NodeMetricsList{}
+	if s.metricsErr = kclient.List(context.TODO(), &nodeMetricsList); s.metricsErr != nil {
+		return s, nil
+	}
+	s.nodeMetrics = nodeMetricsList.Items
+	return s, nil
+}
+
+func printClusterSnapshot(out io.Writer, s *clusterSnapshot, now time.Time) {
+	table := tablewriter.NewWriter(out)
+	table.SetHeader([]string{"App", "Target", "Phase", "Release", "Traffic", "Pods", "Scale", "Last Scaled", "CPU", "Memory"})
+	table.AppendBulk(s.appRows(now))
+	utils.FormatStandardTable(table)
+	table.Render()
+	fmt.Fprintln(out)
+
+	table = tablewriter.NewWriter(out)
+	table.SetHeader([]string{"Nodepool", "Machine Type", "Ready", "Min", "Max", "Autoscale", "CPU", "Memory"})
+	table.AppendBulk(s.nodepoolRows())
+	utils.FormatStandardTable(table)
+	table.Render()
+
+	if s.metricsErr != nil {
+		fmt.Fprintf(out, "\nResource usage isn't available, metrics-server may not be running: %v\n", s.metricsErr)
+	}
+}
+
+func (s *clusterSnapshot) appRows(now time.Time) [][]string {
+	targets := make([]v1alpha1.AppTarget, len(s.appTargets))
+	copy(targets, s.appTargets)
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Spec.App != targets[j].Spec.App {
+			return targets[i].Spec.App < targets[j].Spec.App
+		}
+		return targets[i].Spec.Target < targets[j].Spec.Target
+	})
+
+	usage := s.appUsage()
+	var rows [][]string
+	for _, at := range targets {
+		release := at.Status.ActiveRelease
+		if at.Status.TargetRelease != "" && at.Status.TargetRelease != release {
+			release = fmt.Sprintf("%s -> %s", release, at.Status.TargetRelease)
+		}
+
+		var numAvailable, numDesired int32
+		var releases []*v1alpha1.AppRelease
+		for i := range s.releases {
+			ar := &s.releases[i]
+			if ar.Spec.App != at.Spec.App || ar.Spec.Target != at.Spec.Target {
+				continue
+			}
+			numAvailable += ar.Status.NumAvailable
+			numDesired += ar.Spec.NumDesired
+			releases = append(releases, ar)
+		}
+
+		lastScaled := "-"
+		if at.Status.LastScaledAt != nil && !at.Status.LastScaledAt.IsZero() {
+			lastScaled = durafmt.ParseShort(now.Sub(at.Status.LastScaledAt.Time)).String() + " ago"
+		}
+
+		cpu, memory := "-", "-"
+		if s.metricsErr == nil {
+			u := usage[at.Spec.Target+"/"+at.Spec.App]
+			if u == nil {
+				u = &resourceUsage{}
+			}
+			cpu = formatCPU(u.cpu)
+			memory = formatMemory(u.memory)
+		}
+
+		rows = append(rows, []string{
+			at.Spec.App,
+			at.Spec.Target,
+			string(at.Status.Phase),
+			release,
+			trafficSplit(releases),
+			fmt.Sprintf("%d/%d", numAvailable, numDesired),
+			fmt.Sprintf("%d-%d", at.Spec.Scale.Min, at.Spec.Scale.Max),
+			lastScaled,
+			cpu,
+			memory,
+		})
+	}
+	return rows
+}
+
+// appUsage sums usage of each app's container, keyed by target/app
+func (s *clusterSnapshot) appUsage() map[string]*resourceUsage {
+	// metrics are joined to pods by name, to find the app that they run
+	podApps := make(map[string]string)
+	for _, pod := range s.pods {
+		podApps[pod.Namespace+"/"+pod.Name] = pod.Labels[resources.AppLabel]
+	}
+
+	usage := make(map[string]*resourceUsage)
+	for _, pm := range s.podMetrics {
+		app := podApps[pm.Namespace+"/"+pm.Name]
+		if app == "" {
+			continue
+		}
+		key := pm.Namespace + "/" + app
+		u := usage[key]
+		if u == nil {
+			u = &resourceUsage{}
+			usage[key] = u
+		}
+		for _, container := range pm.Containers {
+			// leave out sidecars, same as `kon app metrics`
+			if container.Name != app {
+				continue
+			}
+			u.cpu.Add(*container.Usage.Cpu())
+			u.memory.Add(*container.Usage.Memory())
+		}
+	}
+	return usage
+}
+
+func (s *clusterSnapshot) nodepoolRows() [][]string {
+	nodeMetrics := make(map[string]*metrics.NodeMetrics)
+	for i := range s.nodeMetrics {
+		nodeMetrics[s.nodeMetrics[i].Name] = &s.nodeMetrics[i]
+	}
+	nodes := make(map[string]*corev1.Node)
+	for i := range s.nodes {
+		nodes[s.nodes[i].Name] = &s.nodes[i]
+	}
+
+	var rows [][]string
+	for _, np := range s.nodepools {
+		var used, allocatable resourceUsage
+		for _, name := range np.Status.Nodes {
+			if node := nodes[name]; node != nil {
+				allocatable.cpu.Add(*node.Status.Allocatable.Cpu())
+				allocatable.memory.Add(*node.Status.Allocatable.Memory())
+			}
+			if nm := nodeMetrics[name]; nm != nil {
+				used.cpu.Add(*nm.Usage.Cpu())
+				used.memory.Add(*nm.Usage.Memory())
+			}
+		}
+
+		cpu, memory := "-", "-"
+		if s.metricsErr == nil {
+			cpu = formatUtilization(used.cpu, allocatable.cpu, formatCPU)
+			memory = formatUtilization(used.memory, allocatable.memory, formatMemory)
+		}
+		autoscale := "no"
+		if np.Spec.Autoscale {
+			autoscale = "yes"
+		}
+		rows = append(rows, []string{
+			np.Name,
+			np.Spec.MachineType,
+			fmt.Sprintf("%d", np.Status.NumReady),
+			fmt.Sprintf("%d", np.Spec.MinSize),
+			fmt.Sprintf("%d", np.Spec.MaxSize),
+			autoscale,
+			cpu,
+			memory,
+		})
+	}
+	return rows
+}
+
+// trafficSplit describes how traffic is split between releases, i.e. "target 20%, active 80%"
+func trafficSplit(releases []*v1alpha1.AppRelease) string {
+	var serving []*v1alpha1.AppRelease
+	for _, ar := range releases {
+		if ar.Spec.TrafficPercentage > 0 {
+			serving = append(serving, ar)
+		}
+	}
+	switch len(serving) {
+	case 0:
+		return "-"
+	case 1:
+		return fmt.Sprintf("%d%%", serving[0].Spec.TrafficPercentage)
+	}
+
+	resources.SortAppReleasesByLatest(serving)
+	var parts []string
+	for _, ar := range serving {
+		role := string(ar.Spec.Role)
+		if role == "" {
+			role = ar.Name
+		}
+		parts = append(parts, fmt.Sprintf("%s %d%%", role, ar.Spec.TrafficPercentage))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func formatCPU(q resource.Quantity) string {
+	return fmt.Sprintf("%dm", q.MilliValue())
+}
+
+func formatMemory(q resource.Quantity) string {
+	return fmt.Sprintf("%dMi", q.Value()/(1024*1024))
+}
+
+func formatUtilization(used, total resource.Quantity, format func(resource.Quantity) string) string {
+	if total.IsZero() {
+		return format(used)
+	}
+	return fmt.Sprintf("%s/%s (%d%%)", format(used), format(total), used.MilliValue()*100/total.MilliValue())
+}
@@ -0,0 +1,184 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metrics "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func newTopTestRelease(name string, role v1alpha1.ReleaseRole, traffic, desired, available int32, createdAt time.Time) v1alpha1.AppRelease {
+	return v1alpha1.AppRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: createdAt}},
+		Spec: v1alpha1.AppReleaseSpec{
+			App:               "myapp",
+			Target:            "production",
+			Role:              role,
+			NumDesired:        desired,
+			TrafficPercentage: traffic,
+		},
+		Status: v1alpha1.AppReleaseStatus{NumAvailable: available},
+	}
+}
+
+func TestClusterSnapshotAppRows(t *testing.T) {
+	now := time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)
+	lastScaled := metav1.NewTime(now.Add(-5 * time.Minute))
+	s := &clusterSnapshot{
+		appTargets: []v1alpha1.AppTarget{
+			{
+				Spec: v1alpha1.AppTargetSpec{
+					App:    "myapp",
+					Target: "production",
+					Scale:  v1alpha1.ScaleSpec{Min: 2, Max: 10},
+				},
+				Status: v1alpha1.AppTargetStatus{
+					Phase:         v1alpha1.AppTargetPhaseDeploying,
+					ActiveRelease: "myapp-1",
+					TargetRelease: "myapp-2",
+					LastScaledAt:  &lastScaled,
+				},
+			},
+			{
+				Spec: v1alpha1.AppTargetSpec{
+					App:    "api",
+					Target: "production",
+					Scale:  v1alpha1.ScaleSpec{Min: 1, Max: 1},
+				},
+				Status: v1alpha1.AppTargetStatus{
+					Phase:         v1alpha1.AppTargetPhaseRunning,
+					ActiveRelease: "api-1",
+					TargetRelease: "api-1",
+				},
+			},
+		},
+		releases: []v1alpha1.AppRelease{
+			newTopTestRelease("myapp-1", v1alpha1.ReleaseRoleActive, 80, 3, 3, now.Add(-time.Hour)),
+			newTopTestRelease("myapp-2", v1alpha1.ReleaseRoleTarget, 20, 1, 1, now),
+		},
+		pods: []corev1.Pod{
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "production",
+					Name:      "myapp-1-abc",
+					Labels:    map[string]string{resources.AppLabel: "myapp"},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "production",
+					Name:      "myapp-2-def",
+					Labels:    map[string]string{resources.AppLabel: "myapp"},
+				},
+			},
+		},
+		podMetrics: []metrics.PodMetrics{
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "production", Name: "myapp-1-abc"},
+				Containers: []metrics.ContainerMetrics{
+					{
+						Name: "myapp",
+						Usage: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("250m"),
+							corev1.ResourceMemory: resource.MustParse("128Mi"),
+						},
+					},
+					{
+						Name: "istio-proxy",
+						Usage: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("10m"),
+							corev1.ResourceMemory: resource.MustParse("40Mi"),
+						},
+					},
+				},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "production", Name: "myapp-2-def"},
+				Containers: []metrics.ContainerMetrics{
+					{
+						Name: "myapp",
+						Usage: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("50m"),
+							corev1.ResourceMemory: resource.MustParse("64Mi"),
+						},
+					},
+				},
+			},
+			{
+				// not an app pod, left out
+				ObjectMeta: metav1.ObjectMeta{Namespace: "production", Name: "myapp-migrate"},
+				Containers: []metrics.ContainerMetrics{
+					{
+						Name: "myapp",
+						Usage: corev1.ResourceList{
+							corev1.ResourceCPU:    resource.MustParse("1"),
+							corev1.ResourceMemory: resource.MustParse("1Gi"),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	assert.Equal(t, [][]string{
+		{"api", "production", "running", "api-1", "-", "0/0", "1-1", "-", "0m", "0Mi"},
+		{"myapp", "production", "deploying", "myapp-1 -> myapp-2", "target 20%, active 80%", "4/4", "2-10", "5 minutes ago", "300m", "192Mi"},
+	}, s.appRows(now))
+}
+
+func TestClusterSnapshotNodepoolRows(t *testing.T) {
+	newNode := func(name, cpu, memory string) corev1.Node {
+		return corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Status: corev1.NodeStatus{
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse(cpu),
+					corev1.ResourceMemory: resource.MustParse(memory),
+				},
+			},
+		}
+	}
+	newNodeMetrics := func(name, cpu, memory string) metrics.NodeMetrics {
+		return metrics.NodeMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			},
+		}
+	}
+	s := &clusterSnapshot{
+		nodepools: []*v1alpha1.Nodepool{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "main"},
+				Spec:       v1alpha1.NodepoolSpec{Autoscale: true, MinSize: 1, MaxSize: 5, MachineType: "m5.large"},
+				Status:     v1alpha1.NodepoolStatus{Nodes: []string{"node-1", "node-2"}, NumReady: 2},
+			},
+		},
+		nodes:       []corev1.Node{newNode("node-1", "2", "4Gi"), newNode("node-2", "2", "4Gi")},
+		nodeMetrics: []metrics.NodeMetrics{newNodeMetrics("node-1", "1", "2Gi"), newNodeMetrics("node-2", "500m", "1Gi")},
+	}
+
+	assert.Equal(t, [][]string{
+		{"main", "m5.large", "2", "1", "5", "yes", "1500m/4000m (37%)", "3072Mi/8192Mi (37%)"},
+	}, s.nodepoolRows())
+
+	// without metrics-server
+	s.metricsErr = assert.AnError
+	assert.Equal(t, []string{"main", "m5.large", "2", "1", "5", "yes", "-", "-"}, s.nodepoolRows()[0])
+}
+
+func TestTrafficSplit(t *testing.T) {
+	now := time.Now()
+	active := newTopTestRelease("myapp-1", v1alpha1.ReleaseRoleActive, 100, 3, 3, now)
+	retired := newTopTestRelease("myapp-0", v1alpha1.ReleaseRoleNone, 0, 0, 0, now.Add(-time.Hour))
+	assert.Equal(t, "100%", trafficSplit([]*v1alpha1.AppRelease{&active, &retired}))
+	assert.Equal(t, "-", trafficSplit([]*v1alpha1.AppRelease{&retired}))
+}
@@ -44,6 +44,10 @@ var VPCCommands = []*cli.Command{
 
 func vpcList(c *cli.Context) error {
 	for _, cm := range GetClusterManagers() {
+		// VPCs of existing clusters aren't managed by Konstellation
+		if cm.VPCProvider() == nil {
+			continue
+		}
 		fmt.Printf("\n%s (%s)\n", cm.Cloud(), cm.Region())
 
 		table := tablewriter.NewWriter(os.Stdout)
@@ -81,6 +85,9 @@ func vpcDestroy(c *cli.Context) error {
 	var err error
 	var manager providers.ClusterManager
 	for _, cm := range GetClusterManagers() {
+		if cm.VPCProvider() == nil {
+			continue
+		}
 		vpc, err = cm.VPCProvider().GetVPC(context.Background(), vpcId)
 		if err != nil {
 			continue
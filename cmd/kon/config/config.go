@@ -29,7 +29,8 @@ type ClusterInfo struct {
 
 type ClientConfig struct {
 	Clouds struct {
-		AWS AWSConfig `yaml:"aws,omitempty"`
+		AWS        AWSConfig        `yaml:"aws,omitempty"`
+		Kubeconfig KubeconfigConfig `yaml:"kubeconfig,omitempty"`
 	} `yaml:"clouds,omitempty"`
 	Clusters        map[string]*ClusterInfo
 	SelectedCluster string
@@ -65,7 +66,7 @@ func (c *ClientConfig) IsPersisted() bool {
 
 func (c *ClientConfig) IsSetup() bool {
 	// return if any of the cloud providers are setup
-	if c.Clouds.AWS.IsSetup() {
+	if c.Clouds.AWS.IsSetup() || c.Clouds.Kubeconfig.IsSetup() {
 		return true
 	}
 	return false
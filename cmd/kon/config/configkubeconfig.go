@@ -0,0 +1,43 @@
+package config
+
+// KubeconfigConfig holds existing clusters that have been adopted from a kubeconfig
+type KubeconfigConfig struct {
+	// kubeconfig containing the contexts, set during setup
+	Path     string
+	Clusters []*KubeconfigCluster `yaml:",omitempty"`
+}
+
+type KubeconfigCluster struct {
+	Name      string
+	Context   string
+	Activated bool `yaml:",omitempty"`
+}
+
+func (c *KubeconfigConfig) IsSetup() bool {
+	return c.Path != ""
+}
+
+func (c *KubeconfigConfig) GetCluster(name string) *KubeconfigCluster {
+	for _, cluster := range c.Clusters {
+		if cluster.Name == name {
+			return cluster
+		}
+	}
+	return nil
+}
+
+// AddCluster records an adopted cluster, replacing an existing entry with the same name
+func (c *KubeconfigConfig) AddCluster(cluster *KubeconfigCluster) {
+	c.RemoveCluster(cluster.Name)
+	c.Clusters = append(c.Clusters, cluster)
+}
+
+func (c *KubeconfigConfig) RemoveCluster(name string) {
+	clusters := make([]*KubeconfigCluster, 0, len(c.Clusters))
+	for _, cluster := range c.Clusters {
+		if cluster.Name != name {
+			clusters = append(clusters, cluster)
+		}
+	}
+	c.Clusters = clusters
+}
@@ -1,11 +1,13 @@
 package kube
 
 import (
+	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	istioapi "istio.io/client-go/pkg/apis/networking/v1beta1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer/json"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	metrics "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	kconf "sigs.k8s.io/controller-runtime/pkg/client/config"
 
@@ -14,8 +16,10 @@ import (
 	"github.com/k11n/konstellation/pkg/components/autoscaler"
 	"github.com/k11n/konstellation/pkg/components/grafana"
 	"github.com/k11n/konstellation/pkg/components/istio"
+	"github.com/k11n/konstellation/pkg/components/jaeger"
 	"github.com/k11n/konstellation/pkg/components/konstellation"
 	"github.com/k11n/konstellation/pkg/components/kubedash"
+	"github.com/k11n/konstellation/pkg/components/loki"
 	"github.com/k11n/konstellation/pkg/components/metricsserver"
 	"github.com/k11n/konstellation/pkg/components/prometheus"
 )
@@ -34,6 +38,8 @@ var (
 		&istio.IstioInstaller{},
 		&prometheus.KubePrometheus{},
 		&grafana.GrafanaOperator{},
+		&loki.Loki{},
+		&jaeger.Jaeger{},
 		&konstellation.Konstellation{},
 	}
 )
@@ -49,6 +55,11 @@ func init() {
 	clientgoscheme.AddToScheme(scheme)
 	metrics.AddToScheme(scheme)
 	istioapi.AddToScheme(scheme)
+	promv1.AddToScheme(scheme)
+}
+
+func GetKubeScheme() *runtime.Scheme {
+	return scheme
 }
 
 func KubernetesClientWithContext(contextName string) (client.Client, error) {
@@ -59,6 +70,15 @@ func KubernetesClientWithContext(contextName string) (client.Client, error) {
 	return client.New(conf, client.Options{Scheme: scheme})
 }
 
+// KubernetesCacheWithContext returns an informer cache to watch resources with, it has to be started by the caller
+func KubernetesCacheWithContext(contextName string) (cache.Cache, error) {
+	conf, err := kconf.GetConfigWithContext(contextName)
+	if err != nil {
+		return nil, err
+	}
+	return cache.New(conf, cache.Options{Scheme: scheme})
+}
+
 func GetKubeDecoder() runtime.Decoder {
 	return clientgoscheme.Codecs.UniversalDeserializer()
 }
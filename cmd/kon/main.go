@@ -30,6 +30,7 @@ func main() {
 	}
 	commandSets := [][]*cli.Command{
 		commands.AppCommands,
+		commands.BuildCommands,
 		commands.ConfigCommands,
 		commands.AccountCommands,
 		commands.CertificateCommands,
@@ -37,6 +38,7 @@ func main() {
 		commands.LaunchCommands,
 		commands.NodepoolCommands,
 		commands.SetupCommands,
+		commands.TopCommands,
 		commands.VPCCommands,
 	}
 	for _, cmds := range commandSets {
@@ -1,11 +1,19 @@
 package providers
 
 import (
+	"fmt"
+
+	cliv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
 	"github.com/k11n/konstellation/api/v1alpha1"
 	"github.com/k11n/konstellation/pkg/cloud"
 	"github.com/k11n/konstellation/pkg/components"
 )
 
+// ErrUnsupported is returned for operations that the cluster's provider doesn't manage, such as nodepools on
+// clusters adopted from a kubeconfig
+var ErrUnsupported = fmt.Errorf("operation is not supported by the cluster's provider")
+
 type CloudProvider interface {
 	ID() string
 	IsSetup() bool
@@ -45,3 +53,9 @@ type ClusterManager interface {
 	CertificateProvider() cloud.CertificateProvider
 	VPCProvider() cloud.VPCProvider
 }
+
+// KubeConfigSource is implemented by managers whose clusters are reached with existing credentials,
+// rather than with `kon cluster get-token`
+type KubeConfigSource interface {
+	KubeConfigForCluster(cluster string) (*cliv1.Cluster, *cliv1.AuthInfo, error)
+}
@@ -0,0 +1,79 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/config"
+	"github.com/k11n/konstellation/cmd/kon/providers"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/version"
+)
+
+type PromptConfigGenerator struct {
+	path string
+}
+
+func NewPromptConfigGenerator(path string) *PromptConfigGenerator {
+	return &PromptConfigGenerator{
+		path: path,
+	}
+}
+
+func (g *PromptConfigGenerator) CreateClusterConfig() (cc *v1alpha1.ClusterConfig, err error) {
+	kconf, err := loadKubeConfig(g.path)
+	if err != nil {
+		return
+	}
+	contexts := adoptableContexts(kconf)
+	if len(contexts) == 0 {
+		err = fmt.Errorf("no contexts found in %s", g.path)
+		return
+	}
+
+	cc = &v1alpha1.ClusterConfig{}
+	cc.Spec.Cloud = CloudID
+	cc.Spec.Region = g.path
+	cc.Spec.Version = version.Version
+	cc.Spec.Kubeconfig = &v1alpha1.KubeconfigClusterSpec{}
+
+	contextSelect := utils.NewPromptSelect("Context of the cluster to adopt", contexts)
+	contextSelect.Searcher = utils.SearchFuncFor(contexts, false)
+	if _, cc.Spec.Kubeconfig.Context, err = contextSelect.Run(); err != nil {
+		return
+	}
+
+	// cluster name
+	prompt := promptui.Prompt{
+		Label:     "Cluster name",
+		Default:   clusterNameForContext(cc.Spec.Kubeconfig.Context),
+		AllowEdit: true,
+		Validate:  utils.ValidateKubeName,
+	}
+	utils.FixPromptBell(&prompt)
+	cc.Name, err = prompt.Run()
+	if err != nil {
+		return
+	}
+	conf := config.GetConfig()
+	if conf.Clusters[cc.Name] != nil {
+		err = fmt.Errorf("Cluster name already in use")
+		return
+	}
+
+	// ensure that we can connect to it
+	cc.Spec.KubeVersion, err = serverVersion(g.path, cc.Spec.Kubeconfig.Context)
+	if err != nil {
+		return
+	}
+	fmt.Printf("Connected to %s, running Kubernetes %s\n", cc.Spec.Kubeconfig.Context, cc.Spec.KubeVersion)
+
+	return
+}
+
+// nodes of adopted clusters are managed outside of Konstellation
+func (g *PromptConfigGenerator) CreateNodepoolConfig(cc *v1alpha1.ClusterConfig) (np *v1alpha1.Nodepool, err error) {
+	return nil, providers.ErrUnsupported
+}
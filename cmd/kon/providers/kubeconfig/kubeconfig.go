@@ -0,0 +1,123 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/tools/clientcmd"
+	cliv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	"github.com/k11n/konstellation/cmd/kon/kube"
+)
+
+const (
+	// contexts that Konstellation generates itself
+	konPrefix = "kon-"
+)
+
+var (
+	nonDigits = regexp.MustCompile(`[^0-9]+`)
+	nonKube   = regexp.MustCompile(`[^a-z0-9-]+`)
+)
+
+func loadKubeConfig(path string) (*cliv1.Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not read kubeconfig %s", path)
+	}
+	obj, _, err := kube.GetKubeDecoder().Decode(data, nil, &cliv1.Config{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not decode kubeconfig %s", path)
+	}
+	return obj.(*cliv1.Config), nil
+}
+
+// adoptableContexts returns contexts that could be adopted, leaving out the ones Konstellation generated
+func adoptableContexts(kconf *cliv1.Config) []string {
+	var contexts []string
+	for _, ctx := range kconf.Contexts {
+		if !strings.HasPrefix(ctx.Name, konPrefix) {
+			contexts = append(contexts, ctx.Name)
+		}
+	}
+	return contexts
+}
+
+// clusterForContext looks up the cluster and user of a context. Relative file references are resolved
+// against the kubeconfig's directory, since they'll be copied into another file
+func clusterForContext(kconf *cliv1.Config, configDir, context string) (*cliv1.Cluster, *cliv1.AuthInfo, error) {
+	var kctx *cliv1.Context
+	for i := range kconf.Contexts {
+		if kconf.Contexts[i].Name == context {
+			kctx = &kconf.Contexts[i].Context
+			break
+		}
+	}
+	if kctx == nil {
+		return nil, nil, fmt.Errorf("could not find context %s", context)
+	}
+
+	var cluster *cliv1.Cluster
+	for i := range kconf.Clusters {
+		if kconf.Clusters[i].Name == kctx.Cluster {
+			c := kconf.Clusters[i].Cluster
+			cluster = &c
+			break
+		}
+	}
+	if cluster == nil {
+		return nil, nil, fmt.Errorf("could not find cluster %s for context %s", kctx.Cluster, context)
+	}
+
+	authInfo := &cliv1.AuthInfo{}
+	for i := range kconf.AuthInfos {
+		if kconf.AuthInfos[i].Name == kctx.AuthInfo {
+			a := kconf.AuthInfos[i].AuthInfo
+			authInfo = &a
+			break
+		}
+	}
+
+	resolvePath(configDir, &cluster.CertificateAuthority)
+	resolvePath(configDir, &authInfo.ClientCertificate)
+	resolvePath(configDir, &authInfo.ClientKey)
+	resolvePath(configDir, &authInfo.TokenFile)
+	return cluster, authInfo, nil
+}
+
+func resolvePath(dir string, path *string) {
+	if *path != "" && !filepath.IsAbs(*path) {
+		*path = filepath.Join(dir, *path)
+	}
+}
+
+// serverVersion returns the cluster's Kubernetes version, as major.minor
+func serverVersion(path, context string) (string, error) {
+	conf, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: path},
+		&clientcmd.ConfigOverrides{CurrentContext: context},
+	).ClientConfig()
+	if err != nil {
+		return "", err
+	}
+	client, err := discovery.NewDiscoveryClientForConfig(conf)
+	if err != nil {
+		return "", err
+	}
+	info, err := client.ServerVersion()
+	if err != nil {
+		return "", errors.Wrapf(err, "could not connect to context %s", context)
+	}
+	// some distributions report minor versions like 18+
+	return fmt.Sprintf("%s.%s", nonDigits.ReplaceAllString(info.Major, ""), nonDigits.ReplaceAllString(info.Minor, "")), nil
+}
+
+// clusterNameForContext suggests a cluster name from a context, i.e. gke_project_us-west1_dev -> gke-project-us-west1-dev
+func clusterNameForContext(context string) string {
+	return strings.Trim(nonKube.ReplaceAllString(strings.ToLower(context), "-"), "-")
+}
@@ -0,0 +1,52 @@
+package kubeconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cliv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+func newTestKubeConfig() *cliv1.Config {
+	return &cliv1.Config{
+		Clusters: []cliv1.NamedCluster{
+			{Name: "kind-dev", Cluster: cliv1.Cluster{Server: "https://127.0.0.1:6443", CertificateAuthority: "certs/ca.crt"}},
+			{Name: "kon-aws-prod", Cluster: cliv1.Cluster{Server: "https://prod.eks.amazonaws.com"}},
+		},
+		AuthInfos: []cliv1.NamedAuthInfo{
+			{Name: "kind-dev", AuthInfo: cliv1.AuthInfo{ClientCertificate: "certs/client.crt", ClientKey: "/etc/kind/client.key"}},
+		},
+		Contexts: []cliv1.NamedContext{
+			{Name: "kind-dev", Context: cliv1.Context{Cluster: "kind-dev", AuthInfo: "kind-dev"}},
+			{Name: "kon-aws-prod", Context: cliv1.Context{Cluster: "kon-aws-prod", AuthInfo: "kon-aws-prod-user"}},
+			{Name: "broken", Context: cliv1.Context{Cluster: "missing"}},
+		},
+	}
+}
+
+func TestAdoptableContexts(t *testing.T) {
+	assert.Equal(t, []string{"kind-dev", "broken"}, adoptableContexts(newTestKubeConfig()))
+}
+
+func TestClusterForContext(t *testing.T) {
+	kconf := newTestKubeConfig()
+	cluster, authInfo, err := clusterForContext(kconf, "/home/me/.kube", "kind-dev")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://127.0.0.1:6443", cluster.Server)
+	assert.Equal(t, "/home/me/.kube/certs/ca.crt", cluster.CertificateAuthority)
+	assert.Equal(t, "/home/me/.kube/certs/client.crt", authInfo.ClientCertificate)
+	assert.Equal(t, "/etc/kind/client.key", authInfo.ClientKey)
+	// original config is left alone
+	assert.Equal(t, "certs/ca.crt", kconf.Clusters[0].Cluster.CertificateAuthority)
+
+	_, _, err = clusterForContext(kconf, "/home/me/.kube", "broken")
+	assert.Error(t, err)
+	_, _, err = clusterForContext(kconf, "/home/me/.kube", "unknown")
+	assert.Error(t, err)
+}
+
+func TestClusterNameForContext(t *testing.T) {
+	assert.Equal(t, "kind-dev", clusterNameForContext("kind-dev"))
+	assert.Equal(t, "gke-project-us-west1-dev", clusterNameForContext("gke_project_us-west1_dev"))
+	assert.Equal(t, "admin-k3s", clusterNameForContext("admin@k3s"))
+}
@@ -0,0 +1,199 @@
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	cliv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/cmd/kon/config"
+	"github.com/k11n/konstellation/cmd/kon/providers"
+	"github.com/k11n/konstellation/pkg/cloud"
+	"github.com/k11n/konstellation/pkg/cloud/types"
+	"github.com/k11n/konstellation/version"
+)
+
+// KubeconfigManager manages clusters adopted from a kubeconfig. Its region is the path of the kubeconfig
+type KubeconfigManager struct {
+	path string
+}
+
+func NewKubeconfigManager(path string) *KubeconfigManager {
+	return &KubeconfigManager{
+		path: path,
+	}
+}
+
+func (m *KubeconfigManager) Cloud() string {
+	return CloudID
+}
+
+func (m *KubeconfigManager) Region() string {
+	return m.path
+}
+
+func (m *KubeconfigManager) String() string {
+	return fmt.Sprintf("Existing cluster (%s)", m.path)
+}
+
+// permissions are determined by the credentials in the kubeconfig
+func (m *KubeconfigManager) CheckCreatePermissions() error {
+	return nil
+}
+
+func (m *KubeconfigManager) CheckDestroyPermissions() error {
+	return nil
+}
+
+// CreateCluster adopts the cluster from its context, it's already running
+func (m *KubeconfigManager) CreateCluster(cc *v1alpha1.ClusterConfig) error {
+	if cc.Spec.Kubeconfig == nil || cc.Spec.Kubeconfig.Context == "" {
+		return fmt.Errorf("context is required to adopt a cluster")
+	}
+	cc.Spec.Region = m.path
+	cc.Spec.Version = version.Version
+
+	fmt.Printf("Adopting cluster %s from context %s\n", cc.Name, cc.Spec.Kubeconfig.Context)
+	fmt.Println("Konstellation will install its components onto the cluster, but won't manage its nodes or network.")
+
+	conf := config.GetConfig()
+	conf.Clouds.Kubeconfig.AddCluster(&config.KubeconfigCluster{
+		Name:    cc.Name,
+		Context: cc.Spec.Kubeconfig.Context,
+	})
+	return conf.Persist()
+}
+
+func (m *KubeconfigManager) CreateNodepool(cc *v1alpha1.ClusterConfig, np *v1alpha1.Nodepool) error {
+	return providers.ErrUnsupported
+}
+
+func (m *KubeconfigManager) ActivateCluster(cc *v1alpha1.ClusterConfig) error {
+	conf := config.GetConfig()
+	cluster := conf.Clouds.Kubeconfig.GetCluster(cc.Name)
+	if cluster == nil {
+		return fmt.Errorf("could not find cluster %s", cc.Name)
+	}
+	cluster.Activated = true
+	return conf.Persist()
+}
+
+// DeleteCluster forgets about the cluster, the cluster itself is left running
+func (m *KubeconfigManager) DeleteCluster(name string) error {
+	conf := config.GetConfig()
+	if conf.Clouds.Kubeconfig.GetCluster(name) == nil {
+		return fmt.Errorf("could not find cluster %s", name)
+	}
+	conf.Clouds.Kubeconfig.RemoveCluster(name)
+	if err := conf.Persist(); err != nil {
+		return err
+	}
+	fmt.Printf("Cluster %s is no longer managed by Konstellation. The cluster itself has been left running.\n", name)
+	return nil
+}
+
+func (m *KubeconfigManager) DeleteNodepool(cluster string, nodepool string) error {
+	return providers.ErrUnsupported
+}
+
+func (m *KubeconfigManager) DestroyVPC(vpcId string) error {
+	return providers.ErrUnsupported
+}
+
+// service accounts are created by the CLI, there are no cloud permissions to link them to
+func (m *KubeconfigManager) SyncLinkedServiceAccount(cluster string, lsa *v1alpha1.LinkedServiceAccount) error {
+	return nil
+}
+
+func (m *KubeconfigManager) DeleteLinkedServiceAccount(cluster string, lsa *v1alpha1.LinkedServiceAccount) error {
+	return nil
+}
+
+func (m *KubeconfigManager) KubernetesProvider() cloud.KubernetesProvider {
+	return &kubernetesService{manager: m}
+}
+
+func (m *KubeconfigManager) CertificateProvider() cloud.CertificateProvider {
+	return nil
+}
+
+func (m *KubeconfigManager) VPCProvider() cloud.VPCProvider {
+	return nil
+}
+
+// KubeConfigForCluster returns the cluster and user from the context that it was adopted from
+func (m *KubeconfigManager) KubeConfigForCluster(cluster string) (*cliv1.Cluster, *cliv1.AuthInfo, error) {
+	kc := config.GetConfig().Clouds.Kubeconfig.GetCluster(cluster)
+	if kc == nil {
+		return nil, nil, fmt.Errorf("could not find cluster %s", cluster)
+	}
+	kconf, err := loadKubeConfig(m.path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return clusterForContext(kconf, filepath.Dir(m.path), kc.Context)
+}
+
+// kubernetesService lists adopted clusters, everything else is managed outside of Konstellation
+type kubernetesService struct {
+	manager *KubeconfigManager
+}
+
+func (s *kubernetesService) ListClusters(ctx context.Context) ([]*types.Cluster, error) {
+	kconf, err := loadKubeConfig(s.manager.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var clusters []*types.Cluster
+	for _, kc := range config.GetConfig().Clouds.Kubeconfig.Clusters {
+		cluster, _, err := clusterForContext(kconf, filepath.Dir(s.manager.path), kc.Context)
+		if err != nil {
+			// context has been removed
+			continue
+		}
+		status := types.StatusActive
+		if !kc.Activated {
+			status = types.StatusUnconfigured
+		}
+		clusters = append(clusters, &types.Cluster{
+			ID:            kc.Context,
+			Name:          kc.Name,
+			Status:        status,
+			CloudProvider: CloudID,
+			Endpoint:      cluster.Server,
+		})
+	}
+	return clusters, nil
+}
+
+func (s *kubernetesService) GetAvailableVersions(ctx context.Context) ([]string, error) {
+	return nil, providers.ErrUnsupported
+}
+
+func (s *kubernetesService) GetCluster(ctx context.Context, name string) (*types.Cluster, error) {
+	clusters, err := s.ListClusters(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		if cluster.Name == name {
+			return cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("could not find cluster %s", name)
+}
+
+func (s *kubernetesService) GetAuthToken(ctx context.Context, cluster string, status types.ClusterStatus) (*types.AuthToken, error) {
+	return nil, fmt.Errorf("cluster %s uses credentials from its kubeconfig, get-token is not needed", cluster)
+}
+
+func (s *kubernetesService) IsNodepoolReady(ctx context.Context, clusterName string, nodepoolName string) (bool, error) {
+	return false, providers.ErrUnsupported
+}
+
+func (s *kubernetesService) CreateNodepool(ctx context.Context, cc *v1alpha1.ClusterConfig, np *v1alpha1.Nodepool) error {
+	return providers.ErrUnsupported
+}
@@ -0,0 +1,96 @@
+package kubeconfig
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+
+	"github.com/k11n/konstellation/cmd/kon/config"
+	"github.com/k11n/konstellation/cmd/kon/kube"
+	"github.com/k11n/konstellation/cmd/kon/utils"
+	"github.com/k11n/konstellation/pkg/components"
+	"github.com/k11n/konstellation/pkg/components/autoscaler"
+	"github.com/k11n/konstellation/pkg/components/ingress"
+)
+
+const (
+	CloudID = "kubeconfig"
+)
+
+// KubeconfigProvider adopts existing Kubernetes clusters from a kubeconfig, such as kind, k3s, or on-prem clusters.
+// Konstellation installs its components onto them, but doesn't manage their nodes or network
+type KubeconfigProvider struct {
+}
+
+func NewKubeconfigProvider() *KubeconfigProvider {
+	return &KubeconfigProvider{}
+}
+
+func (p *KubeconfigProvider) ID() string {
+	return CloudID
+}
+
+func (p *KubeconfigProvider) String() string {
+	return "Existing cluster (kubeconfig)"
+}
+
+func (p *KubeconfigProvider) IsSetup() bool {
+	return config.GetConfig().Clouds.Kubeconfig.IsSetup()
+}
+
+func (p *KubeconfigProvider) Setup() error {
+	conf := config.GetConfig()
+	kconf := &conf.Clouds.Kubeconfig
+
+	path := kconf.Path
+	if path == "" {
+		path = os.Getenv("KUBECONFIG")
+	}
+	if path == "" {
+		defaultPath, err := config.KubeConfigPath()
+		if err != nil {
+			return err
+		}
+		path = defaultPath
+	}
+
+	prompt := promptui.Prompt{
+		Label:     "Kubeconfig containing the clusters",
+		Default:   path,
+		AllowEdit: true,
+		Validate: func(s string) error {
+			kc, err := loadKubeConfig(s)
+			if err != nil {
+				return err
+			}
+			if len(adoptableContexts(kc)) == 0 {
+				return fmt.Errorf("no contexts found")
+			}
+			return nil
+		},
+	}
+	utils.FixPromptBell(&prompt)
+	path, err := prompt.Run()
+	if err != nil {
+		return err
+	}
+	kconf.Path = path
+
+	fmt.Println("Existing clusters can now be used with Konstellation!")
+	fmt.Println("next, adopt a cluster from one of the contexts with `kon cluster create`")
+	return conf.Persist()
+}
+
+func (p *KubeconfigProvider) GetComponents() []components.ComponentInstaller {
+	comps := make([]components.ComponentInstaller, 0, len(kube.KubeComponents)+1)
+	for _, comp := range kube.KubeComponents {
+		// nodes aren't managed by Konstellation, so there's nothing to autoscale
+		if _, ok := comp.(*autoscaler.ClusterAutoScaler); ok {
+			continue
+		}
+		comps = append(comps, comp)
+	}
+	comps = append(comps, &ingress.GenericIngress{})
+	return comps
+}
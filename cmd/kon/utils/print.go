@@ -37,6 +37,11 @@ func PrintImportant(message string, header string) {
 	fmt.Println("")
 }
 
+// ClearScreen moves the cursor to the top and clears the terminal, for commands that refresh their output
+func ClearScreen() {
+	fmt.Print("\033[H\033[2J")
+}
+
 func PrintJSON(val interface{}) {
 	data, _ := json.MarshalIndent(val, "", "  ")
 	fmt.Println(string(data))
@@ -174,12 +174,31 @@ func (r *AppReconciler) reconcileBuild(ctx context.Context, app *v1alpha1.App) (
 			if err != nil {
 				return nil, err
 			}
+			buildsCreated.WithLabelValues(app.Name).Inc()
 			return build, nil
 		} else {
 			return nil, err
 		}
 	}
 
+	// builds registered ahead of the deploy (kon app deploy, deploy hook) become latest once an app uses them.
+	// rolling back to an older build leaves the latest build alone
+	if existing.Labels[resources.BuildTypeLabel] != resources.BuildTypeLatest {
+		latest, err := resources.GetLatestBuild(r.Client, existing.Spec.Registry, existing.Spec.Image)
+		if err != nil && err != resources.ErrNotFound {
+			return nil, err
+		}
+		if latest == nil || latest.Spec.CreatedAt.Seconds < existing.Spec.CreatedAt.Seconds {
+			if existing.Labels == nil {
+				existing.Labels = resources.LabelsForBuild(existing)
+			}
+			existing.Labels[resources.BuildTypeLabel] = resources.BuildTypeLatest
+			if err = r.Client.Update(ctx, existing); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	return existing, nil
 }
 
@@ -225,6 +244,7 @@ func newAppTargetForApp(app *v1alpha1.App, target string, build *v1alpha1.Build)
 			Configs:    app.Spec.Configs,
 			Scale:      *app.Spec.ScaleSpecForTarget(target),
 			Prometheus: app.Spec.Prometheus,
+			Alerts:     app.Spec.Alerts,
 		},
 	}
 
@@ -18,7 +18,9 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"sort"
+	"strconv"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -163,14 +165,13 @@ func (r *AppReleaseReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 		// loop through the pods and see what's going on
 		numSuccessful := 0
 		var createdAt *time.Time
-		for _, pod := range podList.Items {
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			// running pods could still have containers that are crashing
+			if resources.IsPodFailing(pod) {
+				status.PodErrors = append(status.PodErrors, resources.PodStatusForPod(pod))
+			}
 			switch pod.Status.Phase {
-			case corev1.PodPending, corev1.PodFailed:
-				status.PodErrors = append(status.PodErrors, v1alpha1.PodStatus{
-					Pod:     pod.Name,
-					Reason:  pod.Status.Reason,
-					Message: pod.Status.Message,
-				})
 			case corev1.PodRunning, corev1.PodSucceeded:
 				numSuccessful += 1
 			}
@@ -185,12 +186,16 @@ func (r *AppReleaseReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error)
 
 	if !apiequality.Semantic.DeepEqual(status, ar.Status) {
 		//reqLogger.Info("status changed", "old", ar.Status, "new", status)
+		prevState := ar.Status.State
 		ar.Status = status
 		err = r.Client.Status().Update(ctx, ar)
 		reqLogger.Info("Updated AppRelease status", "numAvailable", status.NumAvailable, "numDesired", status.NumDesired)
 		if err != nil {
 			return res, err
 		}
+		if status.State == v1alpha1.ReleaseStateBad && prevState != v1alpha1.ReleaseStateBad {
+			rollbacks.WithLabelValues(ar.Spec.App, ar.Spec.Target).Inc()
+		}
 	}
 
 	return res, err
@@ -208,9 +213,14 @@ func (r *AppReleaseReconciler) newReplicaSetForAR(ar *v1alpha1.AppRelease, build
 	labels[resources.BuildLabel] = build.Name
 	labels[resources.KubeAppLabel] = ar.Spec.App
 
+	// releases keep running the image they were created with, even when the build is refreshed
+	image := ar.Spec.Image
+	if image == "" {
+		image = build.ImageReference()
+	}
 	container := corev1.Container{
 		Name:      ar.Spec.App,
-		Image:     build.FullImageWithTag(),
+		Image:     image,
 		Command:   ar.Spec.Command,
 		Args:      ar.Spec.Args,
 		Resources: ar.Spec.Resources,
@@ -265,6 +275,17 @@ func (r *AppReleaseReconciler) newReplicaSetForAR(ar *v1alpha1.AppRelease, build
 		}
 	}
 
+	// sampling rate is set on each sidecar
+	var podAnnotations map[string]string
+	if t := ar.Spec.Tracing; t != nil && t.SamplingRate != "" {
+		if pct, err := t.SamplingPercentage(); err == nil {
+			podAnnotations = map[string]string{
+				resources.IstioProxyConfigAnnotation: fmt.Sprintf("tracing:\n  sampling: %s\n",
+					strconv.FormatFloat(pct, 'f', -1, 64)),
+			}
+		}
+	}
+
 	// release name would use build creation timestamp
 	rs := &appsv1.ReplicaSet{
 		ObjectMeta: metav1.ObjectMeta{
@@ -279,7 +300,8 @@ func (r *AppReleaseReconciler) newReplicaSetForAR(ar *v1alpha1.AppRelease, build
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      labels,
+					Annotations: podAnnotations,
 				},
 				Spec: podSpec,
 			},
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func TestNewReplicaSetTracing(t *testing.T) {
+	r := &AppReleaseReconciler{Log: ctrl.Log.WithName("test")}
+	ar := &v1alpha1.AppRelease{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-20200701-1022-8f3a", Namespace: "production"},
+		Spec: v1alpha1.AppReleaseSpec{
+			App:    "myapp",
+			Target: "production",
+		},
+	}
+	build := v1alpha1.NewBuild("", "myapp", "v1")
+
+	rs, err := r.newReplicaSetForAR(ar, build, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, rs.Spec.Template.Annotations)
+
+	ar.Spec.Tracing = &v1alpha1.TracingSpec{SamplingRate: "12.5"}
+	rs, err = r.newReplicaSetForAR(ar, build, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "tracing:\n  sampling: 12.5\n", rs.Spec.Template.Annotations[resources.IstioProxyConfigAnnotation])
+}
+
+func TestReleasePinsImage(t *testing.T) {
+	at := &v1alpha1.AppTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp-production", Labels: map[string]string{}},
+		Spec:       v1alpha1.AppTargetSpec{App: "myapp", Target: "production"},
+	}
+	assert.NoError(t, at.UpdateHash())
+	build := v1alpha1.NewBuild("", "myapp", "v1")
+	build.Spec.Digest = "sha256:aaaa"
+
+	ar := appReleaseForTarget(at, build, nil)
+	assert.Equal(t, "myapp@sha256:aaaa", ar.Spec.Image)
+
+	// refreshed build gets a new release, the existing one keeps its image
+	build.Spec.Digest = "sha256:bbbb"
+	refreshed := appReleaseForTarget(at, build, nil)
+	assert.NotEqual(t, ar.Name, refreshed.Name)
+
+	r := &AppReleaseReconciler{Log: ctrl.Log.WithName("test")}
+	rs, err := r.newReplicaSetForAR(ar, build, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp@sha256:aaaa", rs.Spec.Template.Spec.Containers[0].Image)
+
+	// releases from before images were pinned follow the build
+	ar.Spec.Image = ""
+	rs, err = r.newReplicaSetForAR(ar, build, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "myapp@sha256:bbbb", rs.Spec.Template.Spec.Containers[0].Image)
+}
@@ -18,22 +18,33 @@ package controllers
 
 import (
 	"context"
+	"time"
 
 	"github.com/go-logr/logr"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/registry"
 	"github.com/k11n/konstellation/pkg/resources"
 )
 
+const (
+	digestRetryMinInterval = 30 * time.Second
+	digestRetryMaxInterval = time.Hour
+)
+
 // BuildReconciler reconciles a Build object
 type BuildReconciler struct {
 	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	// uncached reader for image pull secrets
+	Reader             client.Reader
+	Log                logr.Logger
+	Scheme             *runtime.Scheme
+	InsecureRegistries []string
 }
 
 // +kubebuilder:rbac:groups=k11n.dev,resources=builds,verbs=get;list;watch;create;update;patch;delete
@@ -58,6 +69,14 @@ func (r *BuildReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err erro
 		return
 	}
 
+	// pin the build to the image that the tag points to now
+	if build.Spec.Digest == "" {
+		res, err = r.resolveDigest(ctx, build)
+		if err != nil {
+			return
+		}
+	}
+
 	// if not latest, then we can ignore
 	if build.Labels[resources.BuildTypeLabel] != resources.BuildTypeLatest {
 		return
@@ -98,9 +117,119 @@ func (r *BuildReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err erro
 		}
 		resources.LogUpdates(r.Log, op, "Removed latest label from build", "build", b.Name, "newBuild", build.Name)
 	}
+
+	err = r.updateWatchingApps(build)
+	return
+}
+
+// resolveDigest pins the build to its image digest. Failures are recorded on the
+// status and retried with backoff, rather than calling the registry on every event
+func (r *BuildReconciler) resolveDigest(ctx context.Context, build *v1alpha1.Build) (res ctrl.Result, err error) {
+	status := &build.Status
+	if status.LastDigestAttempt != nil {
+		next := status.LastDigestAttempt.Add(digestRetryBackoff(status.DigestAttempts))
+		if wait := time.Until(next); wait > 0 {
+			res.RequeueAfter = wait
+			return
+		}
+	}
+
+	digest, resolveErr := resources.ResolveBuildDigest(r.Client, r.Reader, build, r.InsecureRegistries...)
+	if resolveErr != nil {
+		r.Log.Error(resolveErr, "Could not resolve image digest, deploying by tag", "build", build.Name,
+			"attempts", status.DigestAttempts+1)
+		status.DigestAttempts += 1
+		status.DigestError = resolveErr.Error()
+		now := metav1.Now()
+		status.LastDigestAttempt = &now
+		if err = r.Client.Status().Update(ctx, build); err != nil {
+			return
+		}
+		res.RequeueAfter = digestRetryBackoff(status.DigestAttempts)
+		return
+	}
+
+	build.Spec.Digest = digest
+	if err = r.Client.Update(ctx, build); err != nil {
+		return
+	}
+	r.Log.Info("Resolved image digest", "build", build.Name, "digest", digest)
+
+	if status.DigestAttempts > 0 {
+		build.Status = v1alpha1.BuildStatus{}
+		err = r.Client.Status().Update(ctx, build)
+	}
 	return
 }
 
+// digestRetryBackoff returns how long to wait after the given number of failed attempts
+func digestRetryBackoff(attempts int32) time.Duration {
+	if attempts <= 0 {
+		return 0
+	}
+	backoff := digestRetryMinInterval
+	for i := int32(1); i < attempts && backoff < digestRetryMaxInterval; i++ {
+		backoff *= 2
+	}
+	if backoff > digestRetryMaxInterval {
+		backoff = digestRetryMaxInterval
+	}
+	return backoff
+}
+
+// apps with an ImageWatch are moved to the latest build when its tag matches
+func (r *BuildReconciler) updateWatchingApps(build *v1alpha1.Build) error {
+	return resources.ForEach(r.Client, &v1alpha1.AppList{}, func(item interface{}) error {
+		app := item.(v1alpha1.App)
+		if app.Spec.ImageWatch == nil || app.Spec.Registry != build.Spec.Registry ||
+			app.Spec.ImageTag == build.Spec.Tag {
+			return nil
+		}
+		filter, err := registry.NewTagFilter(string(app.Spec.ImageWatch.Strategy), app.Spec.ImageWatch.Pattern)
+		if err != nil {
+			r.Log.Error(err, "Invalid image watch", "app", app.Name)
+			return nil
+		}
+		if !filter.Matches(build.Spec.Tag) {
+			return nil
+		}
+		current, err := resources.GetBuildByName(r.Client,
+			v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, app.Spec.ImageTag).Name)
+		if errors.IsNotFound(err) {
+			current = nil
+		} else if err != nil {
+			return err
+		}
+		if !isWatchUpgrade(&app, current, build) {
+			return nil
+		}
+
+		app.Spec.ImageTag = build.Spec.Tag
+		op, err := resources.UpdateResource(r.Client, &app, nil, nil)
+		if err != nil {
+			return err
+		}
+		resources.LogUpdates(r.Log, op, "Updated app to latest build", "app", app.Name, "build", build.Name)
+		return nil
+	}, client.MatchingFields{"spec.image": build.Spec.Image})
+}
+
+// isWatchUpgrade returns true if moving a watching app from its current build to the new one isn't a downgrade,
+// which would happen when an older tag is registered after the app has moved on
+func isWatchUpgrade(app *v1alpha1.App, current *v1alpha1.Build, build *v1alpha1.Build) bool {
+	if app.Spec.ImageWatch.Strategy == v1alpha1.TagStrategySemver {
+		currentVersion := registry.ParseSemver(app.Spec.ImageTag)
+		if currentVersion != nil && currentVersion.Compare(registry.ParseSemver(build.Spec.Tag)) >= 0 {
+			return false
+		}
+		return true
+	}
+	if current != nil && current.Spec.CreatedAt.Seconds >= build.Spec.CreatedAt.Seconds {
+		return false
+	}
+	return true
+}
+
 func (r *BuildReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &v1alpha1.App{}, "spec.image", func(rawObj runtime.Object) []string {
 		app := rawObj.(*v1alpha1.App)
@@ -0,0 +1,44 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestIsWatchUpgrade(t *testing.T) {
+	newBuild := func(tag string, createdAt int64) *v1alpha1.Build {
+		build := v1alpha1.NewBuild("", "myapp", tag)
+		build.Spec.CreatedAt = metav1.Timestamp{Seconds: createdAt}
+		return build
+	}
+	app := &v1alpha1.App{
+		Spec: v1alpha1.AppSpec{
+			ImageTag:   "main-2",
+			ImageWatch: &v1alpha1.ImageWatchSpec{Strategy: v1alpha1.TagStrategyRegex, Pattern: "^main-"},
+		},
+	}
+	current := newBuild("main-2", 2000)
+	assert.True(t, isWatchUpgrade(app, current, newBuild("main-3", 3000)))
+	// older tag registered later
+	assert.False(t, isWatchUpgrade(app, current, newBuild("main-1", 1000)))
+	// current build isn't known
+	assert.True(t, isWatchUpgrade(app, nil, newBuild("main-1", 1000)))
+
+	app.Spec.ImageTag = "1.2.0"
+	app.Spec.ImageWatch = &v1alpha1.ImageWatchSpec{Strategy: v1alpha1.TagStrategySemver}
+	assert.True(t, isWatchUpgrade(app, nil, newBuild("1.3.0", 1000)))
+	assert.False(t, isWatchUpgrade(app, nil, newBuild("1.1.0", 3000)))
+}
+
+func TestDigestRetryBackoff(t *testing.T) {
+	assert.Equal(t, time.Duration(0), digestRetryBackoff(0))
+	assert.Equal(t, 30*time.Second, digestRetryBackoff(1))
+	assert.Equal(t, time.Minute, digestRetryBackoff(2))
+	assert.Equal(t, 4*time.Minute, digestRetryBackoff(4))
+	assert.Equal(t, time.Hour, digestRetryBackoff(20))
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// BuildGC periodically deletes builds that are no longer referenced by apps, app targets or releases
+type BuildGC struct {
+	client.Client
+	Log      logr.Logger
+	Interval time.Duration
+	Policy   resources.BuildPrunePolicy
+}
+
+func (g *BuildGC) Start(stop <-chan struct{}) error {
+	g.Log.Info("Starting build garbage collection", "interval", g.Interval,
+		"minAge", g.Policy.MinAge, "keepPerImage", g.Policy.KeepPerImage)
+
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			if err := g.collect(); err != nil {
+				g.Log.Error(err, "Could not garbage collect builds")
+			}
+		}
+	}
+}
+
+func (g *BuildGC) collect() error {
+	builds, err := resources.FindBuildsToPrune(g.Client, g.Policy)
+	if err != nil {
+		return err
+	}
+	for _, build := range builds {
+		if err = client.IgnoreNotFound(g.Client.Delete(context.TODO(), build)); err != nil {
+			return err
+		}
+		g.Log.Info("Deleted unused build", "build", build.Name, "image", build.ShortName())
+	}
+	return nil
+}
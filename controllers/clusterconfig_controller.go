@@ -45,6 +45,9 @@ const (
 	defaultScrapeInterval     = "15s"
 	defaultEvaluationInterval = "15s"
 	defaultRetentionPeriod    = "7d"
+	operatorMetricsName       = "konstellation-metrics"
+	operatorControlPlaneLabel = "control-plane"
+	operatorControlPlane      = "konstellation-manager"
 )
 
 // ClusterConfigReconciler reconciles a ClusterConfig object
@@ -58,7 +61,7 @@ type ClusterConfigReconciler struct {
 // +kubebuilder:rbac:groups=k11n.dev,resources=clusterconfigs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch;create;update
 // +kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
-// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheuses;servicemonitors,verbs=get;list;watch;create;update;patch;delete
 
 func (r *ClusterConfigReconciler) Reconcile(req ctrl.Request) (ctrl.Result, error) {
 	ctx := context.Background()
@@ -157,9 +160,44 @@ func (r *ClusterConfigReconciler) reconcilePrometheus(cc *v1alpha1.ClusterConfig
 		return err
 	}
 	resources.LogUpdates(r.Log, op, "Updated Prometheus")
+
+	sm := newOperatorServiceMonitor()
+	op, err = resources.UpdateResource(r.Client, sm, cc, r.Scheme)
+	if err != nil {
+		return err
+	}
+	resources.LogUpdates(r.Log, op, "Updated operator ServiceMonitor")
 	return nil
 }
 
+// newOperatorServiceMonitor scrapes the operator's own metrics, through the metrics service in deploy/operator.yaml
+func newOperatorServiceMonitor() *promv1.ServiceMonitor {
+	labels := map[string]string{
+		operatorControlPlaneLabel: operatorControlPlane,
+	}
+	return &promv1.ServiceMonitor{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      operatorMetricsName,
+			Namespace: resources.KonSystemNamespace,
+			Labels:    labels,
+		},
+		Spec: promv1.ServiceMonitorSpec{
+			Endpoints: []promv1.Endpoint{
+				{
+					Port: "metrics",
+					Path: "/metrics",
+				},
+			},
+			Selector: metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			NamespaceSelector: promv1.NamespaceSelector{
+				MatchNames: []string{resources.KonSystemNamespace},
+			},
+		},
+	}
+}
+
 func newPrometheus(config map[string]string, storageClass string) *promv1.Prometheus {
 	volumeMode := corev1.PersistentVolumeFilesystem
 	diskSize := prometheus.DefaultDiskSize
@@ -221,6 +259,8 @@ func newPrometheus(config map[string]string, storageClass string) *promv1.Promet
 				},
 			},
 			Retention: defaultRetentionPeriod,
+			// alert rules for apps are created in their target namespaces
+			RuleNamespaceSelector: &metav1.LabelSelector{},
 			RuleSelector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
 					prometheusName: k8sName,
@@ -0,0 +1,122 @@
+package controllers
+
+import (
+	"fmt"
+
+	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+const (
+	alertSeverityCritical = "critical"
+	alertSeverityWarning  = "warning"
+)
+
+// burn rate thresholds for a 30 day SLO, from the Site Reliability Workbook. an alert fires when the error budget
+// is consumed this many times faster than sustainable, over both the long and short windows
+type burnRateWindow struct {
+	long     string
+	short    string
+	factor   float64
+	severity string
+}
+
+var burnRateWindows = []burnRateWindow{
+	{long: "1h", short: "5m", factor: 14.4, severity: alertSeverityCritical},
+	{long: "6h", short: "30m", factor: 6, severity: alertSeverityCritical},
+	{long: "1d", short: "2h", factor: 3, severity: alertSeverityWarning},
+	{long: "3d", short: "6h", factor: 1, severity: alertSeverityWarning},
+}
+
+// alertRulesForAppTarget expands the app's alerts into Prometheus rules. SLOs are measured from Istio's request
+// metrics, so they are left out for apps without a service
+func alertRulesForAppTarget(at *v1alpha1.AppTarget) []promv1.Rule {
+	alerts := at.Spec.Alerts
+	if alerts == nil {
+		return nil
+	}
+
+	var rules []promv1.Rule
+	newRule := func(name, expr, forDuration, severity string, annotations map[string]string) promv1.Rule {
+		labels := map[string]string{
+			"app":      at.Spec.App,
+			"target":   at.Spec.Target,
+			"severity": severity,
+		}
+		for k, v := range alerts.Labels {
+			labels[k] = v
+		}
+		return promv1.Rule{
+			Alert:       name,
+			Expr:        intstr.FromString(expr),
+			For:         forDuration,
+			Labels:      labels,
+			Annotations: annotations,
+		}
+	}
+
+	requestSelector := fmt.Sprintf(`reporter="destination", destination_workload_namespace="%s", destination_app="%s"`,
+		at.TargetNamespace(), at.Spec.App)
+	if slo := alerts.SLO; slo != nil && at.NeedsService() {
+		if budget, err := v1alpha1.ErrorBudget(slo.Availability); err == nil {
+			errorRatio := func(window string) string {
+				return fmt.Sprintf(`(sum(rate(istio_requests_total{%s, response_code=~"5.."}[%s])) / sum(rate(istio_requests_total{%s}[%s])))`,
+					requestSelector, window, requestSelector, window)
+			}
+			for _, w := range burnRateWindows {
+				rules = append(rules, newRule("AppAvailabilityBudgetBurn",
+					burnRateExpr(errorRatio, w, budget), "", w.severity, map[string]string{
+						"summary": fmt.Sprintf("%s in %s is failing too many requests", at.Spec.App, at.Spec.Target),
+						"description": fmt.Sprintf("Over the last %s, the error budget for %s%% availability is being used %gx faster than sustainable",
+							w.long, slo.Availability, w.factor),
+					}))
+			}
+		}
+
+		if slo.Latency != nil {
+			if budget, err := v1alpha1.ErrorBudget(slo.Latency.Target); err == nil {
+				slowRatio := func(window string) string {
+					return fmt.Sprintf(`(1 - sum(rate(istio_request_duration_milliseconds_bucket{%s, le="%d"}[%s])) / sum(rate(istio_request_duration_milliseconds_count{%s}[%s])))`,
+						requestSelector, slo.Latency.ThresholdMillis, window, requestSelector, window)
+				}
+				for _, w := range burnRateWindows {
+					rules = append(rules, newRule("AppLatencyBudgetBurn",
+						burnRateExpr(slowRatio, w, budget), "", w.severity, map[string]string{
+							"summary": fmt.Sprintf("%s in %s is responding too slowly", at.Spec.App, at.Spec.Target),
+							"description": fmt.Sprintf("Over the last %s, the error budget for %s%% of requests within %dms is being used %gx faster than sustainable",
+								w.long, slo.Latency.Target, slo.Latency.ThresholdMillis, w.factor),
+						}))
+				}
+			}
+		}
+	}
+
+	if alerts.CrashLoop {
+		rules = append(rules, newRule("AppCrashLooping",
+			fmt.Sprintf(`rate(kube_pod_container_status_restarts_total{namespace="%s", container="%s"}[15m]) * 60 * 5 > 0`,
+				at.TargetNamespace(), at.Spec.App),
+			"15m", alertSeverityWarning, map[string]string{
+				"summary":     fmt.Sprintf("%s in %s is crash looping", at.Spec.App, at.Spec.Target),
+				"description": "Pod {{ $labels.pod }} is restarting {{ printf \"%.2f\" $value }} times every 5 minutes",
+			}))
+	}
+
+	if alerts.PendingPods {
+		rules = append(rules, newRule("AppPodsPending",
+			fmt.Sprintf(`sum by (pod) (kube_pod_status_phase{namespace="%s", phase="Pending"} * on(namespace, pod) group_left() kube_pod_labels{namespace="%s", label_k11n_dev_app="%s"}) > 0`,
+				at.TargetNamespace(), at.TargetNamespace(), at.Spec.App),
+			"15m", alertSeverityWarning, map[string]string{
+				"summary":     fmt.Sprintf("%s in %s has pods that can't be scheduled", at.Spec.App, at.Spec.Target),
+				"description": "Pod {{ $labels.pod }} has been pending for more than 15 minutes",
+			}))
+	}
+
+	return rules
+}
+
+func burnRateExpr(ratio func(window string) string, w burnRateWindow, budget float64) string {
+	threshold := fmt.Sprintf("%.6g", w.factor*budget)
+	return fmt.Sprintf("%s > %s and %s > %s", ratio(w.long), threshold, ratio(w.short), threshold)
+}
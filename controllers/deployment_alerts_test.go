@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"testing"
+
+	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestAlertRulesForAppTarget(t *testing.T) {
+	at := &v1alpha1.AppTarget{
+		Spec: v1alpha1.AppTargetSpec{
+			App:    "myapp",
+			Target: "production",
+			AppCommonSpec: v1alpha1.AppCommonSpec{
+				Ports: []v1alpha1.PortSpec{{Name: "http", Port: 80}},
+			},
+		},
+	}
+	assert.Nil(t, newPromRuleForAppTarget(at))
+
+	at.Spec.Alerts = &v1alpha1.AlertsSpec{
+		SLO: &v1alpha1.SLOSpec{
+			Availability: "99.9",
+			Latency:      &v1alpha1.LatencySLO{ThresholdMillis: 250, Target: "99"},
+		},
+		CrashLoop:   true,
+		PendingPods: true,
+		Labels:      map[string]string{"team": "web"},
+	}
+	rules := alertRulesForAppTarget(at)
+	assert.Len(t, rules, 2*len(burnRateWindows)+2)
+
+	fastBurn := rules[0]
+	assert.Equal(t, "AppAvailabilityBudgetBurn", fastBurn.Alert)
+	assert.Equal(t, map[string]string{
+		"app":      "myapp",
+		"target":   "production",
+		"severity": alertSeverityCritical,
+		"team":     "web",
+	}, fastBurn.Labels)
+	assert.Contains(t, fastBurn.Expr.String(), "[1h]))) > 0.0144 and")
+	assert.Contains(t, fastBurn.Expr.String(), "[5m]))) > 0.0144")
+	assert.Equal(t, alertSeverityWarning, rules[3].Labels["severity"])
+
+	latency := rules[len(burnRateWindows)]
+	assert.Equal(t, "AppLatencyBudgetBurn", latency.Alert)
+	assert.Contains(t, latency.Expr.String(), `le="250"`)
+	assert.Contains(t, latency.Expr.String(), "> 0.144 and")
+
+	assert.Equal(t, "AppCrashLooping", rules[len(rules)-2].Alert)
+	assert.Equal(t, "AppPodsPending", rules[len(rules)-1].Alert)
+	assert.Equal(t, "15m", rules[len(rules)-1].For)
+
+	// user rules are kept in their own group
+	at.Spec.Prometheus = &v1alpha1.PrometheusSpec{
+		Rules: []promv1.Rule{{Record: "myapp:requests"}},
+	}
+	pr := newPromRuleForAppTarget(at)
+	assert.Len(t, pr.Spec.Groups, 2)
+	assert.Equal(t, "myapp", pr.Spec.Groups[0].Name)
+	assert.Equal(t, "myapp-alerts", pr.Spec.Groups[1].Name)
+	assert.Equal(t, "alert-rules", pr.Labels["role"])
+
+	// SLOs need a service
+	at.Spec.Ports = nil
+	assert.Len(t, alertRulesForAppTarget(at), 2)
+}
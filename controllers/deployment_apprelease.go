@@ -23,6 +23,10 @@ const (
 	// keep at least 10 releases and everything in last 48 hours
 	numReleasesToKeep  = 10
 	releaseHoursToKeep = 48
+
+	// new builds are deployed by tag if their digest isn't resolved by then
+	digestWaitTimeout  = time.Minute
+	digestWaitInterval = 5 * time.Second
 )
 
 func (r *DeploymentReconciler) reconcileAppReleases(ctx context.Context, at *v1alpha1.AppTarget, configMap *corev1.ConfigMap) (releases []*v1alpha1.AppRelease, res *ctrl.Result, err error) {
@@ -58,6 +62,11 @@ func (r *DeploymentReconciler) reconcileAppReleases(ctx context.Context, at *v1a
 			continue
 		}
 
+		// a build that now points to another digest (i.e. after `kon build refresh`) needs a new release
+		if ar.Spec.Image != "" && ar.Spec.Image != build.ImageReference() {
+			continue
+		}
+
 		if configMap == nil || configMap.Name == ar.Spec.Config {
 			existingRelease = ar
 			releaseIdx = idx
@@ -74,8 +83,19 @@ func (r *DeploymentReconciler) reconcileAppReleases(ctx context.Context, at *v1a
 		existingRelease = nil
 	}
 
+	// give the build controller a chance to pin a new build to its digest, so that the release isn't created
+	// by tag and then replaced once the digest is known
+	if existingRelease == nil && build.Spec.Digest == "" && build.Status.LastDigestAttempt == nil &&
+		time.Since(build.CreationTimestamp.Time) < digestWaitTimeout {
+		r.Log.Info("waiting for build digest", "build", build.Name)
+		res = &ctrl.Result{RequeueAfter: digestWaitInterval}
+		return
+	}
+
 	// create releases for new builds
+	var newReleaseReason string
 	if existingRelease == nil {
+		newReleaseReason = newReleaseReasonFor(releases, build, configMap)
 		configName := ""
 		if configMap != nil {
 			configName = configMap.Name
@@ -115,6 +135,9 @@ func (r *DeploymentReconciler) reconcileAppReleases(ctx context.Context, at *v1a
 
 		resources.LogUpdates(r.Log, op, "Updated AppRelease", "appTarget", at.Name, "release", ar.Name)
 	}
+	if newReleaseReason != "" {
+		releasesCreated.WithLabelValues(at.Spec.App, at.Spec.Target, newReleaseReason).Inc()
+	}
 
 	// delete older releases
 	if len(releases) > numReleasesToKeep {
@@ -262,6 +285,10 @@ func (r *DeploymentReconciler) deployReleases(ctx context.Context, at *v1alpha1.
 			// traffic already at 100%, update active roles and we are done
 			activeRelease = targetRelease
 			logger.Info("Target fully deployed, marking as active", "release", targetRelease.Name)
+			if !targetRelease.CreationTimestamp.IsZero() {
+				deployDuration.WithLabelValues(at.Spec.App, at.Spec.Target).
+					Observe(time.Since(targetRelease.CreationTimestamp.Time).Seconds())
+			}
 			hasChanges = true
 		} else {
 			// not fully ramped yet, check again
@@ -433,13 +460,16 @@ func appReleaseForTarget(at *v1alpha1.AppTarget, build *v1alpha1.Build, configMa
 	}
 	labels[v1alpha1.AppTargetHash] = at.GetHash()
 
-	// generate name hash with both appTargetHash and config
+	// generate name hash with appTargetHash, config and the image digest
 	hashStr := at.GetHash()
 	if configMap != nil {
 		labels[v1alpha1.ConfigHashLabel] = configMap.Labels[v1alpha1.ConfigHashLabel]
 		hashStr += "-" + labels[v1alpha1.ConfigHashLabel]
 		hashStr = files.Sha1ChecksumString(hashStr)
 	}
+	if build.Spec.Digest != "" {
+		hashStr = files.Sha1ChecksumString(hashStr + "-" + build.Spec.Digest)
+	}
 	name := fmt.Sprintf("%s-%s-%s", at.Spec.App,
 		build.CreationTimestamp.Format("20060102-1504"),
 		hashStr[:5])
@@ -454,6 +484,7 @@ func appReleaseForTarget(at *v1alpha1.AppTarget, build *v1alpha1.Build, configMa
 			App:           at.Spec.App,
 			Target:        at.Spec.Target,
 			Build:         build.Name,
+			Image:         build.ImageReference(),
 			Role:          v1alpha1.ReleaseRoleNone,
 			AppCommonSpec: at.Spec.AppCommonSpec,
 		},
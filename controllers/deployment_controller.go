@@ -18,6 +18,7 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 
 	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/go-logr/logr"
@@ -54,6 +55,7 @@ type DeploymentReconciler struct {
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules;servicemonitors;podmonitors,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=integreatly.org,resources=grafanadashboards,verbs=get;list;watch;create;update;patch;delete
 
 func (r *DeploymentReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err error) {
 	ctx := context.Background()
@@ -64,6 +66,7 @@ func (r *DeploymentReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err
 			// Request object not found, could have been deleted after reconcile request.
 			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
 			// Return and don't requeue
+			deleteAppTargetMetrics(req.Name)
 			return ctrl.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -84,6 +87,7 @@ func (r *DeploymentReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err
 	if err != nil {
 		return
 	}
+	updateAppTargetMetrics(at, releases)
 	if arRes != nil {
 		if arRes.Requeue {
 			res.Requeue = arRes.Requeue
@@ -132,6 +136,11 @@ func (r *DeploymentReconciler) Reconcile(req ctrl.Request) (res ctrl.Result, err
 		return
 	}
 
+	err = r.reconcileGrafanaDashboard(ctx, at)
+	if err != nil {
+		return
+	}
+
 	// update at status
 	if !apiequality.Semantic.DeepEqual(atStatus, at.Status) {
 		// reload apptarget and update status
@@ -225,6 +234,27 @@ func (r *DeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		}),
 	}
 
+	// builds that are pinned to a new digest roll out as a new release
+	buildWatcher := &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(object handler.MapObject) []ctrl.Request {
+			var requests []ctrl.Request
+			build := object.Object.(*v1alpha1.Build)
+			err := resources.ForEach(r.Client, &v1alpha1.AppTargetList{}, func(item interface{}) error {
+				at := item.(v1alpha1.AppTarget)
+				if at.Spec.Build == build.Name {
+					requests = append(requests, ctrl.Request{
+						NamespacedName: types.NamespacedName{Name: at.Name},
+					})
+				}
+				return nil
+			})
+			if err != nil {
+				r.Log.Error(err, "could not list appTargets in buildWatcher")
+			}
+			return requests
+		}),
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&v1alpha1.AppTarget{}).
 		Owns(&v1alpha1.AppRelease{}).
@@ -234,38 +264,15 @@ func (r *DeploymentReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&netv1beta1.Ingress{}).
 		Watches(&source.Kind{Type: &v1alpha1.CertificateRef{}}, certWatcher).
 		Watches(&source.Kind{Type: &v1alpha1.AppConfig{}}, configWatcher).
+		Watches(&source.Kind{Type: &v1alpha1.Build{}}, buildWatcher).
 		Complete(r)
 }
 
 func (r *DeploymentReconciler) reconcileConfigMap(ctx context.Context, at *v1alpha1.AppTarget) (configMap *corev1.ConfigMap, err error) {
-	// grab app release for this app
-	ac, err := resources.GetMergedConfigForType(r.Client, v1alpha1.ConfigTypeApp, at.Spec.App, at.Spec.Target)
-	if err != nil {
+	configMap, err = configMapForAppTarget(r.Client, r.Log, at)
+	if err != nil || configMap == nil {
 		return
 	}
-
-	// find other configmaps
-	sharedConfigs := make([]*v1alpha1.AppConfig, 0, len(at.Spec.Configs))
-	for _, config := range at.Spec.Configs {
-		sc, cErr := resources.GetMergedConfigForType(r.Client, v1alpha1.ConfigTypeShared, config, at.Spec.Target)
-		if cErr != nil {
-			// skip this config and continue
-			r.Log.Error(cErr, "Could not find shared config", "app", at.Spec.App,
-				"target", at.Spec.Target, "config", config)
-			continue
-		}
-		sharedConfigs = append(sharedConfigs, sc)
-	}
-
-	// check if existing configmap with the hash
-	if ac == nil && len(sharedConfigs) == 0 {
-		// no config maps needed
-		return
-	}
-	configMap = resources.CreateConfigMap(at.Spec.App, ac, sharedConfigs)
-	for key, val := range labelsForAppTarget(at) {
-		configMap.Labels[key] = val
-	}
 	_, err = resources.GetConfigMap(r.Client, at.TargetNamespace(), configMap.Name)
 	if errors.IsNotFound(err) {
 		r.Log.Info("Creating ConfigMap", "app", at.Spec.App, "target", at.Spec.Target)
@@ -320,15 +327,8 @@ func (r *DeploymentReconciler) reconcilePrometheusServiceMonitor(ctx context.Con
 }
 
 func (r *DeploymentReconciler) reconcilePrometheusRules(ctx context.Context, at *v1alpha1.AppTarget) error {
-	needsRules := true
-	prom := at.Spec.Prometheus
-	if prom == nil {
-		needsRules = false
-	} else if len(prom.Rules) == 0 {
-		needsRules = false
-	}
-
-	if !needsRules {
+	pr := newPromRuleForAppTarget(at)
+	if pr == nil {
 		// delete existing prometheus rule
 		existing := &promv1.PrometheusRule{}
 		err := r.Client.Get(ctx, client.ObjectKey{Namespace: at.TargetNamespace(), Name: at.Spec.App}, existing)
@@ -348,18 +348,54 @@ func (r *DeploymentReconciler) reconcilePrometheusRules(ctx context.Context, at
 	}
 
 	// create or update
-	pr := newPromRuleForAppTarget(at)
 	op, err := resources.UpdateResource(r.Client, pr, at, r.Scheme)
 	if err != nil {
 		return err
 	}
 
+	numRules := 0
+	for _, group := range pr.Spec.Groups {
+		numRules += len(group.Rules)
+	}
 	resources.LogUpdates(r.Log, op, "updated PrometheusRule",
-		"appTarget", at.Name, "numRules", len(pr.Spec.Groups[0].Rules))
+		"appTarget", at.Name, "numRules", numRules)
 
 	return nil
 }
 
+// configMapForAppTarget merges the app's config with the shared configs it uses. Returns nil when there are no configs
+func configMapForAppTarget(kclient client.Client, log logr.Logger, at *v1alpha1.AppTarget) (configMap *corev1.ConfigMap, err error) {
+	// grab app release for this app
+	ac, err := resources.GetMergedConfigForType(kclient, v1alpha1.ConfigTypeApp, at.Spec.App, at.Spec.Target)
+	if err != nil {
+		return
+	}
+
+	// find other configmaps
+	sharedConfigs := make([]*v1alpha1.AppConfig, 0, len(at.Spec.Configs))
+	for _, config := range at.Spec.Configs {
+		sc, cErr := resources.GetMergedConfigForType(kclient, v1alpha1.ConfigTypeShared, config, at.Spec.Target)
+		if cErr != nil {
+			// skip this config and continue
+			log.Error(cErr, "Could not find shared config", "app", at.Spec.App,
+				"target", at.Spec.Target, "config", config)
+			continue
+		}
+		sharedConfigs = append(sharedConfigs, sc)
+	}
+
+	// check if existing configmap with the hash
+	if ac == nil && len(sharedConfigs) == 0 {
+		// no config maps needed
+		return
+	}
+	configMap = resources.CreateConfigMap(at.Spec.App, ac, sharedConfigs)
+	for key, val := range labelsForAppTarget(at) {
+		configMap.Labels[key] = val
+	}
+	return
+}
+
 func newServiceMonitorForAppTarget(at *v1alpha1.AppTarget) *promv1.ServiceMonitor {
 	sm := &promv1.ServiceMonitor{
 		ObjectMeta: metav1.ObjectMeta{
@@ -383,20 +419,38 @@ func newServiceMonitorForAppTarget(at *v1alpha1.AppTarget) *promv1.ServiceMonito
 	return sm
 }
 
+// newPromRuleForAppTarget combines rules from the app with the ones generated for its alerts. Returns nil when
+// there are no rules
 func newPromRuleForAppTarget(at *v1alpha1.AppTarget) *promv1.PrometheusRule {
+	var groups []promv1.RuleGroup
+	if at.Spec.Prometheus != nil && len(at.Spec.Prometheus.Rules) > 0 {
+		groups = append(groups, promv1.RuleGroup{
+			Name:  at.Spec.App,
+			Rules: at.Spec.Prometheus.Rules,
+		})
+	}
+	if alertRules := alertRulesForAppTarget(at); len(alertRules) > 0 {
+		groups = append(groups, promv1.RuleGroup{
+			Name:  fmt.Sprintf("%s-alerts", at.Spec.App),
+			Rules: alertRules,
+		})
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+
+	// the cluster's Prometheus only loads rules with these labels
+	labels := labelsForAppTarget(at)
+	labels[prometheusName] = k8sName
+	labels["role"] = "alert-rules"
 	pr := &promv1.PrometheusRule{
 		ObjectMeta: metav1.ObjectMeta{
 			Namespace: at.TargetNamespace(),
 			Name:      at.Spec.App,
-			Labels:    labelsForAppTarget(at),
+			Labels:    labels,
 		},
 		Spec: promv1.PrometheusRuleSpec{
-			Groups: []promv1.RuleGroup{
-				{
-					Name:  at.Spec.App,
-					Rules: at.Spec.Prometheus.Rules,
-				},
-			},
+			Groups: groups,
 		},
 	}
 	return pr
@@ -0,0 +1,396 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/components/grafana"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+const (
+	// label that the Grafana instance selects dashboards with
+	grafanaDashboardLabel = "grafana"
+	grafanaDatasource     = "prometheus"
+	dashboardPanelWidth   = 12
+	dashboardPanelHeight  = 8
+)
+
+var grafanaDashboardGVK = schema.GroupVersionKind{
+	Group:   "integreatly.org",
+	Version: "v1alpha1",
+	Kind:    "GrafanaDashboard",
+}
+
+// reconcileGrafanaDashboard keeps the app's dashboard up to date. There's a single dashboard for all of the
+// targets of an app, owned by the App so it's removed along with it
+func (r *DeploymentReconciler) reconcileGrafanaDashboard(ctx context.Context, at *v1alpha1.AppTarget) error {
+	cc, err := resources.GetClusterConfig(r.Client)
+	if err != nil {
+		return err
+	}
+	if cc.GetComponentConfig(grafana.ComponentName) == nil {
+		// grafana isn't installed, nothing to do
+		return nil
+	}
+
+	app, err := resources.GetAppByName(r.Client, at.Spec.App)
+	if err != nil {
+		return err
+	}
+
+	var targets []string
+	for _, target := range app.Spec.Targets {
+		for _, clusterTarget := range cc.Spec.Targets {
+			if target.Name == clusterTarget {
+				targets = append(targets, target.Name)
+				break
+			}
+		}
+	}
+
+	dashboard, err := newGrafanaDashboardForApp(app, targets)
+	if err != nil {
+		return err
+	}
+
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(grafanaDashboardGVK)
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: dashboard.GetNamespace(), Name: dashboard.GetName()}, existing)
+	if errors.IsNotFound(err) {
+		if err = controllerutil.SetControllerReference(app, dashboard, r.Scheme); err != nil {
+			return err
+		}
+		r.Log.Info("Creating GrafanaDashboard", "app", app.Name)
+		return r.Client.Create(ctx, dashboard)
+	} else if err != nil {
+		return err
+	}
+
+	if apiequality.Semantic.DeepEqual(existing.Object["spec"], dashboard.Object["spec"]) &&
+		apiequality.Semantic.DeepEqual(existing.GetLabels(), dashboard.GetLabels()) {
+		return nil
+	}
+	existing.Object["spec"] = dashboard.Object["spec"]
+	existing.SetLabels(dashboard.GetLabels())
+	r.Log.Info("Updating GrafanaDashboard", "app", app.Name)
+	return r.Client.Update(ctx, existing)
+}
+
+func grafanaDashboardName(app string) string {
+	return fmt.Sprintf("kon-app-%s", app)
+}
+
+func newGrafanaDashboardForApp(app *v1alpha1.App, targets []string) (*unstructured.Unstructured, error) {
+	name := grafanaDashboardName(app.Name)
+	content, err := json.MarshalIndent(newAppDashboard(app, targets), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	dashboard := &unstructured.Unstructured{}
+	dashboard.SetGroupVersionKind(grafanaDashboardGVK)
+	dashboard.SetNamespace(resources.GrafanaNamespace)
+	dashboard.SetName(name)
+	dashboard.SetLabels(map[string]string{
+		resources.KubeAppLabel:       grafanaDashboardLabel,
+		resources.AppLabel:           app.Name,
+		resources.KubeManagedByLabel: resources.Konstellation,
+	})
+	dashboard.Object["spec"] = map[string]interface{}{
+		"name": name + ".json",
+		"json": string(content),
+	}
+	return dashboard, nil
+}
+
+type dashboard struct {
+	Title         string            `json:"title"`
+	Tags          []string          `json:"tags"`
+	Editable      bool              `json:"editable"`
+	Refresh       string            `json:"refresh"`
+	SchemaVersion int               `json:"schemaVersion"`
+	Time          map[string]string `json:"time"`
+	Templating    dashboardVarList  `json:"templating"`
+	Panels        []dashboardPanel  `json:"panels"`
+}
+
+type dashboardVarList struct {
+	List []dashboardVar `json:"list"`
+}
+
+type dashboardVar struct {
+	Name       string                   `json:"name"`
+	Label      string                   `json:"label,omitempty"`
+	Type       string                   `json:"type"`
+	Datasource string                   `json:"datasource,omitempty"`
+	Query      string                   `json:"query"`
+	Refresh    int                      `json:"refresh,omitempty"`
+	Multi      bool                     `json:"multi"`
+	IncludeAll bool                     `json:"includeAll"`
+	Current    map[string]interface{}   `json:"current,omitempty"`
+	Options    []map[string]interface{} `json:"options,omitempty"`
+}
+
+type dashboardPanel struct {
+	ID         int               `json:"id"`
+	Title      string            `json:"title"`
+	Type       string            `json:"type"`
+	Datasource string            `json:"datasource,omitempty"`
+	GridPos    dashboardGridPos  `json:"gridPos"`
+	Targets    []dashboardTarget `json:"targets,omitempty"`
+	Yaxes      []dashboardAxis   `json:"yaxes,omitempty"`
+	Lines      bool              `json:"lines,omitempty"`
+	Linewidth  int               `json:"linewidth,omitempty"`
+	Repeat     string            `json:"repeat,omitempty"`
+	MaxPerRow  int               `json:"maxPerRow,omitempty"`
+}
+
+type dashboardGridPos struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+	W int `json:"w"`
+	H int `json:"h"`
+}
+
+type dashboardTarget struct {
+	Expr         string `json:"expr"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	RefID        string `json:"refId"`
+}
+
+type dashboardAxis struct {
+	Format string `json:"format"`
+	Show   bool   `json:"show"`
+	Min    *int   `json:"min,omitempty"`
+}
+
+// dashboardBuilder lays out panels in rows of two
+type dashboardBuilder struct {
+	dashboard dashboard
+	nextID    int
+	x         int
+	y         int
+}
+
+func (b *dashboardBuilder) addRow(title string) {
+	if b.x != 0 {
+		b.x = 0
+		b.y += dashboardPanelHeight
+	}
+	b.nextID++
+	b.dashboard.Panels = append(b.dashboard.Panels, dashboardPanel{
+		ID:      b.nextID,
+		Title:   title,
+		Type:    "row",
+		GridPos: dashboardGridPos{X: 0, Y: b.y, W: 24, H: 1},
+	})
+	b.y++
+}
+
+func (b *dashboardBuilder) addGraph(format string, panel dashboardPanel) {
+	b.nextID++
+	panel.ID = b.nextID
+	panel.Type = "graph"
+	panel.Datasource = grafanaDatasource
+	panel.Lines = true
+	panel.Linewidth = 1
+	if panel.GridPos.W == 0 {
+		panel.GridPos.W = dashboardPanelWidth
+	}
+	if b.x+panel.GridPos.W > 24 {
+		b.x = 0
+		b.y += dashboardPanelHeight
+	}
+	panel.GridPos.X = b.x
+	panel.GridPos.Y = b.y
+	panel.GridPos.H = dashboardPanelHeight
+	for i := range panel.Targets {
+		panel.Targets[i].RefID = string(rune('A' + i))
+	}
+	zero := 0
+	panel.Yaxes = []dashboardAxis{
+		{Format: format, Show: true, Min: &zero},
+		{Format: "short", Show: false},
+	}
+	b.dashboard.Panels = append(b.dashboard.Panels, panel)
+	b.x += panel.GridPos.W
+}
+
+func (b *dashboardBuilder) addVar(v dashboardVar) {
+	b.dashboard.Templating.List = append(b.dashboard.Templating.List, v)
+}
+
+// newAppDashboard creates a dashboard with traffic from Istio, resource usage and replica counts of the app.
+// Releases show up as the version label in Istio metrics, which is set to the build of the release
+func newAppDashboard(app *v1alpha1.App, targets []string) *dashboard {
+	b := &dashboardBuilder{
+		dashboard: dashboard{
+			Title:         fmt.Sprintf("App: %s", app.Name),
+			Tags:          []string{resources.Konstellation, "app"},
+			Refresh:       "30s",
+			SchemaVersion: 22,
+			Time:          map[string]string{"from": "now-3h", "to": "now"},
+		},
+	}
+
+	targetVar := dashboardVar{
+		Name:       "target",
+		Label:      "Target",
+		Type:       "custom",
+		Query:      strings.Join(targets, ","),
+		Multi:      true,
+		IncludeAll: true,
+	}
+	for i, target := range targets {
+		option := map[string]interface{}{
+			"text":     target,
+			"value":    target,
+			"selected": i == 0,
+		}
+		targetVar.Options = append(targetVar.Options, option)
+		if i == 0 {
+			targetVar.Current = option
+		}
+	}
+	b.addVar(targetVar)
+
+	name := app.Name
+	istioSelector := fmt.Sprintf(`reporter="destination", destination_workload_namespace=~"$target", destination_app="%s"`, name)
+	containerSelector := fmt.Sprintf(`namespace=~"$target", container="%s"`, name)
+	replicaSetJoin := fmt.Sprintf(`* on(namespace, replicaset) group_left() kube_replicaset_labels{namespace=~"$target", label_k11n_dev_app="%s"}`, name)
+
+	if len(app.Spec.Ports) > 0 {
+		b.addRow("Traffic")
+		b.addGraph("reqps", dashboardPanel{
+			Title: "Request rate",
+			Targets: []dashboardTarget{
+				{
+					Expr:         fmt.Sprintf(`sum(rate(istio_requests_total{%s}[1m])) by (k11n_dev_appRelease)`, istioSelector),
+					LegendFormat: "{{k11n_dev_appRelease}}",
+				},
+			},
+		})
+		b.addGraph("percentunit", dashboardPanel{
+			Title: "Error rate (5xx)",
+			Targets: []dashboardTarget{
+				{
+					Expr: fmt.Sprintf(`sum(rate(istio_requests_total{%s, response_code=~"5.."}[1m])) by (k11n_dev_appRelease) / sum(rate(istio_requests_total{%s}[1m])) by (k11n_dev_appRelease)`,
+						istioSelector, istioSelector),
+					LegendFormat: "{{k11n_dev_appRelease}}",
+				},
+			},
+		})
+		latencyPanel := dashboardPanel{
+			Title: "Latency",
+			GridPos: dashboardGridPos{
+				W: 24,
+			},
+		}
+		for _, quantile := range []string{"0.50", "0.95", "0.99"} {
+			latencyPanel.Targets = append(latencyPanel.Targets, dashboardTarget{
+				Expr: fmt.Sprintf(`histogram_quantile(%s, sum(rate(istio_request_duration_milliseconds_bucket{%s}[1m])) by (k11n_dev_appRelease, le))`,
+					quantile, istioSelector),
+				LegendFormat: fmt.Sprintf("p%s {{k11n_dev_appRelease}}", strings.TrimPrefix(quantile, "0.")),
+			})
+		}
+		b.addGraph("ms", latencyPanel)
+	}
+
+	b.addRow("Resources")
+	b.addGraph("short", dashboardPanel{
+		Title: "CPU",
+		Targets: []dashboardTarget{
+			{
+				Expr:         fmt.Sprintf(`sum(rate(container_cpu_usage_seconds_total{%s}[5m])) by (pod)`, containerSelector),
+				LegendFormat: "{{pod}}",
+			},
+			{
+				Expr:         fmt.Sprintf(`max(kube_pod_container_resource_requests_cpu_cores{%s})`, containerSelector),
+				LegendFormat: "requested",
+			},
+		},
+	})
+	b.addGraph("bytes", dashboardPanel{
+		Title: "Memory",
+		Targets: []dashboardTarget{
+			{
+				Expr:         fmt.Sprintf(`sum(container_memory_working_set_bytes{%s}) by (pod)`, containerSelector),
+				LegendFormat: "{{pod}}",
+			},
+			{
+				Expr:         fmt.Sprintf(`max(kube_pod_container_resource_requests_memory_bytes{%s})`, containerSelector),
+				LegendFormat: "requested",
+			},
+		},
+	})
+
+	b.addRow("Replicas")
+	b.addGraph("short", dashboardPanel{
+		Title: "Replicas by release",
+		GridPos: dashboardGridPos{
+			W: 24,
+		},
+		Targets: []dashboardTarget{
+			{
+				Expr:         fmt.Sprintf(`sum(kube_replicaset_spec_replicas %s) by (replicaset)`, replicaSetJoin),
+				LegendFormat: "desired {{replicaset}}",
+			},
+			{
+				Expr:         fmt.Sprintf(`sum(kube_replicaset_status_ready_replicas %s) by (replicaset)`, replicaSetJoin),
+				LegendFormat: "ready {{replicaset}}",
+			},
+		},
+	})
+
+	// metrics that the app exposes aren't known ahead of time, each endpoint gets a variable to pick them
+	if app.Spec.Prometheus != nil {
+		for i, endpoint := range app.Spec.Prometheus.Endpoints {
+			port := endpoint.Port
+			if port == "" && endpoint.TargetPort != nil {
+				port = endpoint.TargetPort.String()
+			}
+			selector := fmt.Sprintf(`namespace=~"$target", service="%s"`, name)
+			if port != "" {
+				selector = fmt.Sprintf(`%s, endpoint="%s"`, selector, port)
+			}
+			varName := fmt.Sprintf("metrics_%d", i)
+
+			b.addRow(fmt.Sprintf("App metrics (%s)", port))
+			b.addVar(dashboardVar{
+				Name:       varName,
+				Label:      fmt.Sprintf("Metrics (%s)", port),
+				Type:       "query",
+				Datasource: grafanaDatasource,
+				Query:      fmt.Sprintf(`label_values({%s}, __name__)`, selector),
+				// refresh when the dashboard loads
+				Refresh: 1,
+				Multi:   true,
+			})
+			b.addGraph("short", dashboardPanel{
+				Title: fmt.Sprintf("$%s", varName),
+				// one panel per selected metric
+				Repeat:    varName,
+				MaxPerRow: 2,
+				Targets: []dashboardTarget{
+					{
+						Expr:         fmt.Sprintf(`{__name__="$%s", %s}`, varName, selector),
+						LegendFormat: "{{pod}}",
+					},
+				},
+			})
+		}
+	}
+
+	return &b.dashboard
+}
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/components/grafana"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func TestNewAppDashboard(t *testing.T) {
+	app := &v1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp"},
+		Spec: v1alpha1.AppSpec{
+			AppCommonSpec: v1alpha1.AppCommonSpec{
+				Ports: []v1alpha1.PortSpec{{Name: "http", Port: 80}},
+			},
+			Prometheus: &v1alpha1.PrometheusSpec{
+				Endpoints: []promv1.Endpoint{{Port: "http"}},
+			},
+		},
+	}
+
+	d := newAppDashboard(app, []string{"production", "staging"})
+	assert.Equal(t, "production,staging", d.Templating.List[0].Query)
+	assert.Equal(t, "production", d.Templating.List[0].Current["value"])
+	assert.Len(t, d.Templating.List, 2)
+
+	var titles []string
+	for _, panel := range d.Panels {
+		titles = append(titles, panel.Title)
+		if panel.Type == "graph" {
+			assert.NotEmpty(t, panel.Targets)
+		}
+	}
+	assert.Equal(t, []string{"Traffic", "Request rate", "Error rate (5xx)", "Latency", "Resources", "CPU", "Memory",
+		"Replicas", "Replicas by release", "App metrics (http)", "$metrics_0"}, titles)
+	assert.Contains(t, d.Panels[1].Targets[0].Expr, `destination_app="myapp"`)
+	assert.Equal(t, "metrics_0", d.Panels[10].Repeat)
+	assert.Contains(t, d.Panels[10].Targets[0].Expr, `endpoint="http"`)
+
+	// panels shouldn't overlap
+	assert.Equal(t, 12, d.Panels[2].GridPos.X)
+	assert.Equal(t, d.Panels[1].GridPos.Y, d.Panels[2].GridPos.Y)
+	assert.Equal(t, d.Panels[2].GridPos.Y+dashboardPanelHeight, d.Panels[3].GridPos.Y)
+	assert.Equal(t, d.Panels[3].GridPos.Y+dashboardPanelHeight, d.Panels[4].GridPos.Y)
+
+	// no traffic panels without a service
+	app.Spec.Ports = nil
+	app.Spec.Prometheus = nil
+	d = newAppDashboard(app, []string{"production"})
+	assert.Equal(t, "Resources", d.Panels[0].Title)
+}
+
+func TestReconcileGrafanaDashboard(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	cc := &v1alpha1.ClusterConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec:       v1alpha1.ClusterConfigSpec{Targets: []string{"production"}},
+	}
+	app := &v1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp", UID: "myapp-uid"},
+		Spec: v1alpha1.AppSpec{
+			Targets: []v1alpha1.TargetConfig{{Name: "production"}, {Name: "development"}},
+		},
+	}
+	at := &v1alpha1.AppTarget{
+		Spec: v1alpha1.AppTargetSpec{App: "myapp", Target: "production"},
+	}
+	kclient := fake.NewFakeClientWithScheme(scheme, cc, app)
+	r := &DeploymentReconciler{Client: kclient, Log: ctrl.Log.WithName("test"), Scheme: scheme}
+	key := client.ObjectKey{Namespace: resources.GrafanaNamespace, Name: "kon-app-myapp"}
+
+	// skipped until grafana is installed
+	assert.NoError(t, r.reconcileGrafanaDashboard(context.TODO(), at))
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(grafanaDashboardGVK)
+	assert.Error(t, kclient.Get(context.TODO(), key, obj))
+
+	cc.Status.InstalledComponents = []v1alpha1.ComponentSpec{{Name: grafana.ComponentName}}
+	assert.NoError(t, kclient.Update(context.TODO(), cc))
+	assert.NoError(t, r.reconcileGrafanaDashboard(context.TODO(), at))
+	assert.NoError(t, kclient.Get(context.TODO(), key, obj))
+	assert.Equal(t, "grafana", obj.GetLabels()["app"])
+	assert.Equal(t, "myapp", obj.GetOwnerReferences()[0].Name)
+
+	content, _, _ := unstructured.NestedString(obj.Object, "spec", "json")
+	d := &dashboard{}
+	assert.NoError(t, json.Unmarshal([]byte(content), d))
+	// only targets that the cluster has
+	assert.Equal(t, "production", d.Templating.List[0].Query)
+
+	// targets changed
+	cc.Spec.Targets = append(cc.Spec.Targets, "development")
+	assert.NoError(t, kclient.Update(context.TODO(), cc))
+	assert.NoError(t, r.reconcileGrafanaDashboard(context.TODO(), at))
+	assert.NoError(t, kclient.Get(context.TODO(), key, obj))
+	content, _, _ = unstructured.NestedString(obj.Object, "spec", "json")
+	assert.NoError(t, json.Unmarshal([]byte(content), d))
+	assert.Equal(t, "production,development", d.Templating.List[0].Query)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+// Metrics about deploys, exposed on the manager's metrics endpoint. Failed reconciles are already counted by
+// controller-runtime, as controller_runtime_reconcile_errors_total
+const (
+	metricsNamespace = "kon"
+
+	releaseReasonInitial = "initial"
+	releaseReasonBuild   = "build"
+	releaseReasonImage   = "image"
+	releaseReasonConfig  = "config"
+	releaseReasonSpec    = "spec"
+)
+
+var (
+	deployDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "deploy_duration_seconds",
+		Help:      "Time from a release being created to it becoming the active release",
+		Buckets:   []float64{30, 60, 120, 300, 600, 900, 1800, 3600},
+	}, []string{"app", "target"})
+
+	// a timestamp rather than the elapsed time, which would only be updated when the target is reconciled
+	deployStartedTime = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "apptarget_deploy_started_timestamp_seconds",
+		Help:      "Unix time that the current deploy started at, only present while the target is deploying",
+	}, []string{"app", "target"})
+
+	releasesByState = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "releases",
+		Help:      "Number of releases in each state",
+	}, []string{"app", "target", "state"})
+
+	releaseTraffic = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "release_traffic_percentage",
+		Help:      "Percentage of traffic routed to each release",
+	}, []string{"app", "target", "release"})
+
+	releasesCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "releases_created_total",
+		Help:      "Releases created, by whether the build, its image digest, the config, or the app's spec has changed",
+	}, []string{"app", "target", "reason"})
+
+	buildsCreated = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "builds_created_total",
+		Help:      "Builds created for apps",
+	}, []string{"app"})
+
+	rollbacks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rollbacks_total",
+		Help:      "Releases that have been marked as bad",
+	}, []string{"app", "target"})
+
+	releaseStates = []v1alpha1.ReleaseState{
+		v1alpha1.ReleaseStateNew,
+		v1alpha1.ReleaseStateCanarying,
+		v1alpha1.ReleaseStateReleasing,
+		v1alpha1.ReleaseStateReleased,
+		v1alpha1.ReleaseStateRetiring,
+		v1alpha1.ReleaseStateRetired,
+		v1alpha1.ReleaseStateFailed,
+		v1alpha1.ReleaseStateBad,
+		v1alpha1.ReleaseStateHalted,
+	}
+
+	// label values for each AppTarget, so that series can be removed once releases or targets are gone
+	targetMetricsLock sync.Mutex
+	targetMetrics     = make(map[string]*appTargetMetrics)
+)
+
+type appTargetMetrics struct {
+	app      string
+	target   string
+	releases map[string]bool
+}
+
+func init() {
+	metrics.Registry.MustRegister(
+		deployDuration,
+		deployStartedTime,
+		releasesByState,
+		releaseTraffic,
+		releasesCreated,
+		buildsCreated,
+		rollbacks,
+	)
+}
+
+// updateAppTargetMetrics sets gauges from the current releases of an AppTarget
+func updateAppTargetMetrics(at *v1alpha1.AppTarget, releases []*v1alpha1.AppRelease) {
+	targetMetricsLock.Lock()
+	defer targetMetricsLock.Unlock()
+
+	app, target := at.Spec.App, at.Spec.Target
+	tm := targetMetrics[at.Name]
+	if tm == nil {
+		tm = &appTargetMetrics{app: app, target: target}
+		targetMetrics[at.Name] = tm
+	}
+
+	counts := make(map[v1alpha1.ReleaseState]int)
+	current := make(map[string]bool)
+	var targetRelease *v1alpha1.AppRelease
+	for _, ar := range releases {
+		counts[ar.Status.State] += 1
+		current[ar.Name] = true
+		releaseTraffic.WithLabelValues(app, target, ar.Name).Set(float64(ar.Spec.TrafficPercentage))
+		if ar.Name == at.Status.TargetRelease {
+			targetRelease = ar
+		}
+	}
+	for name := range tm.releases {
+		if !current[name] {
+			releaseTraffic.DeleteLabelValues(app, target, name)
+		}
+	}
+	tm.releases = current
+
+	for _, state := range releaseStates {
+		releasesByState.WithLabelValues(app, target, string(state)).Set(float64(counts[state]))
+	}
+
+	if at.Status.Phase == v1alpha1.AppTargetPhaseDeploying && targetRelease != nil && !targetRelease.CreationTimestamp.IsZero() {
+		deployStartedTime.WithLabelValues(app, target).Set(float64(targetRelease.CreationTimestamp.Unix()))
+	} else {
+		deployStartedTime.DeleteLabelValues(app, target)
+	}
+}
+
+// deleteAppTargetMetrics removes series for an AppTarget that has been deleted
+func deleteAppTargetMetrics(name string) {
+	targetMetricsLock.Lock()
+	defer targetMetricsLock.Unlock()
+
+	tm := targetMetrics[name]
+	if tm == nil {
+		return
+	}
+	for release := range tm.releases {
+		releaseTraffic.DeleteLabelValues(tm.app, tm.target, release)
+	}
+	for _, state := range releaseStates {
+		releasesByState.DeleteLabelValues(tm.app, tm.target, string(state))
+	}
+	deployStartedTime.DeleteLabelValues(tm.app, tm.target)
+	delete(targetMetrics, name)
+}
+
+// newReleaseReasonFor tells what has changed since the latest release, releases must be sorted by latest first
+func newReleaseReasonFor(releases []*v1alpha1.AppRelease, build *v1alpha1.Build, configMap *corev1.ConfigMap) string {
+	if len(releases) == 0 {
+		return releaseReasonInitial
+	}
+	latest := releases[0]
+	if latest.Spec.Build != build.Name {
+		return releaseReasonBuild
+	}
+	if latest.Spec.Image != "" && latest.Spec.Image != build.ImageReference() {
+		return releaseReasonImage
+	}
+	configName := ""
+	if configMap != nil {
+		configName = configMap.Name
+	}
+	if latest.Spec.Config != configName {
+		return releaseReasonConfig
+	}
+	return releaseReasonSpec
+}
@@ -0,0 +1,77 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestUpdateAppTargetMetrics(t *testing.T) {
+	at := &v1alpha1.AppTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "metricsapp-production"},
+		Spec:       v1alpha1.AppTargetSpec{App: "metricsapp", Target: "production"},
+		Status: v1alpha1.AppTargetStatus{
+			Phase:         v1alpha1.AppTargetPhaseDeploying,
+			TargetRelease: "metricsapp-2",
+		},
+	}
+	newRelease := func(name string, state v1alpha1.ReleaseState, traffic int32, age time.Duration) *v1alpha1.AppRelease {
+		return &v1alpha1.AppRelease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+			},
+			Spec:   v1alpha1.AppReleaseSpec{TrafficPercentage: traffic},
+			Status: v1alpha1.AppReleaseStatus{State: state},
+		}
+	}
+	releases := []*v1alpha1.AppRelease{
+		newRelease("metricsapp-2", v1alpha1.ReleaseStateReleasing, 25, 2*time.Minute),
+		newRelease("metricsapp-1", v1alpha1.ReleaseStateReleased, 75, time.Hour),
+	}
+
+	updateAppTargetMetrics(at, releases)
+	assert.Equal(t, 1.0, testutil.ToFloat64(releasesByState.WithLabelValues("metricsapp", "production", "releasing")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(releasesByState.WithLabelValues("metricsapp", "production", "bad")))
+	assert.Equal(t, 75.0, testutil.ToFloat64(releaseTraffic.WithLabelValues("metricsapp", "production", "metricsapp-1")))
+	assert.Equal(t, float64(releases[0].CreationTimestamp.Unix()),
+		testutil.ToFloat64(deployStartedTime.WithLabelValues("metricsapp", "production")))
+
+	// finished deploying, old release is gone
+	at.Status.Phase = v1alpha1.AppTargetPhaseRunning
+	releases = releases[:1]
+	releases[0].Spec.TrafficPercentage = 100
+	updateAppTargetMetrics(at, releases)
+	assert.Equal(t, 0, testutil.CollectAndCount(deployStartedTime))
+	assert.Equal(t, 1, testutil.CollectAndCount(releaseTraffic))
+
+	deleteAppTargetMetrics(at.Name)
+	assert.Equal(t, 0, testutil.CollectAndCount(releaseTraffic))
+	assert.Equal(t, 0, testutil.CollectAndCount(releasesByState))
+}
+
+func TestNewReleaseReasonFor(t *testing.T) {
+	build := &v1alpha1.Build{ObjectMeta: metav1.ObjectMeta{Name: "build-2"}}
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config-2"}}
+	latest := &v1alpha1.AppRelease{
+		Spec: v1alpha1.AppReleaseSpec{Build: "build-1", Config: "config-1"},
+	}
+	releases := []*v1alpha1.AppRelease{latest}
+
+	assert.Equal(t, releaseReasonInitial, newReleaseReasonFor(nil, build, configMap))
+	assert.Equal(t, releaseReasonBuild, newReleaseReasonFor(releases, build, configMap))
+	latest.Spec.Build = "build-2"
+	assert.Equal(t, releaseReasonConfig, newReleaseReasonFor(releases, build, configMap))
+	latest.Spec.Config = "config-2"
+	assert.Equal(t, releaseReasonSpec, newReleaseReasonFor(releases, build, configMap))
+	latest.Spec.Config = ""
+	assert.Equal(t, releaseReasonSpec, newReleaseReasonFor(releases, build, nil))
+	latest.Spec.Image = "myapp@sha256:aaaa"
+	assert.Equal(t, releaseReasonImage, newReleaseReasonFor(releases, build, nil))
+}
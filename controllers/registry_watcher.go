@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/registry"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+const (
+	registryWatchTick = 10 * time.Second
+	// how long to wait before fetching an image config that failed again
+	imageConfigRetryInterval = 30 * time.Minute
+)
+
+// RegistryWatcher polls image registries for apps with an ImageWatch, and creates a Build when a new matching tag
+// is found. BuildReconciler then moves the latest label and updates the apps
+type RegistryWatcher struct {
+	client.Client
+	// uncached reader, used to fetch image pull secrets without caching every secret in the cluster
+	Reader             client.Reader
+	Log                logr.Logger
+	Interval           time.Duration
+	InsecureRegistries []string
+
+	lastPolled map[string]time.Time
+	// image configs by registry/repository:tag, avoids fetching them on every poll
+	configCache map[string]*registry.ImageConfig
+	// when fetching an image config last failed, by the same key
+	configFailures map[string]time.Time
+}
+
+// image pull secrets live in the namespaces of app targets, reading them is the only secrets access outside of kon-system
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get
+
+func (w *RegistryWatcher) Start(stop <-chan struct{}) error {
+	w.lastPolled = make(map[string]time.Time)
+	w.configCache = make(map[string]*registry.ImageConfig)
+	w.configFailures = make(map[string]time.Time)
+	w.Log.Info("Starting registry watcher", "interval", w.Interval)
+
+	ticker := time.NewTicker(registryWatchTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			w.pollApps()
+		}
+	}
+}
+
+func (w *RegistryWatcher) pollApps() {
+	apps, err := resources.ListApps(w.Client)
+	if err != nil {
+		w.Log.Error(err, "Could not list apps")
+		return
+	}
+
+	now := time.Now()
+	for i := range apps {
+		app := &apps[i]
+		if app.Spec.ImageWatch == nil {
+			delete(w.lastPolled, app.Name)
+			continue
+		}
+
+		interval := w.Interval
+		if app.Spec.ImageWatch.IntervalSeconds > 0 {
+			interval = time.Duration(app.Spec.ImageWatch.IntervalSeconds) * time.Second
+		}
+		if now.Sub(w.lastPolled[app.Name]) < interval {
+			continue
+		}
+		w.lastPolled[app.Name] = now
+
+		if err := w.pollApp(app); err != nil {
+			w.Log.Error(err, "Could not check registry for new tags", "app", app.Name,
+				"image", app.Spec.Image)
+		}
+	}
+}
+
+func (w *RegistryWatcher) pollApp(app *v1alpha1.App) error {
+	watch := app.Spec.ImageWatch
+	filter, err := registry.NewTagFilter(string(watch.Strategy), watch.Pattern)
+	if err != nil {
+		return err
+	}
+
+	creds, err := resources.GetImagePullCredentials(w.Reader, app)
+	if err != nil {
+		w.Log.Error(err, "Could not read image pull secrets", "app", app.Name)
+	}
+	rc := registry.NewClient(creds, w.InsecureRegistries...)
+	repository := registry.NormalizeRepository(app.Spec.Registry, app.Spec.Image)
+	tags, err := rc.ListTags(app.Spec.Registry, repository)
+	if err != nil {
+		return err
+	}
+	tags = filter.Filter(tags)
+
+	var tag string
+	if watch.Strategy == v1alpha1.TagStrategySemver {
+		tag = registry.LatestSemverTag(tags)
+	} else {
+		// only tags that haven't been seen before are fetched, drop the ones that are gone
+		w.pruneImageConfigs(app.Spec.Registry, repository, tags)
+		var newest time.Time
+		for _, t := range tags {
+			config, err := w.imageConfig(rc, app.Spec.Registry, repository, t)
+			if err != nil {
+				w.Log.Error(err, "Could not get image config", "image", app.Spec.Image, "tag", t)
+				continue
+			}
+			if tag == "" || config.Created.After(newest) {
+				tag = t
+				newest = config.Created
+			}
+		}
+	}
+	if tag == "" {
+		return nil
+	}
+
+	build := v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, tag)
+	existing := &v1alpha1.Build{}
+	err = w.Client.Get(context.TODO(), types.NamespacedName{Name: build.Name}, existing)
+	if err == nil {
+		// already known
+		return nil
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	created := time.Now()
+	if config, err := w.imageConfig(rc, app.Spec.Registry, repository, tag); err == nil {
+		if !config.Created.IsZero() {
+			created = config.Created
+		}
+		build.Spec.Source = resources.BuildSourceFromLabels(config.Labels)
+	}
+	if digest, err := rc.GetDigest(app.Spec.Registry, repository, tag); err == nil {
+		build.Spec.Digest = digest
+	}
+	build.Spec.CreatedAt = metav1.Timestamp{Seconds: created.Unix()}
+	build.Labels = resources.LabelsForBuild(build)
+	build.Labels[resources.BuildTypeLabel] = resources.BuildTypeLatest
+	if err = w.Client.Create(context.TODO(), build); err != nil {
+		return err
+	}
+	w.Log.Info("Created build for new image tag", "app", app.Name, "build", build.Name, "tag", tag)
+	return nil
+}
+
+func (w *RegistryWatcher) imageConfig(rc *registry.Client, reg, repository, tag string) (*registry.ImageConfig, error) {
+	key := imageConfigKey(reg, repository, tag)
+	if config, ok := w.configCache[key]; ok {
+		return config, nil
+	}
+	if failedAt, ok := w.configFailures[key]; ok && time.Since(failedAt) < imageConfigRetryInterval {
+		return nil, fmt.Errorf("fetching image config failed at %s, will retry later", failedAt.Format(time.RFC3339))
+	}
+	config, err := rc.GetImageConfig(reg, repository, tag)
+	if err != nil {
+		w.configFailures[key] = time.Now()
+		return nil, err
+	}
+	delete(w.configFailures, key)
+	w.configCache[key] = config
+	return config, nil
+}
+
+// pruneImageConfigs removes cached configs of tags that are no longer in the repository
+func (w *RegistryWatcher) pruneImageConfigs(reg, repository string, tags []string) {
+	current := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		current[imageConfigKey(reg, repository, tag)] = true
+	}
+	prefix := imageConfigKey(reg, repository, "")
+	for key := range w.configFailures {
+		if strings.HasPrefix(key, prefix) && !current[key] {
+			delete(w.configFailures, key)
+		}
+	}
+	for key := range w.configCache {
+		if strings.HasPrefix(key, prefix) && !current[key] {
+			delete(w.configCache, key)
+		}
+	}
+}
+
+func imageConfigKey(reg, repository, tag string) string {
+	return registry.NormalizeRegistry(reg) + "/" + repository + ":" + tag
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// AppRenderer builds the resources that the controllers would create for an app target, as they'd be once
+// the app is fully deployed. Nothing is saved, Client is only used to look up the cluster config, configs,
+// builds, certificates and dependencies
+type AppRenderer struct {
+	Client client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// leave out environment variables for dependencies, when they can't be looked up
+	SkipDependencies bool
+	// include AppTarget and AppRelease, which the controllers use to create the other resources
+	IncludeIntermediate bool
+}
+
+func (r *AppRenderer) Render(app *v1alpha1.App, target string) ([]runtime.Object, error) {
+	if app.Spec.GetTargetConfig(target) == nil {
+		return nil, fmt.Errorf("app %s does not have target %s", app.Name, target)
+	}
+
+	cc, err := resources.GetClusterConfig(r.Client)
+	if err != nil {
+		return nil, err
+	}
+	// defaults are normally set by the webhook when the app is saved
+	app = app.DeepCopy()
+	app.SetDefaults(cc.Spec.AppDefaults)
+
+	// release names include the build's creation time, use the existing build when there's one
+	build := v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, app.Spec.ImageTag)
+	build.Labels = resources.LabelsForBuild(build)
+	existingBuild, err := resources.GetBuildByName(r.Client, build.Name)
+	if err == nil {
+		build = existingBuild
+	} else if errors.IsNotFound(err) {
+		build.CreationTimestamp = metav1.Now()
+	} else {
+		return nil, err
+	}
+
+	at := newAppTargetForApp(app, target, build)
+	if err = at.UpdateHash(); err != nil {
+		return nil, err
+	}
+
+	var objs []runtime.Object
+	configMap, err := configMapForAppTarget(r.Client, r.Log, at)
+	if err != nil {
+		return nil, err
+	}
+	if configMap != nil {
+		configMap.Namespace = at.TargetNamespace()
+		objs = append(objs, configMap)
+	}
+
+	// the release after it has been fully rolled out
+	ar := appReleaseForTarget(at, build, configMap)
+	ar.Spec.Role = v1alpha1.ReleaseRoleActive
+	ar.Spec.TrafficPercentage = 100
+	ar.Spec.NumDesired = at.DesiredInstances()
+	ar.Labels[resources.TargetReleaseLabel] = "1"
+	if r.SkipDependencies {
+		ar.Spec.Dependencies = nil
+	}
+	if r.IncludeIntermediate {
+		objs = append(objs, at, ar)
+	}
+
+	arReconciler := &AppReleaseReconciler{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
+	rs, err := arReconciler.newReplicaSetForAR(ar, build, configMap)
+	if err != nil {
+		return nil, err
+	}
+	objs = append(objs, rs)
+
+	if at.NeedsAutoscaler() {
+		objs = append(objs, newAutoscalerForAppTarget(at, ar))
+	}
+
+	if at.NeedsService() {
+		service := newServiceForAppTarget(at)
+		releases := []*v1alpha1.AppRelease{ar}
+		objs = append(objs, service, newDestinationRule(at, service, releases), newVirtualService(at, service, releases))
+		if at.Spec.Prometheus != nil && len(at.Spec.Prometheus.Endpoints) > 0 {
+			objs = append(objs, newServiceMonitorForAppTarget(at))
+		}
+	}
+	if pr := newPromRuleForAppTarget(at); pr != nil {
+		objs = append(objs, pr)
+	}
+
+	if at.NeedsIngress() {
+		dr := &DeploymentReconciler{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
+		in, err := dr.ingressForAppTarget(at)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, in)
+	}
+
+	// builders leave out the type, which is needed when the objects are printed
+	for _, obj := range objs {
+		gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return objs, nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"testing"
+
+	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	istio "istio.io/client-go/pkg/apis/networking/v1beta1"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscale "k8s.io/api/autoscaling/v2beta2"
+	corev1 "k8s.io/api/core/v1"
+	netv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func TestRenderApp(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	assert.NoError(t, istio.AddToScheme(scheme))
+	assert.NoError(t, promv1.AddToScheme(scheme))
+
+	config := v1alpha1.NewAppConfig("myapp", "")
+	assert.NoError(t, config.SetConfigYAML([]byte("key: value\n")))
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.ClusterConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec:       v1alpha1.ClusterConfigSpec{Cloud: "aws", Targets: []string{"production"}},
+		},
+		&corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Namespace: resources.IstioNamespace, Name: resources.IngressBackendName},
+		},
+		config,
+	)
+
+	app := &v1alpha1.App{
+		ObjectMeta: metav1.ObjectMeta{Name: "myapp"},
+		Spec: v1alpha1.AppSpec{
+			Image:    "myapp",
+			ImageTag: "v1",
+			AppCommonSpec: v1alpha1.AppCommonSpec{
+				Ports:        []v1alpha1.PortSpec{{Name: "http", Port: 80}},
+				Dependencies: []v1alpha1.AppReference{{Name: "backend"}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+				},
+			},
+			Scale: v1alpha1.ScaleSpec{Min: 2, Max: 4, TargetCPUUtilization: 60},
+			Targets: []v1alpha1.TargetConfig{
+				{Name: "production", Ingress: &v1alpha1.IngressConfig{Hosts: []string{"myapp.com"}}},
+			},
+		},
+	}
+	renderer := &AppRenderer{
+		Client:           kclient,
+		Log:              ctrl.Log.WithName("test"),
+		Scheme:           scheme,
+		SkipDependencies: true,
+	}
+
+	_, err := renderer.Render(app, "staging")
+	assert.Error(t, err)
+
+	objs, err := renderer.Render(app, "production")
+	assert.NoError(t, err)
+
+	var kinds []string
+	for _, obj := range objs {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind)
+	}
+	assert.Equal(t, []string{"ConfigMap", "ReplicaSet", "HorizontalPodAutoscaler", "Service",
+		"DestinationRule", "VirtualService", "Ingress"}, kinds)
+
+	rs := objs[1].(*appsv1.ReplicaSet)
+	assert.Equal(t, "production", rs.Namespace)
+	assert.Equal(t, int32(2), *rs.Spec.Replicas)
+	assert.Equal(t, "myapp:v1", rs.Spec.Template.Spec.Containers[0].Image)
+	assert.Contains(t, rs.Spec.Template.Spec.Containers[0].Env, corev1.EnvVar{Name: "KEY", Value: "value"})
+	// app doesn't set probes, webhook defaults still apply
+	assert.Equal(t, corev1.ProtocolTCP, rs.Spec.Template.Spec.Containers[0].Ports[0].Protocol)
+
+	assert.Equal(t, rs.Name, objs[2].(*autoscale.HorizontalPodAutoscaler).Spec.ScaleTargetRef.Name)
+	vs := objs[5].(*istio.VirtualService)
+	assert.Equal(t, int32(100), vs.Spec.Http[0].Route[0].Weight)
+	assert.Equal(t, rs.Name, vs.Spec.Http[0].Route[0].Destination.Subset)
+	assert.Equal(t, "myapp-production", objs[6].(*netv1beta1.Ingress).Name)
+
+	// dependencies need to exist
+	renderer.SkipDependencies = false
+	_, err = renderer.Render(app, "production")
+	assert.Error(t, err)
+}
@@ -13,11 +13,14 @@ require (
 	github.com/imdario/mergo v0.3.10
 	github.com/manifoldco/promptui v0.7.0
 	github.com/mitchellh/hashstructure v1.0.0
+	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/olekukonko/tablewriter v0.0.4
 	github.com/onsi/ginkgo v1.11.0
 	github.com/onsi/gomega v1.8.1
 	github.com/pkg/browser v0.0.0-20180916011732-0a3d74bf9ce4
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.6.0
+	github.com/prometheus/common v0.10.0
 	github.com/spf13/cast v1.3.0
 	github.com/stretchr/testify v1.5.1
 	github.com/thoas/go-funk v0.7.0
@@ -32,6 +35,7 @@ require (
 	k8s.io/metrics v0.18.2
 	k8s.io/utils v0.0.0-20200720150651-0bdb4ca86cbc
 	sigs.k8s.io/controller-runtime v0.6.0
+	sigs.k8s.io/yaml v1.2.0
 )
 
 replace k8s.io/client-go => k8s.io/client-go v0.18.2
@@ -19,6 +19,8 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
+	"time"
 
 	promv1 "github.com/coreos/prometheus-operator/pkg/apis/monitoring/v1"
 	istiov1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
@@ -28,10 +30,15 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/gcp"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/api/v1beta1"
 	"github.com/k11n/konstellation/controllers"
+	"github.com/k11n/konstellation/pkg/deployhook"
+	"github.com/k11n/konstellation/pkg/resources"
+	"github.com/k11n/konstellation/pkg/webhooks"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -44,6 +51,7 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(v1alpha1.AddToScheme(scheme))
+	utilruntime.Must(v1beta1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 
 	// Istio scheme
@@ -57,18 +65,49 @@ func init() {
 func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
+	var registryPollInterval time.Duration
+	var insecureRegistries string
+	var buildGCInterval time.Duration
+	var buildRetention time.Duration
+	var buildsToKeep int
+	var deployWebhookAddr string
+	var enableWebhooks bool
+	var webhookCertDir string
 	flag.StringVar(&metricsAddr, "metrics-addr", ":8080", "The address the metric endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "enable-leader-election", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.DurationVar(&registryPollInterval, "registry-poll-interval", time.Minute,
+		"How often registries are checked for new tags of apps with an imageWatch. Set to 0 to disable.")
+	flag.StringVar(&insecureRegistries, "insecure-registries", "",
+		"Comma separated list of registries that should be reached over http.")
+	flag.DurationVar(&buildGCInterval, "build-gc-interval", time.Hour,
+		"How often unused builds are deleted. Set to 0 to disable.")
+	flag.DurationVar(&buildRetention, "build-retention", resources.DefaultBuildRetention,
+		"Minimum age of unused builds before they are deleted.")
+	flag.IntVar(&buildsToKeep, "builds-to-keep", resources.DefaultBuildsKeptPerImage,
+		"Number of most recent builds to keep for each image, even when unused.")
+	flag.StringVar(&deployWebhookAddr, "deploy-webhook-addr", "",
+		"The address the deploy webhook binds to, i.e. :8090. Disabled when empty.")
+	flag.BoolVar(&enableWebhooks, "enable-webhooks", true,
+		"Enable admission and conversion webhooks. Requires the webhook service to be installed in the cluster.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"Directory where the webhook serving certificate is written.")
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseDevMode(true)))
 
-	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
+	var insecureRegistryList []string
+	if insecureRegistries != "" {
+		insecureRegistryList = strings.Split(insecureRegistries, ",")
+	}
+
+	config := ctrl.GetConfigOrDie()
+	mgr, err := ctrl.NewManager(config, ctrl.Options{
 		Scheme:             scheme,
 		MetricsBindAddress: metricsAddr,
 		Port:               9443,
+		CertDir:            webhookCertDir,
 		LeaderElection:     enableLeaderElection,
 		LeaderElectionID:   "3509f031.k11n.dev",
 	})
@@ -102,9 +141,11 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.BuildReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("Build"),
-		Scheme: mgr.GetScheme(),
+		Client:             mgr.GetClient(),
+		Reader:             mgr.GetAPIReader(),
+		Log:                ctrl.Log.WithName("controllers").WithName("Build"),
+		Scheme:             mgr.GetScheme(),
+		InsecureRegistries: insecureRegistryList,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Build")
 		os.Exit(1)
@@ -127,6 +168,68 @@ func main() {
 	}
 	// +kubebuilder:scaffold:builder
 
+	if enableWebhooks {
+		// manager's client isn't usable until it's started
+		kclient, err := client.New(config, client.Options{Scheme: scheme})
+		if err == nil {
+			err = webhooks.EnsureCertificates(kclient, webhookCertDir)
+		}
+		if err != nil {
+			setupLog.Error(err, "unable to set up webhook certificates")
+			os.Exit(1)
+		}
+		webhooks.SetupWithManager(mgr)
+		if err = (&webhooks.CAInjector{
+			Client: mgr.GetClient(),
+			Reader: mgr.GetAPIReader(),
+			Log:    ctrl.Log.WithName("webhooks").WithName("CAInjector"),
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "CAInjector")
+			os.Exit(1)
+		}
+	}
+
+	if registryPollInterval > 0 {
+		watcher := &controllers.RegistryWatcher{
+			Client:             mgr.GetClient(),
+			Reader:             mgr.GetAPIReader(),
+			Log:                ctrl.Log.WithName("controllers").WithName("RegistryWatcher"),
+			Interval:           registryPollInterval,
+			InsecureRegistries: insecureRegistryList,
+		}
+		if err = mgr.Add(watcher); err != nil {
+			setupLog.Error(err, "unable to add registry watcher")
+			os.Exit(1)
+		}
+	}
+
+	if buildGCInterval > 0 {
+		if err = mgr.Add(&controllers.BuildGC{
+			Client:   mgr.GetClient(),
+			Log:      ctrl.Log.WithName("controllers").WithName("BuildGC"),
+			Interval: buildGCInterval,
+			Policy: resources.BuildPrunePolicy{
+				MinAge:       buildRetention,
+				KeepPerImage: buildsToKeep,
+			},
+		}); err != nil {
+			setupLog.Error(err, "unable to add build garbage collection")
+			os.Exit(1)
+		}
+	}
+
+	if deployWebhookAddr != "" {
+		if err = mgr.Add(&deployhook.Server{
+			Client:  mgr.GetClient(),
+			Reader:  mgr.GetAPIReader(),
+			Log:     ctrl.Log.WithName("deployhook"),
+			Address: deployWebhookAddr,
+		}); err != nil {
+			setupLog.Error(err, "unable to add deploy webhook")
+			os.Exit(1)
+		}
+	}
+
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
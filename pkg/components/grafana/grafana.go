@@ -8,7 +8,10 @@ import (
 	"github.com/k11n/konstellation/pkg/utils/retry"
 )
 
-const grafanaVersion = "3.4.0"
+const (
+	ComponentName  = "grafana-operator"
+	grafanaVersion = "3.4.0"
+)
 
 func init() {
 	components.RegisterComponent(&GrafanaOperator{})
@@ -18,7 +21,7 @@ type GrafanaOperator struct {
 }
 
 func (d *GrafanaOperator) Name() string {
-	return "grafana-operator"
+	return ComponentName
 }
 
 func (d *GrafanaOperator) VersionForKube(version string) string {
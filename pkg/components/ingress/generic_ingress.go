@@ -0,0 +1,62 @@
+package ingress
+
+import (
+	"strings"
+
+	netv1beta1 "k8s.io/api/networking/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/components"
+)
+
+func init() {
+	components.RegisterComponent(&GenericIngress{})
+}
+
+// GenericIngress relies on the ingress controller that's already running on the cluster,
+// such as Traefik on k3s, or ingress-nginx on kind
+type GenericIngress struct {
+}
+
+func (i *GenericIngress) Name() string {
+	return "ingress.generic"
+}
+
+func (i *GenericIngress) VersionForKube(version string) string {
+	return "1"
+}
+
+func (i *GenericIngress) InstallComponent(kclient client.Client) error {
+	// nothing to install
+	return nil
+}
+
+func (i *GenericIngress) ConfigureIngress(kclient client.Client, ingress *netv1beta1.Ingress, config *v1alpha1.IngressConfig) error {
+	// wildcard paths are specific to ALB, use prefix matching instead
+	pathType := netv1beta1.PathTypePrefix
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for j := range rule.HTTP.Paths {
+			path := &rule.HTTP.Paths[j]
+			if strings.HasSuffix(path.Path, "/*") {
+				path.Path = strings.TrimSuffix(path.Path, "*")
+				path.PathType = &pathType
+			}
+		}
+	}
+
+	if config == nil {
+		return nil
+	}
+	// annotations are passed through to the ingress controller
+	if ingress.Annotations == nil && len(config.Annotations) > 0 {
+		ingress.Annotations = make(map[string]string)
+	}
+	for key, val := range config.Annotations {
+		ingress.Annotations[key] = val
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package ingress
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	netv1beta1 "k8s.io/api/networking/v1beta1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestGenericIngressConfigure(t *testing.T) {
+	in := &netv1beta1.Ingress{
+		Spec: netv1beta1.IngressSpec{
+			Rules: []netv1beta1.IngressRule{
+				{
+					IngressRuleValue: netv1beta1.IngressRuleValue{
+						HTTP: &netv1beta1.HTTPIngressRuleValue{
+							Paths: []netv1beta1.HTTPIngressPath{
+								{Path: "/*"},
+								{Path: "/api"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	gi := &GenericIngress{}
+	assert.NoError(t, gi.ConfigureIngress(nil, in, &v1alpha1.IngressConfig{
+		Hosts:       []string{"myapp.example.com"},
+		Annotations: map[string]string{"kubernetes.io/ingress.class": "nginx"},
+	}))
+
+	paths := in.Spec.Rules[0].HTTP.Paths
+	assert.Equal(t, "/", paths[0].Path)
+	assert.Equal(t, netv1beta1.PathTypePrefix, *paths[0].PathType)
+	assert.Equal(t, "/api", paths[1].Path)
+	assert.Nil(t, paths[1].PathType)
+	assert.Equal(t, map[string]string{"kubernetes.io/ingress.class": "nginx"}, in.Annotations)
+}
@@ -16,8 +16,11 @@ type IngressComponent interface {
 }
 
 func NewIngressForCluster(cloud, cluster string) IngressComponent {
-	if cloud == "aws" {
+	switch cloud {
+	case "aws":
 		return &AWSALBIngress{}
+	case "kubeconfig":
+		return &GenericIngress{}
 	}
 	log.Fatalf("Unsupported cloud: %s", cloud)
 	return nil
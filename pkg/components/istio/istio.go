@@ -14,6 +14,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/k11n/konstellation/pkg/components"
+	"github.com/k11n/konstellation/pkg/components/jaeger"
 	"github.com/k11n/konstellation/pkg/resources"
 	"github.com/k11n/konstellation/pkg/utils/cli"
 	"github.com/k11n/konstellation/pkg/utils/files"
@@ -21,6 +22,8 @@ import (
 
 const (
 	istioVersion = "1.6.5"
+	// percentage of requests traced when the app doesn't set a sampling rate
+	DefaultTraceSampling = 1.0
 )
 
 func init() {
@@ -111,6 +114,10 @@ func (i *IstioInstaller) InstallComponent(kclient client.Client) error {
 		"--set", "addonComponents.grafana.enabled=false",
 		"--set", "values.gateways.istio-ingressgateway.type=NodePort",
 		"--set", "values.gateways.enabled=true",
+		// send spans to Jaeger, apps can override the sampling rate
+		"--set", "meshConfig.enableTracing=true",
+		"--set", "values.global.tracer.zipkin.address="+jaeger.ZipkinAddress,
+		"--set", fmt.Sprintf("values.pilot.traceSampling=%.1f", DefaultTraceSampling),
 	)
 	if err != nil {
 		return err
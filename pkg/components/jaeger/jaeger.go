@@ -0,0 +1,47 @@
+package jaeger
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/pkg/components"
+	"github.com/k11n/konstellation/pkg/resources"
+	"github.com/k11n/konstellation/pkg/utils/cli"
+	"github.com/k11n/konstellation/pkg/utils/retry"
+)
+
+const (
+	ComponentName = "jaeger"
+	QueryService  = "jaeger-query"
+	QueryPort     = 16686
+
+	jaegerVersion    = "1.18"
+	collectorService = "jaeger-collector"
+	zipkinPort       = 9411
+)
+
+// ZipkinAddress is where Istio's sidecars send spans to
+var ZipkinAddress = fmt.Sprintf("%s.%s.svc:%d", collectorService, resources.KonSystemNamespace, zipkinPort)
+
+func init() {
+	components.RegisterComponent(&Jaeger{})
+}
+
+// Jaeger collects and stores traces from Istio. It runs as a single instance that keeps recent traces in memory
+type Jaeger struct {
+}
+
+func (j *Jaeger) Name() string {
+	return ComponentName
+}
+
+func (j *Jaeger) VersionForKube(version string) string {
+	return jaegerVersion
+}
+
+func (j *Jaeger) InstallComponent(kclient client.Client) error {
+	return retry.Retry(func() error {
+		return cli.KubeApplyFromBox("jaeger.yaml", "")
+	}, 8, 0)
+}
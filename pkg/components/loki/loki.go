@@ -0,0 +1,120 @@
+package loki
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/components"
+	"github.com/k11n/konstellation/pkg/resources"
+	"github.com/k11n/konstellation/pkg/utils/assets"
+	"github.com/k11n/konstellation/pkg/utils/cli"
+	"github.com/k11n/konstellation/pkg/utils/retry"
+)
+
+const (
+	ComponentName    = "loki"
+	DiskSizeKey      = "disk-size"
+	RetentionKey     = "retention"
+	DefaultDiskSize  = "50Gi"
+	DefaultRetention = "7d"
+	ServiceName      = "loki"
+	ServicePort      = 3100
+
+	lokiVersion     = "1.5.0"
+	promtailVersion = "1.5.0"
+	// Loki's index is split into weekly tables, retention has to be a multiple of it
+	indexPeriod = 7 * 24 * time.Hour
+)
+
+func init() {
+	components.RegisterComponent(&Loki{})
+}
+
+// Loki stores logs from all pods, shipped by Promtail running on each node
+type Loki struct {
+}
+
+type lokiConfig struct {
+	LokiVersion     string
+	PromtailVersion string
+	DiskSize        string
+	Retention       string
+}
+
+func (l *Loki) Name() string {
+	return ComponentName
+}
+
+func (l *Loki) VersionForKube(version string) string {
+	return lokiVersion
+}
+
+func (l *Loki) InstallComponent(kclient client.Client) error {
+	cc, err := resources.GetClusterConfig(kclient)
+	if err != nil {
+		return err
+	}
+
+	// component isn't yet marked as installed, read its config directly
+	conf, err := configForComponent(cc.Spec.ComponentConfig[ComponentName])
+	if err != nil {
+		return err
+	}
+
+	box := assets.DeployResourcesBox()
+	f, err := box.Open("templates/loki.yaml")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template.New("loki").Parse(string(content))
+	if err != nil {
+		return err
+	}
+
+	buf := bytes.NewBuffer(nil)
+	if err = tmpl.Execute(buf, conf); err != nil {
+		return err
+	}
+
+	return retry.Retry(func() error {
+		return cli.KubeApplyReader(bytes.NewReader(buf.Bytes()))
+	}, 8, 0)
+}
+
+func configForComponent(config v1alpha1.ComponentConfig) (*lokiConfig, error) {
+	conf := &lokiConfig{
+		LokiVersion:     lokiVersion,
+		PromtailVersion: promtailVersion,
+		DiskSize:        DefaultDiskSize,
+	}
+	if val := config[DiskSizeKey]; val != "" {
+		conf.DiskSize = val
+	}
+
+	retention := DefaultRetention
+	if val := config[RetentionKey]; val != "" {
+		retention = val
+	}
+	duration, err := model.ParseDuration(retention)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", RetentionKey, err)
+	}
+	if time.Duration(duration) == 0 || time.Duration(duration)%indexPeriod != 0 {
+		return nil, fmt.Errorf("%s must be a multiple of 7d, got %s", RetentionKey, retention)
+	}
+	conf.Retention = fmt.Sprintf("%dh", int64(time.Duration(duration)/time.Hour))
+	return conf, nil
+}
@@ -0,0 +1,29 @@
+package loki
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestConfigForComponent(t *testing.T) {
+	conf, err := configForComponent(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultDiskSize, conf.DiskSize)
+	assert.Equal(t, "168h", conf.Retention)
+
+	conf, err = configForComponent(v1alpha1.ComponentConfig{
+		DiskSizeKey:  "200Gi",
+		RetentionKey: "4w",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "200Gi", conf.DiskSize)
+	assert.Equal(t, "672h", conf.Retention)
+
+	_, err = configForComponent(v1alpha1.ComponentConfig{RetentionKey: "10d"})
+	assert.Error(t, err)
+	_, err = configForComponent(v1alpha1.ComponentConfig{RetentionKey: "forever"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,253 @@
+package deployhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+const (
+	// secret in kon-system holding a key that can deploy any app
+	GlobalSecretName = "deploy-webhook"
+	// per-app secrets are labeled with the name of the app they may deploy
+	DeployKeyLabel = "k11n.dev/deployKey"
+	SecretKey      = "key"
+
+	SignatureHeader = "X-Kon-Signature"
+	// unix time in seconds that the request was signed at, covered by the signature
+	TimestampHeader = "X-Kon-Timestamp"
+	signaturePrefix = "sha256="
+	maxBodySize     = 1 << 20
+	// signed requests outside of this window are rejected, to limit replays
+	maxSignatureAge = 5 * time.Minute
+)
+
+// DeployRequest is the payload accepted at /deploy
+type DeployRequest struct {
+	App      string                `json:"app"`
+	Tag      string                `json:"tag"`
+	Digest   string                `json:"digest,omitempty"`
+	Metadata *v1alpha1.BuildSource `json:"metadata,omitempty"`
+}
+
+type DeployResponse struct {
+	App     string `json:"app"`
+	Build   string `json:"build"`
+	Image   string `json:"image"`
+	Changed bool   `json:"changed"`
+}
+
+// Server accepts deploy requests from CI systems, authenticated by deploy keys stored in Secrets.
+// Requests either present the key as a bearer token, or sign the timestamp and body with it using HMAC-SHA256.
+// The server speaks plain HTTP, it should be exposed through an ingress or load balancer that terminates TLS
+type Server struct {
+	Client client.Client
+	// uncached reader for secrets
+	Reader  client.Reader
+	Log     logr.Logger
+	Address string
+}
+
+// +kubebuilder:rbac:groups="",namespace=kon-system,resources=secrets,resourceNames=deploy-webhook,verbs=get
+// +kubebuilder:rbac:groups="",namespace=kon-system,resources=secrets,verbs=list
+
+// NeedLeaderElection returns false so that every replica serves requests
+func (s *Server) NeedLeaderElection() bool {
+	return false
+}
+
+func (s *Server) Start(stop <-chan struct{}) error {
+	mux := http.NewServeMux()
+	mux.Handle("/deploy", s)
+	srv := &http.Server{
+		Addr:         s.Address,
+		Handler:      mux,
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+	}
+
+	errChan := make(chan error, 1)
+	go func() {
+		s.Log.Info("Starting deploy webhook", "address", s.Address)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errChan <- err
+		}
+	}()
+
+	select {
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return srv.Shutdown(ctx)
+	case err := <-errChan:
+		return err
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "only POST is supported")
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "could not read request")
+		return
+	}
+	req := DeployRequest{}
+	if err = json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if req.App == "" || req.Tag == "" {
+		writeError(w, http.StatusBadRequest, "app and tag are required")
+		return
+	}
+
+	keys, err := s.deployKeys(req.App)
+	if err != nil {
+		s.Log.Error(err, "Could not load deploy keys")
+		writeError(w, http.StatusInternalServerError, "could not load deploy keys")
+		return
+	}
+	if !Authorize(r, body, keys) {
+		writeError(w, http.StatusUnauthorized, "not authorized to deploy "+req.App)
+		return
+	}
+
+	res, err := s.deploy(&req)
+	if errors.IsNotFound(err) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	} else if err != nil {
+		s.Log.Error(err, "Could not deploy", "app", req.App, "tag", req.Tag)
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	s.Log.Info("Deploying app from webhook", "app", req.App, "build", res.Build, "changed", res.Changed)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// creates the build and sets the app to use it, in the same way as `kon app deploy`
+func (s *Server) deploy(req *DeployRequest) (*DeployResponse, error) {
+	app, err := resources.GetAppByName(s.Client, req.App)
+	if err != nil {
+		return nil, err
+	}
+
+	source := req.Metadata
+	if source != nil && source.IsEmpty() {
+		source = nil
+	}
+	build := v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, req.Tag)
+	if _, err = resources.SaveBuild(s.Client, build, source, req.Digest); err != nil {
+		return nil, err
+	}
+
+	app.Spec.ImageTag = req.Tag
+	op, err := resources.UpdateResource(s.Client, app, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DeployResponse{
+		App:     app.Name,
+		Build:   build.Name,
+		Image:   build.FullImageWithTag(),
+		Changed: op != controllerutil.OperationResultNone,
+	}, nil
+}
+
+// loads the global key along with keys for the app
+func (s *Server) deployKeys(app string) ([][]byte, error) {
+	var keys [][]byte
+	global := &corev1.Secret{}
+	err := s.Reader.Get(context.TODO(), client.ObjectKey{Namespace: resources.KonSystemNamespace, Name: GlobalSecretName}, global)
+	if err == nil {
+		if key := global.Data[SecretKey]; len(key) > 0 {
+			keys = append(keys, key)
+		}
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	secrets := corev1.SecretList{}
+	err = s.Reader.List(context.TODO(), &secrets, client.InNamespace(resources.KonSystemNamespace),
+		client.MatchingLabels{DeployKeyLabel: app})
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		if key := secret.Data[SecretKey]; len(key) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Authorize checks that the request carries one of the keys, or a valid signature made with one of them.
+// Signatures must be made within maxSignatureAge of now
+func Authorize(r *http.Request, body []byte, keys [][]byte) bool {
+	return authorizeAt(r, body, keys, time.Now())
+}
+
+func authorizeAt(r *http.Request, body []byte, keys [][]byte, now time.Time) bool {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	signature := r.Header.Get(SignatureHeader)
+	timestamp := r.Header.Get(TimestampHeader)
+	if signature != "" && !validTimestamp(timestamp, now) {
+		signature = ""
+	}
+	for _, key := range keys {
+		if token != "" && subtle.ConstantTimeCompare([]byte(token), key) == 1 {
+			return true
+		}
+		if signature != "" && hmac.Equal([]byte(signature), []byte(Sign(key, timestamp, body))) {
+			return true
+		}
+	}
+	return false
+}
+
+func validTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	return age <= maxSignatureAge && age >= -maxSignatureAge
+}
+
+// Sign computes the signature header value for a request signed at timestamp (unix seconds), over "<timestamp>.<body>"
+func Sign(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
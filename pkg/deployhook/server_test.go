@@ -0,0 +1,155 @@
+package deployhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func newTestServer(t *testing.T) *Server {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp"},
+			Spec: v1alpha1.AppSpec{
+				Image:    "repo/myapp",
+				ImageTag: "v1",
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "myapp-deploy-key",
+				Namespace: resources.KonSystemNamespace,
+				Labels:    map[string]string{DeployKeyLabel: "myapp"},
+			},
+			Data: map[string][]byte{SecretKey: []byte("appkey")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "other-deploy-key",
+				Namespace: resources.KonSystemNamespace,
+				Labels:    map[string]string{DeployKeyLabel: "other"},
+			},
+			Data: map[string][]byte{SecretKey: []byte("otherkey")},
+		},
+	)
+	return &Server{
+		Client: kclient,
+		Reader: kclient,
+		Log:    ctrl.Log.WithName("test"),
+	}
+}
+
+func sendRequest(s *Server, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/deploy", bytes.NewReader(body))
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDeployWithKey(t *testing.T) {
+	s := newTestServer(t)
+	body, _ := json.Marshal(DeployRequest{
+		App: "myapp",
+		Tag: "v2",
+		Metadata: &v1alpha1.BuildSource{
+			Commit: "abcdef",
+		},
+	})
+
+	rec := sendRequest(s, body, map[string]string{"Authorization": "Bearer otherkey"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendRequest(s, body, map[string]string{"Authorization": "Bearer appkey"})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	res := DeployResponse{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.True(t, res.Changed)
+	assert.Equal(t, "repo/myapp:v2", res.Image)
+
+	app, err := resources.GetAppByName(s.Client, "myapp")
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", app.Spec.ImageTag)
+
+	build, err := resources.GetBuildByName(s.Client, res.Build)
+	assert.NoError(t, err)
+	assert.Equal(t, "abcdef", build.Spec.Source.Commit)
+	// the app reconciler marks the build as latest once it deploys
+	assert.Empty(t, build.Labels[resources.BuildTypeLabel])
+}
+
+func signedHeaders(key string, body []byte) map[string]string {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return map[string]string{
+		TimestampHeader: timestamp,
+		SignatureHeader: Sign([]byte(key), timestamp, body),
+	}
+}
+
+func TestDeployWithSignature(t *testing.T) {
+	s := newTestServer(t)
+	body := []byte(`{"app": "myapp", "tag": "v3"}`)
+
+	rec := sendRequest(s, body, signedHeaders("otherkey", body))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = sendRequest(s, body, signedHeaders("appkey", body))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// missing app, authorized with the global key
+	assert.NoError(t, s.Client.Create(context.TODO(), &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GlobalSecretName,
+			Namespace: resources.KonSystemNamespace,
+		},
+		Data: map[string][]byte{SecretKey: []byte("globalkey")},
+	}))
+	body = []byte(`{"app": "missing", "tag": "v1"}`)
+	rec = sendRequest(s, body, signedHeaders("globalkey", body))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestAuthorizeSignatureWindow(t *testing.T) {
+	keys := [][]byte{[]byte("appkey")}
+	body := []byte(`{"app": "myapp", "tag": "v3"}`)
+	now := time.Unix(1600000000, 0)
+	newRequest := func(timestamp string, signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/deploy", bytes.NewReader(body))
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, signature)
+		return req
+	}
+
+	ts := "1600000000"
+	assert.True(t, authorizeAt(newRequest(ts, Sign(keys[0], ts, body)), body, keys, now))
+	assert.True(t, authorizeAt(newRequest(ts, Sign(keys[0], ts, body)), body, keys, now.Add(4*time.Minute)))
+	// replayed later
+	assert.False(t, authorizeAt(newRequest(ts, Sign(keys[0], ts, body)), body, keys, now.Add(10*time.Minute)))
+	// timestamp changed without re-signing
+	assert.False(t, authorizeAt(newRequest("1600000060", Sign(keys[0], ts, body)), body, keys, now))
+	// missing timestamp
+	assert.False(t, authorizeAt(newRequest("", Sign(keys[0], "", body)), body, keys, now))
+}
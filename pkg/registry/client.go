@@ -0,0 +1,415 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DockerHubRegistry = "registry-1.docker.io"
+
+	mediaTypeManifestV2     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeManifestList   = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIImageIndex  = "application/vnd.oci.image.index.v1+json"
+	headerContentDigest     = "Docker-Content-Digest"
+	headerWWWAuthenticate   = "Www-Authenticate"
+	defaultRequestTimeout   = 30 * time.Second
+	maxTagPages             = 100
+	manifestAcceptHeaderVal = mediaTypeManifestV2 + ", " + mediaTypeManifestList + ", " +
+		mediaTypeOCIManifest + ", " + mediaTypeOCIImageIndex
+)
+
+var (
+	ErrNotFound     = fmt.Errorf("image or tag not found in registry")
+	ErrUnauthorized = fmt.Errorf("not authorized to access registry")
+)
+
+// Client talks to Docker Registry v2 and OCI distribution compatible endpoints
+type Client struct {
+	httpClient  *http.Client
+	credentials map[string]Credential
+	// registries that are reached over plain http. localhost registries are always insecure
+	insecure map[string]bool
+	tokens   sync.Map
+}
+
+type Credential struct {
+	Username string
+	Password string
+}
+
+// ImageConfig contains the parts of an image config blob that we care about
+type ImageConfig struct {
+	Created time.Time         `json:"created"`
+	Labels  map[string]string `json:"-"`
+}
+
+type imageConfigBlob struct {
+	Created time.Time `json:"created"`
+	Config  struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+type manifest struct {
+	MediaType string `json:"mediaType"`
+	Config    struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+func NewClient(credentials map[string]Credential, insecureRegistries ...string) *Client {
+	c := &Client{
+		httpClient:  &http.Client{Timeout: defaultRequestTimeout},
+		credentials: make(map[string]Credential),
+		insecure:    make(map[string]bool),
+	}
+	for host, cred := range credentials {
+		c.credentials[NormalizeRegistry(host)] = cred
+	}
+	for _, host := range insecureRegistries {
+		c.insecure[NormalizeRegistry(host)] = true
+	}
+	return c
+}
+
+// NormalizeRegistry returns the hostname used to reach a registry, mapping an empty registry to Docker Hub
+func NormalizeRegistry(registry string) string {
+	registry = strings.TrimPrefix(registry, "https://")
+	registry = strings.TrimPrefix(registry, "http://")
+	registry = strings.TrimSuffix(registry, "/")
+	registry = strings.TrimSuffix(registry, "/v1")
+	registry = strings.TrimSuffix(registry, "/v2")
+	switch registry {
+	case "", "docker.io", "index.docker.io":
+		return DockerHubRegistry
+	}
+	return registry
+}
+
+// NormalizeRepository adds the implicit library/ prefix to official Docker Hub images
+func NormalizeRepository(registry, repository string) string {
+	if NormalizeRegistry(registry) == DockerHubRegistry && !strings.Contains(repository, "/") {
+		return "library/" + repository
+	}
+	return repository
+}
+
+// ListTags returns all tags for the repository, following pagination
+func (c *Client) ListTags(registry, repository string) ([]string, error) {
+	registry = NormalizeRegistry(registry)
+	repository = NormalizeRepository(registry, repository)
+
+	var tags []string
+	path := fmt.Sprintf("/v2/%s/tags/list", repository)
+	for i := 0; i < maxTagPages && path != ""; i++ {
+		resp, err := c.do(http.MethodGet, registry, repository, path, nil)
+		if err != nil {
+			return nil, err
+		}
+		body := struct {
+			Tags []string `json:"tags"`
+		}{}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, body.Tags...)
+		path = nextPagePath(resp.Header.Get("Link"))
+	}
+	return tags, nil
+}
+
+// GetDigest resolves a tag into the digest of its manifest
+func (c *Client) GetDigest(registry, repository, reference string) (string, error) {
+	registry = NormalizeRegistry(registry)
+	repository = NormalizeRepository(registry, repository)
+
+	headers := map[string]string{"Accept": manifestAcceptHeaderVal}
+	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, reference)
+	resp, err := c.do(http.MethodHead, registry, repository, path, headers)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+
+	digest := resp.Header.Get(headerContentDigest)
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return a digest for %s:%s", repository, reference)
+	}
+	return digest, nil
+}
+
+// GetImageConfig fetches the config of an image, which includes its creation time and labels.
+// For multi-platform images, the linux/amd64 image is used
+func (c *Client) GetImageConfig(registry, repository, reference string) (*ImageConfig, error) {
+	registry = NormalizeRegistry(registry)
+	repository = NormalizeRepository(registry, repository)
+
+	m, err := c.getManifest(registry, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Manifests) > 0 {
+		digest := m.Manifests[0].Digest
+		for _, entry := range m.Manifests {
+			if entry.Platform.OS == "linux" && entry.Platform.Architecture == "amd64" {
+				digest = entry.Digest
+				break
+			}
+		}
+		if m, err = c.getManifest(registry, repository, digest); err != nil {
+			return nil, err
+		}
+	}
+	if m.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest for %s:%s does not reference a config", repository, reference)
+	}
+
+	resp, err := c.do(http.MethodGet, registry, repository, fmt.Sprintf("/v2/%s/blobs/%s", repository, m.Config.Digest), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	blob := imageConfigBlob{}
+	if err = json.NewDecoder(resp.Body).Decode(&blob); err != nil {
+		return nil, err
+	}
+	return &ImageConfig{
+		Created: blob.Created,
+		Labels:  blob.Config.Labels,
+	}, nil
+}
+
+func (c *Client) getManifest(registry, repository, reference string) (*manifest, error) {
+	headers := map[string]string{"Accept": manifestAcceptHeaderVal}
+	resp, err := c.do(http.MethodGet, registry, repository, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), headers)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	m := manifest{}
+	if err = json.NewDecoder(resp.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// performs a request, authenticating with the registry when challenged
+func (c *Client) do(method, registry, repository, path string, headers map[string]string) (*http.Response, error) {
+	reqURL := c.baseURL(registry) + path
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequest(method, reqURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for key, val := range headers {
+			req.Header.Set(key, val)
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	tokenKey := registry + "/" + repository
+	if token, ok := c.tokens.Load(tokenKey); ok {
+		req.Header.Set("Authorization", token.(string))
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get(headerWWWAuthenticate)
+		drainAndClose(resp.Body)
+
+		authHeader, err := c.authorize(registry, challenge)
+		if err != nil {
+			return nil, err
+		}
+		c.tokens.Store(tokenKey, authHeader)
+
+		if req, err = newRequest(); err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", authHeader)
+		if resp, err = c.httpClient.Do(req); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		drainAndClose(resp.Body)
+		return nil, ErrNotFound
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		drainAndClose(resp.Body)
+		return nil, ErrUnauthorized
+	case resp.StatusCode >= 300:
+		drainAndClose(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, reqURL)
+	}
+	return resp, nil
+}
+
+// returns the Authorization header value to use for the challenge
+func (c *Client) authorize(registry, challenge string) (string, error) {
+	cred, hasCred := c.credentials[registry]
+	scheme, params := parseChallenge(challenge)
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if !hasCred {
+			return "", ErrUnauthorized
+		}
+		req, _ := http.NewRequest(http.MethodGet, "/", nil)
+		req.SetBasicAuth(cred.Username, cred.Password)
+		return req.Header.Get("Authorization"), nil
+	case "bearer":
+		realm := params["realm"]
+		if realm == "" {
+			return "", fmt.Errorf("registry %s did not provide a token realm", registry)
+		}
+		query := url.Values{}
+		if params["service"] != "" {
+			query.Set("service", params["service"])
+		}
+		if params["scope"] != "" {
+			query.Set("scope", params["scope"])
+		}
+		tokenURL := realm
+		if len(query) > 0 {
+			tokenURL += "?" + query.Encode()
+		}
+		req, err := http.NewRequest(http.MethodGet, tokenURL, nil)
+		if err != nil {
+			return "", err
+		}
+		if hasCred {
+			req.SetBasicAuth(cred.Username, cred.Password)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return "", ErrUnauthorized
+		}
+		body := struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}{}
+		if err = json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return "", err
+		}
+		token := body.Token
+		if token == "" {
+			token = body.AccessToken
+		}
+		return "Bearer " + token, nil
+	}
+	return "", ErrUnauthorized
+}
+
+func (c *Client) baseURL(registry string) string {
+	if c.insecure[registry] || isLocalRegistry(registry) {
+		return "http://" + registry
+	}
+	return "https://" + registry
+}
+
+func isLocalRegistry(registry string) bool {
+	host := registry
+	if idx := strings.LastIndex(host, ":"); idx != -1 {
+		host = host[:idx]
+	}
+	return host == "localhost" || host == "127.0.0.1"
+}
+
+// parses a WWW-Authenticate header such as Bearer realm="https://auth.docker.io/token",service="registry.docker.io"
+func parseChallenge(header string) (scheme string, params map[string]string) {
+	params = make(map[string]string)
+	header = strings.TrimSpace(header)
+	idx := strings.Index(header, " ")
+	if idx == -1 {
+		return header, params
+	}
+	scheme = header[:idx]
+	rest := header[idx+1:]
+
+	for len(rest) > 0 {
+		eq := strings.Index(rest, "=")
+		if eq == -1 {
+			break
+		}
+		key := strings.TrimSpace(rest[:eq])
+		rest = rest[eq+1:]
+		var val string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end == -1 {
+				val = rest[1:]
+				rest = ""
+			} else {
+				val = rest[1 : end+1]
+				rest = rest[end+2:]
+			}
+		} else {
+			end := strings.Index(rest, ",")
+			if end == -1 {
+				val = rest
+				rest = ""
+			} else {
+				val = rest[:end]
+				rest = rest[end:]
+			}
+		}
+		params[strings.ToLower(key)] = val
+		rest = strings.TrimLeft(rest, ", ")
+	}
+	return
+}
+
+// parses the Link header used for pagination: </v2/repo/tags/list?n=100&last=b>; rel="next"
+func nextPagePath(link string) string {
+	if link == "" || !strings.Contains(link, `rel="next"`) {
+		return ""
+	}
+	start := strings.Index(link, "<")
+	end := strings.Index(link, ">")
+	if start == -1 || end <= start {
+		return ""
+	}
+	next := link[start+1 : end]
+	if u, err := url.Parse(next); err == nil && u.IsAbs() {
+		return u.RequestURI()
+	}
+	return next
+}
+
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
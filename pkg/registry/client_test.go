@@ -0,0 +1,114 @@
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testRepo   = "team/app"
+	testToken  = "secret-token"
+	testDigest = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	testConfig = "sha256:2222222222222222222222222222222222222222222222222222222222222222"
+)
+
+// newTestRegistry emulates a registry that requires bearer tokens, issued by the same server
+func newTestRegistry(t *testing.T) *httptest.Server {
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		assert.Equal(t, fmt.Sprintf("repository:%s:pull", testRepo), r.URL.Query().Get("scope"))
+		json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+	})
+	mux.HandleFunc("/v2/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.Header().Set("Www-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:%s:pull"`,
+				server.URL, testRepo))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case fmt.Sprintf("/v2/%s/tags/list", testRepo):
+			if r.URL.Query().Get("last") == "" {
+				w.Header().Set("Link", fmt.Sprintf(`</v2/%s/tags/list?n=2&last=1.0.0>; rel="next"`, testRepo))
+				json.NewEncoder(w).Encode(map[string]interface{}{"tags": []string{"0.9.0", "1.0.0"}})
+			} else {
+				json.NewEncoder(w).Encode(map[string]interface{}{"tags": []string{"1.1.0", "latest"}})
+			}
+		case fmt.Sprintf("/v2/%s/manifests/1.1.0", testRepo):
+			w.Header().Set(headerContentDigest, testDigest)
+			if r.Method == http.MethodGet {
+				fmt.Fprintf(w, `{"mediaType": "%s", "config": {"digest": "%s"}}`, mediaTypeManifestV2, testConfig)
+			}
+		case fmt.Sprintf("/v2/%s/blobs/%s", testRepo, testConfig):
+			fmt.Fprint(w, `{"created": "2020-07-01T10:00:00Z", "config": {"Labels": {"org.opencontainers.image.revision": "abc"}}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+	server = httptest.NewServer(mux)
+	return server
+}
+
+func TestClientWithTokenAuth(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	client := NewClient(map[string]Credential{
+		host: {Username: "user", Password: "pass"},
+	})
+
+	tags, err := client.ListTags(host, testRepo)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0.9.0", "1.0.0", "1.1.0", "latest"}, tags)
+
+	digest, err := client.GetDigest(host, testRepo, "1.1.0")
+	assert.NoError(t, err)
+	assert.Equal(t, testDigest, digest)
+
+	config, err := client.GetImageConfig(host, testRepo, "1.1.0")
+	assert.NoError(t, err)
+	assert.True(t, config.Created.Equal(time.Date(2020, 7, 1, 10, 0, 0, 0, time.UTC)))
+	assert.Equal(t, "abc", config.Labels["org.opencontainers.image.revision"])
+
+	_, err = client.GetDigest(host, testRepo, "missing")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestClientWithoutCredentials(t *testing.T) {
+	server := newTestRegistry(t)
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	_, err := NewClient(nil).ListTags(host, testRepo)
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestNormalizeRegistry(t *testing.T) {
+	assert.Equal(t, DockerHubRegistry, NormalizeRegistry(""))
+	assert.Equal(t, DockerHubRegistry, NormalizeRegistry("https://index.docker.io/v1/"))
+	assert.Equal(t, "myregistry.com", NormalizeRegistry("https://myregistry.com"))
+	assert.Equal(t, "library/nginx", NormalizeRepository("", "nginx"))
+	assert.Equal(t, "nginx", NormalizeRepository("myregistry.com", "nginx"))
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:a/b:pull"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, "https://auth.docker.io/token", params["realm"])
+	assert.Equal(t, "registry.docker.io", params["service"])
+	assert.Equal(t, "repository:a/b:pull", params["scope"])
+}
@@ -0,0 +1,58 @@
+package registry
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Auth     string `json:"auth"`
+}
+
+// CredentialsFromSecret reads registry credentials from an image pull secret
+func CredentialsFromSecret(secret *corev1.Secret) (map[string]Credential, error) {
+	var auths map[string]dockerConfigEntry
+	switch secret.Type {
+	case corev1.SecretTypeDockerConfigJson:
+		config := struct {
+			Auths map[string]dockerConfigEntry `json:"auths"`
+		}{}
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &config); err != nil {
+			return nil, err
+		}
+		auths = config.Auths
+	case corev1.SecretTypeDockercfg:
+		if err := json.Unmarshal(secret.Data[corev1.DockerConfigKey], &auths); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("secret %s is not an image pull secret", secret.Name)
+	}
+
+	creds := make(map[string]Credential)
+	for host, entry := range auths {
+		cred := Credential{
+			Username: entry.Username,
+			Password: entry.Password,
+		}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return nil, fmt.Errorf("invalid auth for registry %s", host)
+			}
+			parts := strings.SplitN(string(decoded), ":", 2)
+			if len(parts) == 2 {
+				cred.Username = parts[0]
+				cred.Password = parts[1]
+			}
+		}
+		creds[NormalizeRegistry(host)] = cred
+	}
+	return creds, nil
+}
@@ -0,0 +1,159 @@
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	StrategySemver = "semver"
+	StrategyRegex  = "regex"
+)
+
+var (
+	semverPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+)
+
+// TagFilter selects which tags of an image are eligible to be deployed
+type TagFilter struct {
+	Strategy string
+	pattern  *regexp.Regexp
+}
+
+func NewTagFilter(strategy, pattern string) (*TagFilter, error) {
+	f := &TagFilter{Strategy: strategy}
+	switch strategy {
+	case StrategySemver:
+	case StrategyRegex:
+		if pattern == "" {
+			return nil, fmt.Errorf("a pattern is required with the regex strategy")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported strategy: %s", strategy)
+	}
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		f.pattern = re
+	}
+	return f, nil
+}
+
+func (f *TagFilter) Matches(tag string) bool {
+	if f.pattern != nil && !f.pattern.MatchString(tag) {
+		return false
+	}
+	if f.Strategy == StrategySemver {
+		return ParseSemver(tag) != nil
+	}
+	return true
+}
+
+// Filter returns tags that match, preserving order
+func (f *TagFilter) Filter(tags []string) []string {
+	var matched []string
+	for _, tag := range tags {
+		if f.Matches(tag) {
+			matched = append(matched, tag)
+		}
+	}
+	return matched
+}
+
+type Semver struct {
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string
+}
+
+func ParseSemver(tag string) *Semver {
+	matches := semverPattern.FindStringSubmatch(tag)
+	if matches == nil {
+		return nil
+	}
+	v := &Semver{PreRelease: matches[4]}
+	v.Major, _ = strconv.Atoi(matches[1])
+	v.Minor, _ = strconv.Atoi(matches[2])
+	v.Patch, _ = strconv.Atoi(matches[3])
+	return v
+}
+
+// Compare returns -1, 0, or 1 following semver precedence rules
+func (v *Semver) Compare(other *Semver) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if pair[0] != pair[1] {
+			if pair[0] < pair[1] {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	// a release has higher precedence than its pre-releases
+	if v.PreRelease == other.PreRelease {
+		return 0
+	} else if v.PreRelease == "" {
+		return 1
+	} else if other.PreRelease == "" {
+		return -1
+	}
+	return comparePreRelease(v.PreRelease, other.PreRelease)
+}
+
+func comparePreRelease(a, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] == bParts[i] {
+			continue
+		}
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum < bNum {
+				return -1
+			}
+			return 1
+		case aErr == nil:
+			// numeric identifiers have lower precedence
+			return -1
+		case bErr == nil:
+			return 1
+		case aParts[i] < bParts[i]:
+			return -1
+		default:
+			return 1
+		}
+	}
+	switch {
+	case len(aParts) < len(bParts):
+		return -1
+	case len(aParts) > len(bParts):
+		return 1
+	}
+	return 0
+}
+
+// LatestSemverTag returns the tag with the highest version, or an empty string when none are valid
+func LatestSemverTag(tags []string) string {
+	var latest string
+	var latestVersion *Semver
+	for _, tag := range tags {
+		v := ParseSemver(tag)
+		if v == nil {
+			continue
+		}
+		if latestVersion == nil || v.Compare(latestVersion) > 0 {
+			latest = tag
+			latestVersion = v
+		}
+	}
+	return latest
+}
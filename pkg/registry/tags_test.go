@@ -0,0 +1,31 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatestSemverTag(t *testing.T) {
+	assert.Equal(t, "v1.10.0", LatestSemverTag([]string{"v1.2.0", "v1.10.0", "v1.9.9", "latest"}))
+	assert.Equal(t, "1.0.0", LatestSemverTag([]string{"1.0.0-rc.1", "1.0.0", "1.0.0-rc.2"}))
+	assert.Equal(t, "1.0.0-rc.10", LatestSemverTag([]string{"1.0.0-rc.2", "1.0.0-rc.10"}))
+	assert.Empty(t, LatestSemverTag([]string{"latest", "main"}))
+}
+
+func TestTagFilter(t *testing.T) {
+	f, err := NewTagFilter(StrategySemver, `^v1\.`)
+	assert.NoError(t, err)
+	assert.True(t, f.Matches("v1.2.3"))
+	assert.False(t, f.Matches("v2.0.0"))
+	assert.False(t, f.Matches("v1.latest"))
+
+	f, err = NewTagFilter(StrategyRegex, `^main-[0-9a-f]{7}$`)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"main-abcdef1"}, f.Filter([]string{"main-abcdef1", "dev-abcdef1", "main"}))
+
+	_, err = NewTagFilter(StrategyRegex, "")
+	assert.Error(t, err)
+	_, err = NewTagFilter("unknown", "")
+	assert.Error(t, err)
+}
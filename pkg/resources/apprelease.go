@@ -124,6 +124,18 @@ func SortAppReleasesByLatest(releases []*v1alpha1.AppRelease) {
 	})
 }
 
+// GetPodsForAppTarget returns pods across all releases of the app in the target namespace
+func GetPodsForAppTarget(kclient client.Client, namespace string, app string) (pods []*corev1.Pod, err error) {
+	err = ForEach(kclient, &corev1.PodList{}, func(item interface{}) error {
+		pod := item.(corev1.Pod)
+		pods = append(pods, &pod)
+		return nil
+	}, client.MatchingLabels{
+		AppLabel: app,
+	}, client.InNamespace(namespace))
+	return
+}
+
 var statusOrder = []corev1.PodPhase{
 	corev1.PodRunning,
 	corev1.PodSucceeded,
@@ -158,38 +170,18 @@ func GetPodsForAppRelease(kclient client.Client, namespace string, release strin
 	return
 }
 
+// GetFailureReason returns the most likely reason that pods of the release are failing
 func GetFailureReason(kclient client.Client, namespace, release string) (string, error) {
 	pods, err := GetPodsForAppRelease(kclient, namespace, release)
 	if err != nil {
 		return "", err
 	}
 
-	reason := ""
-	for _, pod := range pods {
-		for _, condition := range pod.Status.Conditions {
-			if condition.Reason == "Unschedulable" {
-				return condition.Message, nil
-			}
-		}
-
-		for _, status := range pod.Status.ContainerStatuses {
-			if status.LastTerminationState.Terminated != nil {
-				reason = status.LastTerminationState.Terminated.Reason
-				if status.LastTerminationState.Terminated.Reason != "Error" {
-					// There might be a better message somewhere else
-					return reason, nil
-				}
-			}
-		}
-
-		for _, status := range pod.Status.ContainerStatuses {
-			if status.State.Waiting != nil {
-				return status.State.Waiting.Reason, nil
-			}
-		}
+	causes := DiagnosePods(pods, nil)
+	if len(causes) == 0 {
+		return "", nil
 	}
-
-	return reason, nil
+	return causes[0].Summary(), nil
 }
 
 func GetFirstDeployableRelease(releases []*v1alpha1.AppRelease) *v1alpha1.AppRelease {
@@ -3,11 +3,26 @@ package resources
 import (
 	"context"
 	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/registry"
+)
+
+// image labels that build source is read from. commit and author use the OCI annotation keys
+var (
+	imageCommitLabels  = []string{"org.opencontainers.image.revision", "org.label-schema.vcs-ref"}
+	imageAuthorLabels  = []string{"org.opencontainers.image.authors", "dev.k11n.author"}
+	imageBranchLabels  = []string{"dev.k11n.branch"}
+	imageCIURLLabels   = []string{"dev.k11n.ci-url"}
+	imageMessageLabels = []string{"dev.k11n.message"}
 )
 
 func LabelsForBuild(build *v1alpha1.Build) map[string]string {
@@ -34,7 +49,7 @@ func GetLatestBuild(kclient client.Client, registry, image string) (build *v1alp
 		return nil
 	}, client.MatchingLabels{
 		BuildRegistryLabel: registry,
-		BuildImageLabel:    image,
+		BuildImageLabel:    strings.ReplaceAll(image, "/", "_"),
 		BuildTypeLabel:     BuildTypeLatest,
 	})
 	if err == nil && build == nil {
@@ -42,3 +57,167 @@ func GetLatestBuild(kclient client.Client, registry, image string) (build *v1alp
 	}
 	return
 }
+
+// BuildSourceFromLabels reads source metadata from image labels, returns nil when none are present
+func BuildSourceFromLabels(labels map[string]string) *v1alpha1.BuildSource {
+	firstLabel := func(keys []string) string {
+		for _, key := range keys {
+			if val := strings.TrimSpace(labels[key]); val != "" {
+				return val
+			}
+		}
+		return ""
+	}
+	source := &v1alpha1.BuildSource{
+		Commit:  firstLabel(imageCommitLabels),
+		Branch:  firstLabel(imageBranchLabels),
+		Author:  firstLabel(imageAuthorLabels),
+		CIURL:   firstLabel(imageCIURLLabels),
+		Message: firstLabel(imageMessageLabels),
+	}
+	if source.IsEmpty() {
+		return nil
+	}
+	return source
+}
+
+// GetImagePullCredentials loads registry credentials from the app's image pull secrets. Since secrets are
+// namespaced, the first target that contains the secret is used
+func GetImagePullCredentials(reader client.Reader, app *v1alpha1.App) (map[string]registry.Credential, error) {
+	creds := make(map[string]registry.Credential)
+	for _, secretName := range app.Spec.ImagePullSecrets {
+		for _, target := range app.Spec.Targets {
+			secret := &corev1.Secret{}
+			err := reader.Get(context.TODO(), types.NamespacedName{Namespace: target.Name, Name: secretName}, secret)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return creds, err
+			}
+			secretCreds, err := registry.CredentialsFromSecret(secret)
+			if err != nil {
+				return creds, err
+			}
+			for host, cred := range secretCreds {
+				creds[host] = cred
+			}
+			break
+		}
+	}
+	return creds, nil
+}
+
+// GetImagePullCredentialsForImage collects registry credentials from all apps that use the image
+func GetImagePullCredentialsForImage(kclient client.Client, reader client.Reader, registryName, image string) (map[string]registry.Credential, error) {
+	apps, err := ListApps(kclient)
+	if err != nil {
+		return nil, err
+	}
+	creds := make(map[string]registry.Credential)
+	for i := range apps {
+		app := &apps[i]
+		if app.Spec.Registry != registryName || app.Spec.Image != image {
+			continue
+		}
+		appCreds, err := GetImagePullCredentials(reader, app)
+		if err != nil {
+			return nil, err
+		}
+		for host, cred := range appCreds {
+			creds[host] = cred
+		}
+	}
+	return creds, nil
+}
+
+// SaveBuild creates the build, or merges metadata into the existing build with the same name
+func SaveBuild(kclient client.Client, build *v1alpha1.Build, source *v1alpha1.BuildSource, digest string) (controllerutil.OperationResult, error) {
+	existing, err := GetBuildByName(kclient, build.Name)
+	if err == nil {
+		build = existing
+		if source != nil {
+			if build.Spec.Source == nil {
+				build.Spec.Source = &v1alpha1.BuildSource{}
+			}
+			build.Spec.Source.MergeWith(source)
+		}
+	} else if errors.IsNotFound(err) {
+		build.Labels = LabelsForBuild(build)
+		build.Spec.CreatedAt = metav1.Timestamp{Seconds: time.Now().Unix()}
+		build.Spec.Source = source
+	} else {
+		return controllerutil.OperationResultNone, err
+	}
+	if digest != "" {
+		build.Spec.Digest = digest
+	}
+
+	return UpdateResource(kclient, build, nil, nil)
+}
+
+// ResolveBuildDigest looks up the digest that the build's tag currently points to. Registry credentials are taken
+// from apps that use the image
+func ResolveBuildDigest(kclient client.Client, reader client.Reader, build *v1alpha1.Build, insecureRegistries ...string) (string, error) {
+	creds, err := GetImagePullCredentialsForImage(kclient, reader, build.Spec.Registry, build.Spec.Image)
+	if err != nil {
+		return "", err
+	}
+
+	tag := build.Spec.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	rc := registry.NewClient(creds, insecureRegistries...)
+	return rc.GetDigest(build.Spec.Registry, registry.NormalizeRepository(build.Spec.Registry, build.Spec.Image), tag)
+}
+
+// BuildUsage lists everything that references a build
+type BuildUsage struct {
+	Apps     []string
+	Targets  []*v1alpha1.AppTarget
+	Releases []*v1alpha1.AppRelease
+}
+
+func (u *BuildUsage) IsEmpty() bool {
+	return len(u.Apps) == 0 && len(u.Targets) == 0 && len(u.Releases) == 0
+}
+
+// GetBuildUsage returns usage by build name, for builds that are referenced by apps, app targets or app releases
+func GetBuildUsage(kclient client.Client) (map[string]*BuildUsage, error) {
+	usage := make(map[string]*BuildUsage)
+	usageFor := func(build string) *BuildUsage {
+		if usage[build] == nil {
+			usage[build] = &BuildUsage{}
+		}
+		return usage[build]
+	}
+
+	err := ForEach(kclient, &v1alpha1.AppList{}, func(item interface{}) error {
+		app := item.(v1alpha1.App)
+		u := usageFor(v1alpha1.NewBuild(app.Spec.Registry, app.Spec.Image, app.Spec.ImageTag).Name)
+		u.Apps = append(u.Apps, app.Name)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = ForEach(kclient, &v1alpha1.AppTargetList{}, func(item interface{}) error {
+		at := item.(v1alpha1.AppTarget)
+		u := usageFor(at.Spec.Build)
+		u.Targets = append(u.Targets, &at)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = ForEach(kclient, &v1alpha1.AppReleaseList{}, func(item interface{}) error {
+		ar := item.(v1alpha1.AppRelease)
+		u := usageFor(ar.Spec.Build)
+		u.Releases = append(u.Releases, &ar)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
@@ -0,0 +1,78 @@
+package resources
+
+import (
+	"sort"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+const (
+	DefaultBuildRetention     = 30 * 24 * time.Hour
+	DefaultBuildsKeptPerImage = 10
+)
+
+// BuildPrunePolicy determines which unused builds can be deleted
+type BuildPrunePolicy struct {
+	// builds younger than this are always kept
+	MinAge time.Duration
+	// number of most recent builds to keep for each image
+	KeepPerImage int
+}
+
+// FindBuildsToPrune returns builds that are unused and eligible for deletion under the policy
+func FindBuildsToPrune(kclient client.Client, policy BuildPrunePolicy) ([]*v1alpha1.Build, error) {
+	usage, err := GetBuildUsage(kclient)
+	if err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for name := range usage {
+		inUse[name] = true
+	}
+
+	var builds []*v1alpha1.Build
+	err = ForEach(kclient, &v1alpha1.BuildList{}, func(item interface{}) error {
+		build := item.(v1alpha1.Build)
+		builds = append(builds, &build)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return SelectBuildsToPrune(builds, inUse, policy, time.Now()), nil
+}
+
+// SelectBuildsToPrune picks builds that are not in use, older than MinAge, and not among the most recent
+// KeepPerImage builds of their image. The latest build of each image is never pruned
+func SelectBuildsToPrune(builds []*v1alpha1.Build, inUse map[string]bool, policy BuildPrunePolicy, now time.Time) []*v1alpha1.Build {
+	byImage := make(map[string][]*v1alpha1.Build)
+	var images []string
+	for _, build := range builds {
+		key := build.ImagePath()
+		if _, ok := byImage[key]; !ok {
+			images = append(images, key)
+		}
+		byImage[key] = append(byImage[key], build)
+	}
+	sort.Strings(images)
+
+	var toPrune []*v1alpha1.Build
+	for _, image := range images {
+		imageBuilds := byImage[image]
+		sort.SliceStable(imageBuilds, func(i, j int) bool {
+			return imageBuilds[i].CreationTimestamp.After(imageBuilds[j].CreationTimestamp.Time)
+		})
+		for i, build := range imageBuilds {
+			if i < policy.KeepPerImage || inUse[build.Name] ||
+				build.Labels[BuildTypeLabel] == BuildTypeLatest ||
+				now.Sub(build.CreationTimestamp.Time) < policy.MinAge {
+				continue
+			}
+			toPrune = append(toPrune, build)
+		}
+	}
+	return toPrune
+}
@@ -0,0 +1,46 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func TestSelectBuildsToPrune(t *testing.T) {
+	now := time.Now()
+	newBuild := func(image, tag string, age time.Duration) *v1alpha1.Build {
+		b := v1alpha1.NewBuild("", image, tag)
+		b.CreationTimestamp = metav1.Time{Time: now.Add(-age)}
+		return b
+	}
+	day := 24 * time.Hour
+
+	builds := []*v1alpha1.Build{
+		newBuild("app", "v1", 10*day),
+		newBuild("app", "v2", 9*day),
+		newBuild("app", "v3", 8*day),
+		newBuild("app", "v4", 7*day),
+		newBuild("app", "v5", day),
+		newBuild("other", "v1", 10*day),
+	}
+	builds[0].Labels = map[string]string{BuildTypeLabel: BuildTypeLatest}
+	inUse := map[string]bool{
+		builds[2].Name: true,
+	}
+
+	pruned := SelectBuildsToPrune(builds, inUse, BuildPrunePolicy{
+		MinAge:       5 * day,
+		KeepPerImage: 1,
+	}, now)
+
+	var names []string
+	for _, b := range pruned {
+		names = append(names, b.ShortName())
+	}
+	// v5 is newest and too young, v3 is in use, v1 is latest and other:v1 is the only build for its image
+	assert.Equal(t, []string{"app:v4", "app:v2"}, names)
+}
@@ -0,0 +1,24 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildSourceFromLabels(t *testing.T) {
+	assert.Nil(t, BuildSourceFromLabels(nil))
+	assert.Nil(t, BuildSourceFromLabels(map[string]string{"maintainer": "me"}))
+
+	source := BuildSourceFromLabels(map[string]string{
+		"org.label-schema.vcs-ref":          "abc",
+		"org.opencontainers.image.revision": "0123456789",
+		"dev.k11n.branch":                   "main",
+		"dev.k11n.ci-url":                   "https://ci.example.com/jobs/1",
+	})
+	assert.NotNil(t, source)
+	assert.Equal(t, "0123456789", source.Commit)
+	assert.Equal(t, "main", source.Branch)
+	assert.Equal(t, "https://ci.example.com/jobs/1", source.CIURL)
+	assert.Empty(t, source.Author)
+}
@@ -0,0 +1,296 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thoas/go-funk"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+const (
+	ReasonUnschedulable = "Unschedulable"
+	ReasonOOMKilled     = "OOMKilled"
+	ReasonCrashLoop     = "CrashLoopBackOff"
+	ReasonError         = "Error"
+
+	// event reasons that explain failures
+	eventReasonUnhealthy   = "Unhealthy"
+	eventReasonFailedMount = "FailedMount"
+)
+
+// failure reasons ordered by how likely they are the root cause, lower comes first.
+// an image that can't be pulled or a missing config prevents the app from ever running,
+// while crashes and failing probes are often the symptoms of those
+var failureRanks = map[string]int{
+	"ErrImagePull":               0,
+	"ImagePullBackOff":           0,
+	"InvalidImageName":           0,
+	"ErrImageNeverPull":          0,
+	"CreateContainerConfigError": 1,
+	"CreateContainerError":       1,
+	eventReasonFailedMount:       1,
+	ReasonUnschedulable:          2,
+	ReasonOOMKilled:              3,
+	ReasonCrashLoop:              4,
+	ReasonError:                  4,
+	"ContainerCannotRun":         4,
+	eventReasonUnhealthy:         5,
+}
+
+const defaultFailureRank = 10
+
+// reasons of waiting containers that are expected while a pod is starting
+var startingReasons = map[string]bool{
+	"ContainerCreating": true,
+	"PodInitializing":   true,
+}
+
+// FailureCause is a reason that pods of a release aren't becoming available, along with the pods it affects
+type FailureCause struct {
+	Reason    string
+	Container string
+	Message   string
+	ExitCode  int32
+	Pods      []string
+}
+
+// Explanation describes the cause and what could be done about it
+func (f *FailureCause) Explanation() string {
+	switch f.Reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+		return "the image could not be pulled. Check that the image and tag exist, and that the cluster has access to its registry"
+	case "CreateContainerConfigError", "CreateContainerError":
+		return "the container could not be created. A ConfigMap or Secret that it references may be missing"
+	case eventReasonFailedMount:
+		return "a volume could not be mounted"
+	case ReasonUnschedulable:
+		return "pods could not be scheduled onto any node. The cluster may be out of capacity, or no nodes match the app's requirements"
+	case ReasonOOMKilled:
+		return "the container ran out of memory and was killed. Increase its memory limit under resources in the app manifest"
+	case ReasonCrashLoop, ReasonError, "ContainerCannotRun":
+		if f.ExitCode != 0 {
+			return fmt.Sprintf("the container exited with code %d and is being restarted. Check its logs for errors", f.ExitCode)
+		}
+		return "the container is exiting and being restarted. Check its logs for errors"
+	case eventReasonUnhealthy:
+		return "probes are failing. Check that the app listens on the port its probes use, and that it has enough time to start"
+	default:
+		if f.Message != "" {
+			return f.Message
+		}
+		return f.Reason
+	}
+}
+
+// Summary is a one line description of the cause
+func (f *FailureCause) Summary() string {
+	switch {
+	case f.Reason == ReasonUnschedulable && f.Message != "":
+		return f.Message
+	case f.ExitCode != 0:
+		return fmt.Sprintf("%s (exit code %d)", f.Reason, f.ExitCode)
+	default:
+		return f.Reason
+	}
+}
+
+// PodStatusForPod returns the pod's status along with details of each of its containers
+func PodStatusForPod(pod *corev1.Pod) v1alpha1.PodStatus {
+	status := v1alpha1.PodStatus{
+		Pod:     pod.Name,
+		Reason:  pod.Status.Reason,
+		Message: pod.Status.Message,
+	}
+	if status.Reason == "" {
+		// scheduling failures are only found in conditions
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				status.Reason = condition.Reason
+				status.Message = condition.Message
+			}
+		}
+	}
+
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		container := v1alpha1.PodContainerStatus{
+			Name:         cs.Name,
+			Ready:        cs.Ready,
+			RestartCount: cs.RestartCount,
+		}
+		switch {
+		case cs.State.Waiting != nil:
+			container.State = v1alpha1.ContainerStateWaiting
+			container.Reason = cs.State.Waiting.Reason
+			container.Message = cs.State.Waiting.Message
+		case cs.State.Terminated != nil:
+			container.State = v1alpha1.ContainerStateTerminated
+			container.Reason = cs.State.Terminated.Reason
+			container.Message = cs.State.Terminated.Message
+			container.ExitCode = cs.State.Terminated.ExitCode
+		case cs.State.Running != nil:
+			container.State = v1alpha1.ContainerStateRunning
+		}
+		if cs.LastTerminationState.Terminated != nil {
+			container.LastTerminationReason = cs.LastTerminationState.Terminated.Reason
+			container.LastExitCode = cs.LastTerminationState.Terminated.ExitCode
+		}
+		status.Containers = append(status.Containers, container)
+	}
+	return status
+}
+
+// IsPodFailing returns true when the pod isn't running, or when a container is stuck or crashing
+func IsPodFailing(pod *corev1.Pod) bool {
+	switch pod.Status.Phase {
+	case corev1.PodPending, corev1.PodFailed:
+		return true
+	case corev1.PodRunning:
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Ready {
+				continue
+			}
+			if cs.State.Terminated != nil || (cs.State.Waiting != nil && !startingReasons[cs.State.Waiting.Reason]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DiagnosePods finds reasons that pods are failing and returns them ranked, most likely root cause first.
+// Warning events involving the pods are used to find failures that aren't reflected in pod status
+func DiagnosePods(pods []*corev1.Pod, events []corev1.Event) []*FailureCause {
+	var causes []*FailureCause
+	addCause := func(pod string, cause FailureCause) {
+		for _, c := range causes {
+			if c.Reason == cause.Reason && c.Container == cause.Container {
+				if !funk.ContainsString(c.Pods, pod) {
+					c.Pods = append(c.Pods, pod)
+				}
+				return
+			}
+		}
+		cause.Pods = []string{pod}
+		causes = append(causes, &cause)
+	}
+
+	podNames := make(map[string]bool)
+	for _, pod := range pods {
+		podNames[pod.Name] = true
+		status := PodStatusForPod(pod)
+		if status.Reason == ReasonUnschedulable {
+			addCause(pod.Name, FailureCause{Reason: ReasonUnschedulable, Message: status.Message})
+		}
+
+		for _, container := range status.Containers {
+			switch {
+			case container.LastTerminationReason == ReasonOOMKilled || container.Reason == ReasonOOMKilled:
+				// OOMKilled containers would also be in a crash loop, memory is the more useful reason
+				addCause(pod.Name, FailureCause{
+					Reason:    ReasonOOMKilled,
+					Container: container.Name,
+				})
+			case container.Ready || container.State == v1alpha1.ContainerStateRunning:
+				continue
+			case container.State == v1alpha1.ContainerStateWaiting && container.Reason == ReasonCrashLoop:
+				addCause(pod.Name, FailureCause{
+					Reason:    ReasonCrashLoop,
+					Container: container.Name,
+					Message:   container.Message,
+					ExitCode:  container.LastExitCode,
+				})
+			case container.State == v1alpha1.ContainerStateWaiting && container.Reason != "" &&
+				!startingReasons[container.Reason]:
+				addCause(pod.Name, FailureCause{
+					Reason:    container.Reason,
+					Container: container.Name,
+					Message:   container.Message,
+				})
+			case container.State == v1alpha1.ContainerStateTerminated && container.ExitCode != 0:
+				addCause(pod.Name, FailureCause{
+					Reason:    container.Reason,
+					Container: container.Name,
+					Message:   container.Message,
+					ExitCode:  container.ExitCode,
+				})
+			}
+		}
+	}
+
+	for _, event := range events {
+		if event.Type != corev1.EventTypeWarning || event.InvolvedObject.Kind != "Pod" ||
+			!podNames[event.InvolvedObject.Name] {
+			continue
+		}
+		switch event.Reason {
+		case eventReasonUnhealthy, eventReasonFailedMount:
+			addCause(event.InvolvedObject.Name, FailureCause{
+				Reason:  event.Reason,
+				Message: event.Message,
+			})
+		}
+	}
+
+	sort.SliceStable(causes, func(i, j int) bool {
+		ri, rj := failureRank(causes[i].Reason), failureRank(causes[j].Reason)
+		if ri != rj {
+			return ri < rj
+		}
+		return len(causes[i].Pods) > len(causes[j].Pods)
+	})
+	return causes
+}
+
+// GetEventsForAppReleases returns events for the releases and the objects they own, such as ReplicaSets and pods.
+// Events are sorted by when they were last seen, latest last
+func GetEventsForAppReleases(kclient client.Client, namespace string, releases []string) ([]corev1.Event, error) {
+	eventList := corev1.EventList{}
+	err := kclient.List(context.TODO(), &eventList, client.InNamespace(namespace))
+	if err != nil {
+		return nil, err
+	}
+
+	var events []corev1.Event
+	for _, event := range eventList.Items {
+		name := event.InvolvedObject.Name
+		for _, release := range releases {
+			// pods are named after the ReplicaSet, which has the same name as the release
+			if name == release || strings.HasPrefix(name, release+"-") {
+				events = append(events, event)
+				break
+			}
+		}
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		return EventLastSeen(&events[i]).Before(EventLastSeen(&events[j]))
+	})
+	return events, nil
+}
+
+// EventLastSeen returns the last time the event occurred
+func EventLastSeen(event *corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
+
+func failureRank(reason string) int {
+	if rank, ok := failureRanks[reason]; ok {
+		return rank
+	}
+	return defaultFailureRank
+}
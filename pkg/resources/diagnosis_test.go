@@ -0,0 +1,162 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+func newDiagnosisPod(name string, phase corev1.PodPhase, statuses ...corev1.ContainerStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "production",
+		},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: statuses,
+		},
+	}
+}
+
+func crashLoopStatus(lastReason string, exitCode int32) corev1.ContainerStatus {
+	return corev1.ContainerStatus{
+		Name:         "myapp",
+		RestartCount: 3,
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{Reason: ReasonCrashLoop, Message: "back-off 40s restarting failed container"},
+		},
+		LastTerminationState: corev1.ContainerState{
+			Terminated: &corev1.ContainerStateTerminated{Reason: lastReason, ExitCode: exitCode},
+		},
+	}
+}
+
+func TestPodStatusForPod(t *testing.T) {
+	pod := newDiagnosisPod("myapp-1-a", corev1.PodRunning, crashLoopStatus(ReasonError, 2))
+	status := PodStatusForPod(pod)
+	assert.Equal(t, "myapp-1-a", status.Pod)
+	assert.Equal(t, []v1alpha1.PodContainerStatus{
+		{
+			Name:                  "myapp",
+			RestartCount:          3,
+			State:                 v1alpha1.ContainerStateWaiting,
+			Reason:                ReasonCrashLoop,
+			Message:               "back-off 40s restarting failed container",
+			LastTerminationReason: ReasonError,
+			LastExitCode:          2,
+		},
+	}, status.Containers)
+	assert.True(t, IsPodFailing(pod))
+
+	// scheduling failures come from conditions
+	pod = newDiagnosisPod("myapp-1-b", corev1.PodPending)
+	pod.Status.Conditions = []corev1.PodCondition{
+		{
+			Type:    corev1.PodScheduled,
+			Status:  corev1.ConditionFalse,
+			Reason:  ReasonUnschedulable,
+			Message: "0/3 nodes are available: 3 Insufficient cpu.",
+		},
+	}
+	status = PodStatusForPod(pod)
+	assert.Equal(t, ReasonUnschedulable, status.Reason)
+	assert.Equal(t, "0/3 nodes are available: 3 Insufficient cpu.", status.Message)
+	assert.Empty(t, status.Containers)
+
+	// starting containers aren't failing
+	pod = newDiagnosisPod("myapp-1-c", corev1.PodRunning, corev1.ContainerStatus{
+		Name:  "myapp",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}},
+	})
+	assert.False(t, IsPodFailing(pod))
+}
+
+func TestDiagnosePods(t *testing.T) {
+	pods := []*corev1.Pod{
+		newDiagnosisPod("myapp-1-a", corev1.PodRunning, crashLoopStatus(ReasonError, 1)),
+		newDiagnosisPod("myapp-1-b", corev1.PodRunning, crashLoopStatus(ReasonOOMKilled, 137)),
+		newDiagnosisPod("myapp-1-c", corev1.PodRunning, crashLoopStatus(ReasonError, 1)),
+		newDiagnosisPod("myapp-1-d", corev1.PodRunning, corev1.ContainerStatus{
+			Name:  "myapp",
+			Ready: true,
+			State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		}),
+	}
+	events := []corev1.Event{
+		{
+			Type:           corev1.EventTypeWarning,
+			Reason:         "Unhealthy",
+			Message:        "Readiness probe failed: connection refused",
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "myapp-1-a"},
+		},
+		// not one of the pods
+		{
+			Type:           corev1.EventTypeWarning,
+			Reason:         "FailedMount",
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "other-1-a"},
+		},
+		{
+			Type:           corev1.EventTypeNormal,
+			Reason:         "Pulled",
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "myapp-1-a"},
+		},
+	}
+
+	causes := DiagnosePods(pods, events)
+	assert.Len(t, causes, 3)
+	assert.Equal(t, ReasonOOMKilled, causes[0].Reason)
+	assert.Equal(t, []string{"myapp-1-b"}, causes[0].Pods)
+	assert.Equal(t, ReasonCrashLoop, causes[1].Reason)
+	assert.Equal(t, "myapp", causes[1].Container)
+	assert.Equal(t, int32(1), causes[1].ExitCode)
+	assert.Equal(t, []string{"myapp-1-a", "myapp-1-c"}, causes[1].Pods)
+	assert.Equal(t, "CrashLoopBackOff (exit code 1)", causes[1].Summary())
+	assert.Equal(t, "Unhealthy", causes[2].Reason)
+
+	// images that can't be pulled are the root cause of everything else
+	pods = append(pods, newDiagnosisPod("myapp-1-e", corev1.PodPending, corev1.ContainerStatus{
+		Name:  "myapp",
+		State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}},
+	}))
+	causes = DiagnosePods(pods, nil)
+	assert.Len(t, causes, 3)
+	assert.Equal(t, "ImagePullBackOff", causes[0].Reason)
+
+	assert.Empty(t, DiagnosePods(pods[3:4], nil))
+}
+
+func TestGetEventsForAppReleases(t *testing.T) {
+	now := time.Now()
+	newEvent := func(name, object string, lastSeen time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: "production"},
+			InvolvedObject: corev1.ObjectReference{Name: object},
+			LastTimestamp:  metav1.Time{Time: lastSeen},
+		}
+	}
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		newEvent("pod", "myapp-20200701-1200-abcd-x7k2p", now.Add(-time.Minute)),
+		newEvent("replicaset", "myapp-20200701-1200-abcd", now.Add(-2*time.Minute)),
+		newEvent("other-release", "myapp-20200630-1200-ef01-x7k2p", now),
+		newEvent("other-app", "myapp-worker-20200701-1200-abcd", now),
+	)
+
+	events, err := GetEventsForAppReleases(kclient, "production", []string{"myapp-20200701-1200-abcd"})
+	assert.NoError(t, err)
+	var names []string
+	for _, event := range events {
+		names = append(names, event.Name)
+	}
+	assert.Equal(t, []string{"replicaset", "pod"}, names)
+}
@@ -16,6 +16,10 @@ type KubeClusterConfig struct {
 	Cluster     string
 	EndpointUrl string
 	CAData      []byte
+
+	// when set, used as is in place of EndpointUrl and CAData, and of authenticating with `kon cluster get-token`
+	ClusterInfo *cliv1.Cluster
+	AuthInfo    *cliv1.AuthInfo
 }
 
 func (c *KubeClusterConfig) Name() string {
@@ -73,6 +77,9 @@ func UpdateKubeConfig(kconf *cliv1.Config, cliPath string, clusters []*KubeClust
 				CertificateAuthorityData: cluster.CAData,
 			},
 		}
+		if cluster.ClusterInfo != nil {
+			namedCluster.Cluster = *cluster.ClusterInfo
+		}
 		kconf.Clusters = append(kconf.Clusters, namedCluster)
 		authInfo := cliv1.NamedAuthInfo{
 			Name: cluster.User(),
@@ -89,6 +96,9 @@ func UpdateKubeConfig(kconf *cliv1.Config, cliPath string, clusters []*KubeClust
 				},
 			},
 		}
+		if cluster.AuthInfo != nil {
+			authInfo.AuthInfo = *cluster.AuthInfo
+		}
 		kconf.AuthInfos = append(kconf.AuthInfos, authInfo)
 		kconf.Contexts = append(kconf.Contexts, cliv1.NamedContext{
 			Name: cluster.Name(),
@@ -19,6 +19,8 @@ const (
 	KubeAppInstanceLabel = "app.kubernetes.io/instance"
 
 	IstioInjectLabel = "istio-injection"
+	// overrides mesh-wide proxy settings for a pod
+	IstioProxyConfigAnnotation = "proxy.istio.io/config"
 
 	Konstellation   = "konstellation"
 	BuildTypeLatest = "latest"
@@ -158,7 +158,7 @@ func ForEach(kclient client.Client, listObj runtime.Object, eachFunc func(item i
 		}
 
 		itemsField := listVal.FieldByName("Items")
-		if itemsField.IsZero() {
+		if !itemsField.IsValid() {
 			return fmt.Errorf("list object doesn't not contain Items field")
 		}
 
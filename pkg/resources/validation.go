@@ -0,0 +1,155 @@
+package resources
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+)
+
+// ReferenceValidator checks that objects refer to targets, apps, ports and shared configs that exist.
+// It's used by the admission webhook, as well as `kon app validate`
+type ReferenceValidator struct {
+	// looks up references on the cluster. When nil, references that aren't in Apps or SharedConfigs
+	// are passed to Unverified
+	Reader client.Reader
+	// targets configured on the cluster, targets aren't checked when nil
+	ClusterTargets []string
+	// objects that are validated together, these are checked before the cluster
+	Apps          map[string]*v1alpha1.App
+	SharedConfigs map[string]bool
+	// called for references that can't be verified without a Reader
+	Unverified func(path *field.Path, message string)
+}
+
+// ValidateTarget checks that target is one of the cluster's targets
+func (v *ReferenceValidator) ValidateTarget(path *field.Path, target string) *field.Error {
+	if v.ClusterTargets == nil || target == "" {
+		return nil
+	}
+	for _, t := range v.ClusterTargets {
+		if t == target {
+			return nil
+		}
+	}
+	return field.NotSupported(path, target, v.ClusterTargets)
+}
+
+// ValidateAppRefs checks the app's targets, dependencies and shared configs. err is set when references
+// could not be looked up, errs contains what was found before that
+func (v *ReferenceValidator) ValidateAppRefs(app *v1alpha1.App) (errs field.ErrorList, err error) {
+	specPath := field.NewPath("spec")
+
+	for i, tc := range app.Spec.Targets {
+		if fErr := v.ValidateTarget(specPath.Child("targets").Index(i).Child("name"), tc.Name); fErr != nil {
+			errs = append(errs, fErr)
+		}
+	}
+
+	for i, ref := range app.Spec.Dependencies {
+		depPath := specPath.Child("dependencies").Index(i)
+		if ref.Name == "" {
+			continue
+		}
+		if fErr := v.ValidateTarget(depPath.Child("target"), ref.Target); fErr != nil {
+			errs = append(errs, fErr)
+		}
+
+		var dep *v1alpha1.App
+		dep, err = v.getApp(app, ref.Name)
+		if errors.IsNotFound(err) {
+			errs = append(errs, field.NotFound(depPath.Child("name"), ref.Name))
+			err = nil
+			continue
+		} else if err != nil {
+			return
+		} else if dep == nil {
+			v.unverified(depPath.Child("name"), fmt.Sprintf("app %s is not in the validated manifests", ref.Name))
+			continue
+		}
+
+		if ref.Port != "" {
+			found := false
+			for _, port := range dep.Spec.Ports {
+				if port.Name == ref.Port {
+					found = true
+					break
+				}
+			}
+			if !found {
+				errs = append(errs, field.NotFound(depPath.Child("port"), ref.Port))
+			}
+		}
+	}
+
+	for i, name := range app.Spec.Configs {
+		configPath := specPath.Child("configs").Index(i)
+		if v.SharedConfigs[name] {
+			continue
+		}
+		if v.Reader == nil {
+			v.unverified(configPath, fmt.Sprintf("shared config %s is not in the validated manifests", name))
+			continue
+		}
+		configs := v1alpha1.AppConfigList{}
+		err = v.Reader.List(context.TODO(), &configs, client.MatchingLabels{
+			v1alpha1.SharedConfigLabel: name,
+		})
+		if err != nil {
+			return
+		}
+		if len(configs.Items) == 0 {
+			errs = append(errs, field.NotFound(configPath, name))
+		}
+	}
+	return
+}
+
+// ValidateAppConfigRefs checks the target that the config is for
+func (v *ReferenceValidator) ValidateAppConfigRefs(config *v1alpha1.AppConfig) field.ErrorList {
+	path := field.NewPath("metadata", "labels").Key(v1alpha1.TargetLabel)
+	if err := v.ValidateTarget(path, config.GetTarget()); err != nil {
+		return field.ErrorList{err}
+	}
+	return nil
+}
+
+// ValidateLinkedServiceAccountRefs checks the targets that the account is created in
+func (v *ReferenceValidator) ValidateLinkedServiceAccountRefs(lsa *v1alpha1.LinkedServiceAccount) field.ErrorList {
+	var errs field.ErrorList
+	for i, target := range lsa.Spec.Targets {
+		if err := v.ValidateTarget(field.NewPath("spec", "targets").Index(i), target); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// getApp looks up a dependency, returns nil without an error when it can't be verified
+func (v *ReferenceValidator) getApp(app *v1alpha1.App, name string) (*v1alpha1.App, error) {
+	if name == app.Name {
+		return app, nil
+	}
+	if dep := v.Apps[name]; dep != nil {
+		return dep, nil
+	}
+	if v.Reader == nil {
+		return nil, nil
+	}
+	dep := &v1alpha1.App{}
+	if err := v.Reader.Get(context.TODO(), types.NamespacedName{Name: name}, dep); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+func (v *ReferenceValidator) unverified(path *field.Path, message string) {
+	if v.Unverified != nil {
+		v.Unverified(path, message)
+	}
+}
@@ -0,0 +1,92 @@
+package files
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ReadDotenv parses KEY=VALUE pairs from a .env file. Blank lines and lines starting with # are skipped,
+// values may be wrapped in single or double quotes
+func ReadDotenv(reader io.Reader) (map[string]string, error) {
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum += 1
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		idx := strings.Index(line, "=")
+		if idx <= 0 {
+			return nil, fmt.Errorf("invalid line %d, expected KEY=VALUE", lineNum)
+		}
+		key := strings.TrimSpace(line[:idx])
+		val := strings.TrimSpace(line[idx+1:])
+
+		if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+			val = unescapeDotenv(val[1 : len(val)-1])
+		} else if len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'' {
+			val = val[1 : len(val)-1]
+		} else if commentIdx := strings.Index(val, " #"); commentIdx != -1 {
+			val = strings.TrimSpace(val[:commentIdx])
+		}
+		values[key] = val
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// WriteDotenv writes values sorted by key, quoting any values that cannot be represented as is
+func WriteDotenv(writer io.Writer, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		val := values[key]
+		if strings.ContainsAny(val, " \t\n\r\"'#$\\") {
+			val = `"` + escapeDotenv(val) + `"`
+		}
+		if _, err := fmt.Fprintf(writer, "%s=%s\n", key, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	dotenvEscaper = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\r", `\r`,
+		"\t", `\t`,
+		"$", `\$`,
+	)
+	dotenvUnescaper = strings.NewReplacer(
+		`\\`, `\`,
+		`\"`, `"`,
+		`\n`, "\n",
+		`\r`, "\r",
+		`\t`, "\t",
+		`\$`, "$",
+	)
+)
+
+func escapeDotenv(val string) string {
+	return dotenvEscaper.Replace(val)
+}
+
+func unescapeDotenv(val string) string {
+	return dotenvUnescaper.Replace(val)
+}
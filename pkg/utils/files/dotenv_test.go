@@ -0,0 +1,48 @@
+package files
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadDotenv(t *testing.T) {
+	content := `
+# comment
+PLAIN=value
+export EXPORTED=1
+SPACED = with spaces # trailing comment
+DOUBLE="line1\nline2"
+SINGLE='raw \n value'
+EMPTY=
+`
+	values, err := ReadDotenv(strings.NewReader(content))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"PLAIN":    "value",
+		"EXPORTED": "1",
+		"SPACED":   "with spaces",
+		"DOUBLE":   "line1\nline2",
+		"SINGLE":   `raw \n value`,
+		"EMPTY":    "",
+	}, values)
+
+	_, err = ReadDotenv(strings.NewReader("NOT_A_PAIR"))
+	assert.Error(t, err)
+}
+
+func TestDotenvRoundTrip(t *testing.T) {
+	values := map[string]string{
+		"HOST":   "localhost",
+		"CONFIG": "key: \"value\"\nother: $HOME\\path\n",
+	}
+	buf := bytes.NewBuffer(nil)
+	assert.NoError(t, WriteDotenv(buf, values))
+	assert.True(t, strings.HasPrefix(buf.String(), "CONFIG=\""))
+
+	parsed, err := ReadDotenv(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, values, parsed)
+}
@@ -0,0 +1,80 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// CAInjector keeps the CA bundle set on the webhook configurations and CRDs. They are shipped with a
+// placeholder, so re-applying the manifests (i.e. on reinstall) would otherwise disable the webhooks
+type CAInjector struct {
+	client.Client
+	// uncached reader for the certificate secret
+	Reader client.Reader
+	Log    logr.Logger
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get;list;watch;update
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;update
+
+func (r *CAInjector) Reconcile(req ctrl.Request) (ctrl.Result, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: resources.KonSystemNamespace, Name: CertSecretName}
+	if err := r.Reader.Get(context.TODO(), key, secret); err != nil {
+		return ctrl.Result{}, err
+	}
+	// every object is checked, the CA is only written when it differs
+	if err := injectCABundle(r.Client, secret.Data[caCertKey]); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+func (r *CAInjector) SetupWithManager(mgr ctrl.Manager) error {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("cainjector").
+		For(&admissionregistrationv1beta1.MutatingWebhookConfiguration{}).
+		Watches(&source.Kind{Type: &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}}, &handler.EnqueueRequestForObject{}).
+		Watches(&source.Kind{Type: crd}, &handler.EnqueueRequestForObject{}).
+		WithEventFilter(predicate.Funcs{
+			CreateFunc: func(e event.CreateEvent) bool {
+				return isInjectable(e.Meta.GetName())
+			},
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return isInjectable(e.MetaNew.GetName())
+			},
+			DeleteFunc: func(e event.DeleteEvent) bool {
+				return false
+			},
+			GenericFunc: func(e event.GenericEvent) bool {
+				return isInjectable(e.Meta.GetName())
+			},
+		}).
+		Complete(r)
+}
+
+func isInjectable(name string) bool {
+	if name == MutatingWebhookConfigName || name == ValidatingWebhookConfigName {
+		return true
+	}
+	for _, crdName := range ConvertibleCRDs {
+		if name == crdName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+package webhooks
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+func TestCAInjectorRestoresBundle(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+
+	ca := []byte("ca-cert")
+	placeholder := []byte("\n")
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: CertSecretName, Namespace: resources.KonSystemNamespace},
+			Data:       map[string][]byte{caCertKey: ca},
+		},
+		// as re-applied from the manifests
+		&admissionregistrationv1beta1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: ValidatingWebhookConfigName},
+			Webhooks: []admissionregistrationv1beta1.ValidatingWebhook{
+				{Name: "vapp.k11n.dev", ClientConfig: admissionregistrationv1beta1.WebhookClientConfig{CABundle: placeholder}},
+			},
+		},
+	)
+	injector := &CAInjector{
+		Client: kclient,
+		Reader: kclient,
+		Log:    ctrl.Log.WithName("test"),
+	}
+
+	_, err := injector.Reconcile(ctrl.Request{})
+	assert.NoError(t, err)
+
+	vwc := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+	assert.NoError(t, kclient.Get(context.TODO(), client.ObjectKey{Name: ValidatingWebhookConfigName}, vwc))
+	assert.Equal(t, ca, vwc.Webhooks[0].ClientConfig.CABundle)
+}
+
+func TestIsInjectable(t *testing.T) {
+	assert.True(t, isInjectable(MutatingWebhookConfigName))
+	assert.True(t, isInjectable("apps.k11n.dev"))
+	assert.False(t, isInjectable("builds.k11n.dev"))
+}
@@ -0,0 +1,246 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path"
+	"time"
+
+	admissionregistrationv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+var (
+	// CRDs that have more than one version, and are converted by the webhook
+	ConvertibleCRDs = []string{"apps.k11n.dev", "appconfigs.k11n.dev"}
+
+	crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1beta1", Kind: "CustomResourceDefinition"}
+)
+
+const (
+	CertSecretName = "konstellation-webhook-certs"
+	ServiceName    = "konstellation-webhook-service"
+
+	MutatingWebhookConfigName   = "konstellation-mutating-webhook-configuration"
+	ValidatingWebhookConfigName = "konstellation-validating-webhook-configuration"
+
+	caCertKey      = "ca.crt"
+	certValidity   = 10 * 365 * 24 * time.Hour
+	renewThreshold = 30 * 24 * time.Hour
+)
+
+// +kubebuilder:rbac:groups="",namespace=kon-system,resources=secrets,verbs=create
+// +kubebuilder:rbac:groups="",namespace=kon-system,resources=secrets,resourceNames=konstellation-webhook-certs,verbs=get;update
+
+// EnsureCertificates makes sure the webhook server has a serving certificate. Certificates are self-signed
+// and stored in a Secret, so that every replica serves the same one. The CA is then injected into
+// the webhook configurations, CAInjector keeps it there while the operator runs.
+// kclient needs to be uncached, since this runs before the manager is started
+func EnsureCertificates(kclient client.Client, certDir string) error {
+	secret, err := getOrCreateCertSecret(kclient)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(certDir, 0700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if err = ioutil.WriteFile(path.Join(certDir, key), secret.Data[key], 0600); err != nil {
+			return err
+		}
+	}
+
+	return injectCABundle(kclient, secret.Data[caCertKey])
+}
+
+func getOrCreateCertSecret(kclient client.Client) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	key := client.ObjectKey{Namespace: resources.KonSystemNamespace, Name: CertSecretName}
+	err := kclient.Get(context.TODO(), key, secret)
+	if err == nil {
+		if certValid(secret.Data[corev1.TLSCertKey]) {
+			return secret, nil
+		}
+		// expiring, rotate
+		if secret.Data, err = generateCertificates(); err != nil {
+			return nil, err
+		}
+		return secret, kclient.Update(context.TODO(), secret)
+	} else if !errors.IsNotFound(err) {
+		return nil, err
+	}
+
+	secret.Namespace = key.Namespace
+	secret.Name = key.Name
+	secret.Type = corev1.SecretTypeTLS
+	if secret.Data, err = generateCertificates(); err != nil {
+		return nil, err
+	}
+	err = kclient.Create(context.TODO(), secret)
+	if errors.IsAlreadyExists(err) {
+		// created by another replica
+		err = kclient.Get(context.TODO(), key, secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func certValid(certPEM []byte) bool {
+	block, _ := pem.Decode(certPEM)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return time.Now().Add(renewThreshold).Before(cert.NotAfter)
+}
+
+// generateCertificates creates a CA and a serving cert for the webhook service signed by it
+func generateCertificates() (map[string][]byte, error) {
+	now := time.Now()
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: "konstellation-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(certValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	host := fmt.Sprintf("%s.%s.svc", ServiceName, resources.KonSystemNamespace)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano() + 1),
+		Subject:      pkix.Name{CommonName: host},
+		DNSNames:     []string{host, host + ".cluster.local"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(certValidity),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string][]byte{
+		caCertKey:               pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		corev1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}),
+		corev1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
+
+// injectCABundle sets the CA on all of our webhook configurations
+func injectCABundle(kclient client.Client, ca []byte) error {
+	mwc := &admissionregistrationv1beta1.MutatingWebhookConfiguration{}
+	err := kclient.Get(context.TODO(), client.ObjectKey{Name: MutatingWebhookConfigName}, mwc)
+	if err == nil {
+		changed := false
+		for i := range mwc.Webhooks {
+			changed = setCABundle(&mwc.Webhooks[i].ClientConfig, ca) || changed
+		}
+		if changed {
+			if err = kclient.Update(context.TODO(), mwc); err != nil {
+				return err
+			}
+		}
+	} else if !errors.IsNotFound(err) {
+		// not installed when it's not found, nothing to inject
+		return err
+	}
+
+	vwc := &admissionregistrationv1beta1.ValidatingWebhookConfiguration{}
+	err = kclient.Get(context.TODO(), client.ObjectKey{Name: ValidatingWebhookConfigName}, vwc)
+	if err == nil {
+		changed := false
+		for i := range vwc.Webhooks {
+			changed = setCABundle(&vwc.Webhooks[i].ClientConfig, ca) || changed
+		}
+		if changed {
+			if err = kclient.Update(context.TODO(), vwc); err != nil {
+				return err
+			}
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	for _, crdName := range ConvertibleCRDs {
+		if err = injectCRDCABundle(kclient, crdName, ca); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// injectCRDCABundle sets the CA for the conversion webhook. CRDs are handled as unstructured
+// to avoid depending on apiextensions types
+func injectCRDCABundle(kclient client.Client, name string, ca []byte) error {
+	crd := &unstructured.Unstructured{}
+	crd.SetGroupVersionKind(crdGVK)
+	err := kclient.Get(context.TODO(), client.ObjectKey{Name: name}, crd)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	path := []string{"spec", "conversion", "webhookClientConfig", "caBundle"}
+	if _, found, _ := unstructured.NestedMap(crd.Object, path[:3]...); !found {
+		// conversion webhook isn't configured
+		return nil
+	}
+	encoded := base64.StdEncoding.EncodeToString(ca)
+	if existing, _, _ := unstructured.NestedString(crd.Object, path...); existing == encoded {
+		return nil
+	}
+	if err = unstructured.SetNestedField(crd.Object, encoded, path...); err != nil {
+		return err
+	}
+	return kclient.Update(context.TODO(), crd)
+}
+
+func setCABundle(config *admissionregistrationv1beta1.WebhookClientConfig, ca []byte) bool {
+	if bytes.Equal(config.CABundle, ca) {
+		return false
+	}
+	config.CABundle = ca
+	return true
+}
@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// +kubebuilder:webhook:path=/mutate-k11n-dev-v1alpha1-app,mutating=true,failurePolicy=ignore,groups=k11n.dev,resources=apps,verbs=create;update,versions=v1alpha1;v1beta1,name=mapp.k11n.dev
+
+// AppDefaulter fills in defaults on apps as they are saved, using AppDefaults from the ClusterConfig
+type AppDefaulter struct {
+	Client  client.Client
+	Log     logr.Logger
+	decoder *admission.Decoder
+}
+
+func (d *AppDefaulter) InjectDecoder(decoder *admission.Decoder) error {
+	d.decoder = decoder
+	return nil
+}
+
+func (d *AppDefaulter) Handle(ctx context.Context, req admission.Request) admission.Response {
+	app := &v1alpha1.App{}
+	if err := decode(d.decoder, req, app); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if app.DeletionTimestamp != nil {
+		return admission.Allowed("")
+	}
+
+	var defaults *v1alpha1.AppDefaults
+	var clusterTargets []string
+	cc, err := resources.GetClusterConfig(d.Client)
+	if err == nil {
+		defaults = cc.Spec.AppDefaults
+		clusterTargets = cc.Spec.Targets
+	} else if err != resources.ErrNotFound {
+		d.Log.Error(err, "Could not load cluster config")
+	}
+
+	// only on create, so apps could still be removed from all targets
+	if req.Operation == admissionv1beta1.Create {
+		app.SetDefaultTargets(defaults, clusterTargets)
+	}
+	app.SetDefaults(defaults)
+
+	marshaled, err := encode(req, app)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
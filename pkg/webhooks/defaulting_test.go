@@ -0,0 +1,78 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/api/v1beta1"
+)
+
+func TestDefaultApp(t *testing.T) {
+	kclient, decoder := newTestClient(t)
+	d := &AppDefaulter{Client: kclient, Log: ctrl.Log.WithName("test")}
+	assert.NoError(t, d.InjectDecoder(decoder))
+
+	app := &v1alpha1.App{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "k11n.dev/v1alpha1", Kind: "App"},
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend"},
+		Spec: v1alpha1.AppSpec{
+			Image: "frontend",
+		},
+	}
+	res := d.Handle(context.TODO(), appRequest(t, app))
+	assert.True(t, res.Allowed)
+
+	paths := make(map[string]interface{})
+	for _, patch := range res.Patches {
+		paths[patch.Path] = patch.Value
+	}
+	assert.Contains(t, paths, "/spec/targets")
+	assert.Len(t, paths["/spec/targets"], 2)
+	assert.Contains(t, paths, "/spec/scale/min")
+}
+
+func TestDefaultAppV1beta1(t *testing.T) {
+	kclient, decoder := newTestClient(t)
+	d := &AppDefaulter{Client: kclient, Log: ctrl.Log.WithName("test")}
+	assert.NoError(t, d.InjectDecoder(decoder))
+
+	app := &v1beta1.App{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "k11n.dev/v1beta1", Kind: "App"},
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend"},
+		Spec: v1beta1.AppSpec{
+			Image: "frontend",
+			AppCommonSpec: v1beta1.AppCommonSpec{
+				Ports: []v1beta1.PortSpec{{Name: "http", Port: 80}},
+			},
+			Targets: []v1beta1.TargetConfig{{Name: "staging"}},
+		},
+	}
+	raw, err := json.Marshal(app)
+	assert.NoError(t, err)
+	res := d.Handle(context.TODO(), admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Name:      app.Name,
+			Operation: admissionv1beta1.Update,
+			Kind:      metav1.GroupVersionKind{Group: "k11n.dev", Version: "v1beta1", Kind: "App"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	})
+	assert.True(t, res.Allowed)
+
+	paths := make(map[string]interface{})
+	for _, patch := range res.Patches {
+		paths[patch.Path] = patch.Value
+	}
+	assert.Equal(t, "TCP", paths["/spec/ports/0/protocol"])
+	assert.Equal(t, "latest", paths["/spec/targets/0/deployMode"])
+	assert.NotContains(t, paths, "/apiVersion")
+}
@@ -0,0 +1,144 @@
+package webhooks
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-logr/logr"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/pkg/resources"
+)
+
+// +kubebuilder:webhook:path=/validate-k11n-dev-v1alpha1-app,mutating=false,failurePolicy=ignore,groups=k11n.dev,resources=apps,verbs=create;update,versions=v1alpha1;v1beta1,name=vapp.k11n.dev
+// +kubebuilder:webhook:path=/validate-k11n-dev-v1alpha1-appconfig,mutating=false,failurePolicy=ignore,groups=k11n.dev,resources=appconfigs,verbs=create;update,versions=v1alpha1;v1beta1,name=vappconfig.k11n.dev
+// +kubebuilder:webhook:path=/validate-k11n-dev-v1alpha1-linkedserviceaccount,mutating=false,failurePolicy=ignore,groups=k11n.dev,resources=linkedserviceaccounts,verbs=create;update,versions=v1alpha1,name=vlinkedserviceaccount.k11n.dev
+// +kubebuilder:webhook:path=/validate-k11n-dev-v1alpha1-nodepool,mutating=false,failurePolicy=ignore,groups=k11n.dev,resources=nodepools,verbs=create;update,versions=v1alpha1,name=vnodepool.k11n.dev
+
+// validatable is implemented by the Konstellation types with intrinsic validation
+type validatable interface {
+	runtime.Object
+	Validate() field.ErrorList
+}
+
+// Validator rejects objects that fail their intrinsic validation, or that reference targets, apps or ports
+// that don't exist in the cluster
+type Validator struct {
+	Client client.Client
+	Log    logr.Logger
+
+	// returns an empty object of the kind being validated
+	newObject func() validatable
+	// cluster-dependent checks
+	validateRefs func(v *Validator, obj validatable) field.ErrorList
+	decoder      *admission.Decoder
+}
+
+func NewAppValidator(kclient client.Client, log logr.Logger) *Validator {
+	return &Validator{
+		Client:       kclient,
+		Log:          log,
+		newObject:    func() validatable { return &v1alpha1.App{} },
+		validateRefs: validateAppRefs,
+	}
+}
+
+func NewAppConfigValidator(kclient client.Client, log logr.Logger) *Validator {
+	return &Validator{
+		Client:    kclient,
+		Log:       log,
+		newObject: func() validatable { return &v1alpha1.AppConfig{} },
+		validateRefs: func(v *Validator, obj validatable) field.ErrorList {
+			return v.referenceValidator().ValidateAppConfigRefs(obj.(*v1alpha1.AppConfig))
+		},
+	}
+}
+
+func NewLinkedServiceAccountValidator(kclient client.Client, log logr.Logger) *Validator {
+	return &Validator{
+		Client:    kclient,
+		Log:       log,
+		newObject: func() validatable { return &v1alpha1.LinkedServiceAccount{} },
+		validateRefs: func(v *Validator, obj validatable) field.ErrorList {
+			return v.referenceValidator().ValidateLinkedServiceAccountRefs(obj.(*v1alpha1.LinkedServiceAccount))
+		},
+	}
+}
+
+func NewNodepoolValidator(kclient client.Client, log logr.Logger) *Validator {
+	return &Validator{
+		Client:    kclient,
+		Log:       log,
+		newObject: func() validatable { return &v1alpha1.Nodepool{} },
+	}
+}
+
+func (v *Validator) InjectDecoder(d *admission.Decoder) error {
+	v.decoder = d
+	return nil
+}
+
+func (v *Validator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.Operation == admissionv1beta1.Delete {
+		return admission.Allowed("")
+	}
+	obj := v.newObject()
+	if err := decode(v.decoder, req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// don't block finalizers from being removed
+	if meta, ok := obj.(metav1.Object); ok && meta.GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+
+	errs := obj.Validate()
+	if v.validateRefs != nil {
+		errs = append(errs, v.validateRefs(v, obj)...)
+	}
+	if len(errs) == 0 {
+		return admission.Allowed("")
+	}
+	v.Log.Info("Rejected invalid object", "kind", req.Kind.Kind, "name", req.Name, "errors", errs.ToAggregate().Error())
+	return invalidResponse(req, errs)
+}
+
+// referenceValidator checks references against the cluster. Targets aren't checked when there's no ClusterConfig yet
+func (v *Validator) referenceValidator() *resources.ReferenceValidator {
+	rv := &resources.ReferenceValidator{Reader: v.Client}
+	cc, err := resources.GetClusterConfig(v.Client)
+	if err == nil {
+		rv.ClusterTargets = cc.Spec.Targets
+	} else if err != resources.ErrNotFound {
+		v.Log.Error(err, "Could not load cluster config")
+	}
+	return rv
+}
+
+func validateAppRefs(v *Validator, obj validatable) field.ErrorList {
+	app := obj.(*v1alpha1.App)
+	errs, err := v.referenceValidator().ValidateAppRefs(app)
+	if err != nil {
+		// references that couldn't be loaded are let through
+		v.Log.Error(err, "Could not load references", "app", app.Name)
+	}
+	return errs
+}
+
+func invalidResponse(req admission.Request, errs field.ErrorList) admission.Response {
+	gk := v1alpha1.GroupVersion.WithKind(req.Kind.Kind).GroupKind()
+	status := apierrors.NewInvalid(gk, req.Name, errs).ErrStatus
+	return admission.Response{
+		AdmissionResponse: admissionv1beta1.AdmissionResponse{
+			Allowed: false,
+			Result:  &status,
+		},
+	}
+}
@@ -0,0 +1,105 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/api/v1beta1"
+)
+
+func newTestClient(t *testing.T) (client.Client, *admission.Decoder) {
+	scheme := runtime.NewScheme()
+	assert.NoError(t, clientgoscheme.AddToScheme(scheme))
+	assert.NoError(t, v1alpha1.AddToScheme(scheme))
+	assert.NoError(t, v1beta1.AddToScheme(scheme))
+
+	kclient := fake.NewFakeClientWithScheme(scheme,
+		&v1alpha1.ClusterConfig{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec: v1alpha1.ClusterConfigSpec{
+				Targets: []string{"staging", "production"},
+			},
+		},
+		&v1alpha1.App{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend"},
+			Spec: v1alpha1.AppSpec{
+				Image: "backend",
+				AppCommonSpec: v1alpha1.AppCommonSpec{
+					Ports: []v1alpha1.PortSpec{{Name: "grpc", Port: 9000}},
+				},
+			},
+		},
+	)
+	decoder, err := admission.NewDecoder(scheme)
+	assert.NoError(t, err)
+	return kclient, decoder
+}
+
+func newTestValidator(t *testing.T) *Validator {
+	kclient, decoder := newTestClient(t)
+	v := NewAppValidator(kclient, ctrl.Log.WithName("test"))
+	assert.NoError(t, v.InjectDecoder(decoder))
+	return v
+}
+
+func appRequest(t *testing.T, app *v1alpha1.App) admission.Request {
+	raw, err := json.Marshal(app)
+	assert.NoError(t, err)
+	return admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Name:      app.Name,
+			Operation: admissionv1beta1.Create,
+			Kind:      metav1.GroupVersionKind{Group: "k11n.dev", Version: "v1alpha1", Kind: "App"},
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+func TestValidateApp(t *testing.T) {
+	v := newTestValidator(t)
+	app := &v1alpha1.App{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "k11n.dev/v1alpha1", Kind: "App"},
+		ObjectMeta: metav1.ObjectMeta{Name: "frontend"},
+		Spec: v1alpha1.AppSpec{
+			Image: "frontend",
+			AppCommonSpec: v1alpha1.AppCommonSpec{
+				Dependencies: []v1alpha1.AppReference{{Name: "backend", Port: "grpc"}},
+			},
+			Targets: []v1alpha1.TargetConfig{{Name: "staging"}},
+		},
+	}
+	res := v.Handle(context.TODO(), appRequest(t, app))
+	assert.True(t, res.Allowed)
+
+	app.Spec.Targets = append(app.Spec.Targets, v1alpha1.TargetConfig{Name: "development"})
+	app.Spec.Dependencies = []v1alpha1.AppReference{
+		{Name: "backend", Port: "http"},
+		{Name: "database"},
+	}
+	app.Spec.Configs = []string{"common"}
+	res = v.Handle(context.TODO(), appRequest(t, app))
+	assert.False(t, res.Allowed)
+
+	var fields []string
+	for _, cause := range res.Result.Details.Causes {
+		fields = append(fields, cause.Field)
+	}
+	assert.ElementsMatch(t, []string{
+		"spec.targets[1].name",
+		"spec.dependencies[0].port",
+		"spec.dependencies[1].name",
+		"spec.configs[0]",
+	}, fields)
+}
@@ -0,0 +1,82 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	convertible "sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/conversion"
+
+	"github.com/k11n/konstellation/api/v1alpha1"
+	"github.com/k11n/konstellation/api/v1beta1"
+)
+
+// SetupWithManager registers the admission webhooks with the manager's webhook server
+func SetupWithManager(mgr ctrl.Manager) {
+	server := mgr.GetWebhookServer()
+	log := ctrl.Log.WithName("webhooks")
+	kclient := mgr.GetClient()
+
+	server.Register("/convert", &conversion.Webhook{})
+	server.Register("/mutate-k11n-dev-v1alpha1-app", &webhook.Admission{
+		Handler: &AppDefaulter{Client: kclient, Log: log.WithName("AppDefaulter")},
+	})
+	server.Register("/validate-k11n-dev-v1alpha1-app", &webhook.Admission{
+		Handler: NewAppValidator(kclient, log.WithName("App")),
+	})
+	server.Register("/validate-k11n-dev-v1alpha1-appconfig", &webhook.Admission{
+		Handler: NewAppConfigValidator(kclient, log.WithName("AppConfig")),
+	})
+	server.Register("/validate-k11n-dev-v1alpha1-linkedserviceaccount", &webhook.Admission{
+		Handler: NewLinkedServiceAccountValidator(kclient, log.WithName("LinkedServiceAccount")),
+	})
+	server.Register("/validate-k11n-dev-v1alpha1-nodepool", &webhook.Admission{
+		Handler: NewNodepoolValidator(kclient, log.WithName("Nodepool")),
+	})
+}
+
+// decode reads the request object into obj. Handlers work with v1alpha1 types, objects submitted as
+// v1beta1 are converted first
+func decode(decoder *admission.Decoder, req admission.Request, obj runtime.Object) error {
+	if req.Kind.Version != v1beta1.GroupVersion.Version {
+		return decoder.Decode(req, obj)
+	}
+	src, hub, err := hubFor(obj)
+	if err != nil {
+		return err
+	}
+	if err = decoder.Decode(req, hub); err != nil {
+		return err
+	}
+	return src.ConvertFrom(hub)
+}
+
+// encode serializes obj in the version of the request
+func encode(req admission.Request, obj runtime.Object) ([]byte, error) {
+	if req.Kind.Version != v1beta1.GroupVersion.Version {
+		return json.Marshal(obj)
+	}
+	src, hub, err := hubFor(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err = src.ConvertTo(hub); err != nil {
+		return nil, err
+	}
+	hub.GetObjectKind().SetGroupVersionKind(v1beta1.GroupVersion.WithKind(req.Kind.Kind))
+	return json.Marshal(hub)
+}
+
+func hubFor(obj runtime.Object) (convertible.Convertible, convertible.Hub, error) {
+	switch o := obj.(type) {
+	case *v1alpha1.App:
+		return o, &v1beta1.App{}, nil
+	case *v1alpha1.AppConfig:
+		return o, &v1beta1.AppConfig{}, nil
+	}
+	return nil, nil, fmt.Errorf("%T does not have a v1beta1 version", obj)
+}